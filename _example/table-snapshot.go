@@ -0,0 +1,62 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/fioprotocol/fio-go"
+	"github.com/fioprotocol/fio-go/eos"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// example of saving a table snapshot to disk, and comparing it to the previous snapshot. For example, to audit
+// changes to fees:
+//
+//    go run table-snapshot.go -u https://testnet.fioprotocol.io -t fio.fee::fiofees -d ./snapshots
+
+func main() {
+	var url, table, dir string
+	flag.StringVar(&url, "u", "https://testnet.fioprotocol.io", "nodeos API url")
+	flag.StringVar(&table, "t", "fio.fee::fiofees", "table to snapshot, as code::table")
+	flag.StringVar(&dir, "d", ".", "directory to store snapshots in")
+	flag.Parse()
+
+	// error helper
+	log.SetFlags(log.LstdFlags | log.Lshortfile)
+	fatal := func(err error) {
+		if err != nil {
+			trace := log.Output(2, err.Error())
+			log.Fatal(trace)
+		}
+	}
+
+	parts := strings.Split(table, "::")
+	if len(parts) != 2 {
+		log.Fatal("table should be in the format code::table")
+	}
+	api := &fio.API{API: eos.New(url)}
+
+	snap, err := api.SnapshotTable(parts[0], parts[1])
+	fatal(err)
+	fmt.Printf("read %d rows from %s at block %d\n", len(snap.Rows), table, snap.HeadBlock)
+
+	file := filepath.Join(dir, fmt.Sprintf("%s-%s.json", parts[0], parts[1]))
+	previous, err := fio.LoadTableSnapshot(file)
+	switch {
+	case os.IsNotExist(err):
+		fmt.Println("no previous snapshot, nothing to compare")
+	case err != nil:
+		fatal(err)
+	default:
+		changes, err := fio.DiffTableSnapshots(previous, snap)
+		fatal(err)
+		fmt.Printf("%d changes since block %d\n", len(changes), previous.HeadBlock)
+		for _, c := range changes {
+			fmt.Println(c)
+		}
+	}
+
+	fatal(snap.Save(file))
+}
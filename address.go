@@ -10,8 +10,6 @@ import (
 	"io/ioutil"
 	"math"
 	"net/http"
-	"regexp"
-	"strconv"
 	"time"
 
 	"crypto/sha1" // #nosec
@@ -29,17 +27,11 @@ type Address string
 //       only one @ and at least one a-z0-9 on either side of @.
 //       a-z0-9 is required on either side of any dash
 //    Case-insensitive
+//
+// Use ValidateAddress to get the reasons an address is invalid.
 func (a Address) Valid() (ok bool) {
-	if len(string(a)) < 3 || len(string(a)) > 64 {
-		return false
-	}
-	if bad, err := regexp.MatchString(`(?:--|@@|@.*@|-@|@-|^-|-$)`, string(a)); bad || err != nil {
-		return false
-	}
-	if match, err := regexp.MatchString(`^[a-zA-Z0-9-]+[@][a-zA-Z0-9-]+$`, string(a)); err != nil || !match {
-		return false
-	}
-	return true
+	_, err := ValidateAddress(string(a))
+	return err == nil
 }
 
 // RegAddress Registers a FIO Address on the FIO blockchain
@@ -67,6 +59,19 @@ func NewRegAddress(actor eos.AccountName, address Address, ownerPubKey string) (
 	), true
 }
 
+// NewValidRegAddress is the same as NewRegAddress, but returns a *ValidationError describing why the address or
+// owner's public key is invalid.
+func NewValidRegAddress(actor eos.AccountName, address Address, ownerPubKey string) (*Action, error) {
+	if _, err := ValidateAddress(string(address)); err != nil {
+		return nil, err
+	}
+	if err := validatePubKey("owner_fio_public_key", ownerPubKey); err != nil {
+		return nil, err
+	}
+	a, _ := NewRegAddress(actor, address, ownerPubKey)
+	return a, nil
+}
+
 // MustNewRegAddress panics on a bad address, but allows embedding because it only returns one value
 func MustNewRegAddress(actor eos.AccountName, address Address, ownerPubKey string) (action *Action) {
 	a, ok := NewRegAddress(actor, address, ownerPubKey)
@@ -98,6 +103,15 @@ func NewBurnAddress(actor eos.AccountName, address Address) (action *Action, ok
 	), true
 }
 
+// NewValidBurnAddress is the same as NewBurnAddress, but returns a *ValidationError for an invalid address
+func NewValidBurnAddress(actor eos.AccountName, address Address) (*Action, error) {
+	if _, err := ValidateAddress(string(address)); err != nil {
+		return nil, err
+	}
+	a, _ := NewBurnAddress(actor, address)
+	return a, nil
+}
+
 func MustNewBurnAddress(actor eos.AccountName, address Address) (action *Action) {
 	a, ok := NewBurnAddress(actor, address)
 	if !ok {
@@ -128,54 +142,55 @@ type TokenPubAddr struct {
 
 // NewAddAddress adds a single public address
 func NewAddAddress(actor eos.AccountName, fioAddress Address, token string, chain string, publicAddress string) (action *Action, ok bool) {
-	if !fioAddress.Valid() {
-		return nil, false
-	}
-	// ensure both chain and token are not empty
-	if token != "" && chain == "" {
-		token = chain
-	} else if chain != "" && token == "" {
-		chain = token
-	} else if chain == "" && token == "" {
-		return nil, false
-	}
-	return NewAction(
-		"fio.address", "addaddress", actor,
-		AddAddress{
-			FioAddress:      string(fioAddress),
-			PublicAddresses: []TokenPubAddr{{TokenCode: token, ChainCode: chain, PublicAddress: publicAddress}},
-			MaxFee:          Tokens(GetMaxFee(FeeAddPubAddress)),
-			Tpid:            CurrentTpid(),
-			Actor:           actor,
-		},
-	), true
+	a, err := NewValidAddAddress(actor, fioAddress, token, chain, publicAddress)
+	return a, err == nil
+}
+
+// NewValidAddAddress is the same as NewAddAddress, but returns a *ValidationError describing an invalid address,
+// chain code, token code, or public address. If only one of token or chain is provided it is used for both.
+func NewValidAddAddress(actor eos.AccountName, fioAddress Address, token string, chain string, publicAddress string) (*Action, error) {
+	return NewValidAddAddresses(actor, fioAddress, []TokenPubAddr{{TokenCode: token, ChainCode: chain, PublicAddress: publicAddress}})
 }
 
-// NewAddAddresses adds multiple public addresses at a time
+// NewAddAddresses adds multiple public addresses at a time. ok is false if more than 5 addresses are supplied, the
+// action was previously built and rejected by the chain, use NewValidAddAddresses to find out why.
 func NewAddAddresses(actor eos.AccountName, fioAddress Address, addrs []TokenPubAddr) (action *Action, ok bool) {
-	if !fioAddress.Valid() {
-		return nil, false
+	a, err := NewValidAddAddresses(actor, fioAddress, addrs)
+	return a, err == nil
+}
+
+// NewValidAddAddresses is the same as NewAddAddresses, but returns a *ValidationError describing the first
+// invalid value. Between 1 and 5 public addresses may be added in a single action. Public addresses are checked
+// using the chain's PubAddressValidator, see RegisterPubAddressValidator.
+func NewValidAddAddresses(actor eos.AccountName, fioAddress Address, addrs []TokenPubAddr) (*Action, error) {
+	if _, err := ValidateAddress(string(fioAddress)); err != nil {
+		return nil, err
+	}
+	if len(addrs) == 0 || len(addrs) > 5 {
+		return nil, errors.New("between 1 and 5 public addresses may be added at a time")
 	}
 	// fixup struct so both chain code and token code exist
-	for _, a := range addrs {
-		if a.TokenCode != "" && a.ChainCode == "" {
-			a.TokenCode = a.ChainCode
-		} else if a.ChainCode != "" && a.TokenCode == "" {
-			a.ChainCode = a.TokenCode
-		} else if a.ChainCode == "" && a.TokenCode == "" {
-			return nil, false
+	fixed := make([]TokenPubAddr, len(addrs))
+	for i := range addrs {
+		tpa, err := ValidateTokenPubAddr(addrs[i])
+		if err != nil {
+			return nil, err
+		}
+		if err = ValidateChainPubAddress(tpa.ChainCode, tpa.PublicAddress); err != nil {
+			return nil, err
 		}
+		fixed[i] = tpa
 	}
 	return NewAction(
 		"fio.address", "addaddress", actor,
 		AddAddress{
 			FioAddress:      string(fioAddress),
-			PublicAddresses: addrs,
+			PublicAddresses: fixed,
 			MaxFee:          Tokens(GetMaxFee(FeeAddPubAddress)),
 			Tpid:            CurrentTpid(),
 			Actor:           actor,
 		},
-	), true
+	), nil
 }
 
 // RegDomain registers a FIO Domain on the FIO blockchain
@@ -208,6 +223,18 @@ type RenewDomain struct {
 	Actor     eos.AccountName `json:"actor"`
 }
 
+// NewValidRegDomain is the same as NewRegDomain, but returns a *ValidationError describing why the domain or
+// owner's public key is invalid.
+func NewValidRegDomain(actor eos.AccountName, domain string, ownerPubKey string) (*Action, error) {
+	if _, err := ValidateDomain(domain); err != nil {
+		return nil, err
+	}
+	if err := validatePubKey("owner_fio_public_key", ownerPubKey); err != nil {
+		return nil, err
+	}
+	return NewRegDomain(actor, domain, ownerPubKey), nil
+}
+
 func NewRenewDomain(actor eos.AccountName, domain string) *Action {
 	return NewAction(
 		"fio.address", "renewdomain", actor,
@@ -220,6 +247,14 @@ func NewRenewDomain(actor eos.AccountName, domain string) *Action {
 	)
 }
 
+// NewValidRenewDomain is the same as NewRenewDomain, but returns a *ValidationError for an invalid domain
+func NewValidRenewDomain(actor eos.AccountName, domain string) (*Action, error) {
+	if _, err := ValidateDomain(domain); err != nil {
+		return nil, err
+	}
+	return NewRenewDomain(actor, domain), nil
+}
+
 // TransferDom (future) transfers ownership of a domain
 type TransferDom struct {
 	FioDomain            string          `json:"fio_domain"`
@@ -242,6 +277,17 @@ func NewTransferDom(actor eos.AccountName, domain string, newOwnerPubKey string)
 	)
 }
 
+// NewValidTransferDom is the same as NewTransferDom, but returns a *ValidationError for an invalid domain or key
+func NewValidTransferDom(actor eos.AccountName, domain string, newOwnerPubKey string) (*Action, error) {
+	if _, err := ValidateDomain(domain); err != nil {
+		return nil, err
+	}
+	if err := validatePubKey("new_owner_fio_public_key", newOwnerPubKey); err != nil {
+		return nil, err
+	}
+	return NewTransferDom(actor, domain, newOwnerPubKey), nil
+}
+
 // RenewAddress extends the expiration of an address by a year, and refreshes the bundle
 type RenewAddress struct {
 	FioAddress string          `json:"fio_address"`
@@ -262,6 +308,14 @@ func NewRenewAddress(actor eos.AccountName, address string) *Action {
 	)
 }
 
+// NewValidRenewAddress is the same as NewRenewAddress, but returns a *ValidationError for an invalid address
+func NewValidRenewAddress(actor eos.AccountName, address string) (*Action, error) {
+	if _, err := ValidateAddress(address); err != nil {
+		return nil, err
+	}
+	return NewRenewAddress(actor, address), nil
+}
+
 // TransferAddress (future) transfers ownership of a FIO address
 type TransferAddress struct {
 	FioAddress           string          `json:"fio_address"`
@@ -284,6 +338,18 @@ func NewTransferAddress(actor eos.AccountName, address Address, newOwnerPubKey s
 	)
 }
 
+// NewValidTransferAddress is the same as NewTransferAddress, but returns a *ValidationError for an invalid
+// address or key
+func NewValidTransferAddress(actor eos.AccountName, address Address, newOwnerPubKey string) (*Action, error) {
+	if _, err := ValidateAddress(string(address)); err != nil {
+		return nil, err
+	}
+	if err := validatePubKey("new_owner_fio_public_key", newOwnerPubKey); err != nil {
+		return nil, err
+	}
+	return NewTransferAddress(actor, address, newOwnerPubKey), nil
+}
+
 // ExpDomain is used by a test contract and not available on mainnet
 //
 // Deprecated: only used in development environments
@@ -344,36 +410,30 @@ type BurnExpiredRange struct {
 	Limit  int32 `json:"limit"`
 }
 
+// ErrNoExpired is returned by GetExpiredOffset when no domains have expired
+var ErrNoExpired = errors.New("no results for GetExpiredOffset")
+
 type expiredIdOnly struct {
 	Id int64 `json:"id"`
 }
 
 // GetExpiredOffset finds the first FIO domain in the fio.address::domains table, and returns the index for that domain.
+// ErrNoExpired is returned if there aren't any.
 func (api *API) GetExpiredOffset(descending bool) (int64, error) {
-	gtro, err := api.GetTableRowsOrder(GetTableRowsOrderRequest{
-		Code:       "fio.address",
-		Scope:      "fio.address",
-		Table:      "domains",
-		LowerBound: "0",
-		UpperBound: strconv.Itoa(int(time.Now().Add(-90*24*time.Hour).UTC().Unix())),
-		Limit:      1,
-		KeyType:    "i64",
-		Index:      "3",
-		JSON:       true,
-		Reverse:    descending,
-	})
-	if err != nil {
-		return 0, err
+	q := NewTableQuery(api, "fio.address", "domains").Index("expiration").
+		Lower(0).Upper(time.Now().Add(-90 * 24 * time.Hour))
+	if descending {
+		q.Reverse()
 	}
-	ids := make([]expiredIdOnly, 0)
-	err = json.Unmarshal(gtro.Rows, &ids)
+	id := expiredIdOnly{}
+	found, err := q.First(&id)
 	if err != nil {
 		return 0, err
 	}
-	if len(ids) == 0 || ids[0].Id == 0 {
-		return 0, errors.New("no results for GetExpiredOffset")
+	if !found || id.Id == 0 {
+		return 0, ErrNoExpired
 	}
-	return ids[0].Id, nil
+	return id.Id, nil
 }
 
 // SetDomainPub changes the permissions for a domain, allowing (or not) anyone to register an address
@@ -402,6 +462,14 @@ func NewSetDomainPub(actor eos.AccountName, domain string, public bool) *Action
 	)
 }
 
+// NewValidSetDomainPub is the same as NewSetDomainPub, but returns a *ValidationError for an invalid domain
+func NewValidSetDomainPub(actor eos.AccountName, domain string, public bool) (*Action, error) {
+	if _, err := ValidateDomain(domain); err != nil {
+		return nil, err
+	}
+	return NewSetDomainPub(actor, domain, public), nil
+}
+
 type PubAddress struct {
 	PublicAddress string `json:"public_address"`
 	Message       string `json:"message"`
@@ -424,6 +492,18 @@ type getAllPublicResp struct {
 
 // GetAllPublic fetches all public addresses for an address.
 func (api *API) GetAllPublic(fioAddress Address) ([]TokenPubAddr, error) {
+	addrs, found, err := api.getAllPublic(fioAddress)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, errors.New("empty result")
+	}
+	return addrs, nil
+}
+
+// getAllPublic is GetAllPublic, but reports an unregistered address as not found instead of an error
+func (api *API) getAllPublic(fioAddress Address) ([]TokenPubAddr, bool, error) {
 	gtr, err := api.GetTableRows(eos.GetTableRowsRequest{
 		Code:       "fio.address",
 		Scope:      "fio.address",
@@ -436,17 +516,18 @@ func (api *API) GetAllPublic(fioAddress Address) ([]TokenPubAddr, error) {
 		JSON:       true,
 	})
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
 	result := make([]getAllPublicResp, 0)
 	err = json.Unmarshal(gtr.Rows, &result)
 	if len(result) == 0 {
-		return nil, errors.New("empty result")
+		return nil, false, nil
 	}
-	return result[0].Addresses, nil
+	return result[0].Addresses, true, nil
 }
 
-// PubAddressLookup finds a public address for a user, given a currency key
+// PubAddressLookup finds a public address for a user, given a currency key. A public address that is not found is not
+// an error, but any other error response from the API is.
 //  pubAddress, ok, err := api.PubAddressLookup(fio.Address("alice:fio", "BTC")
 func (api *API) PubAddressLookup(fioAddress Address, chain string, token string) (address PubAddress, found bool, err error) {
 	if token == "" {
@@ -475,6 +556,13 @@ func (api *API) PubAddressLookup(fioAddress Address, chain string, token string)
 	if err != nil {
 		return PubAddress{}, false, err
 	}
+	switch res.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return PubAddress{}, false, nil
+	default:
+		return PubAddress{}, false, errors.New(fmt.Sprintf("error %d: %s", res.StatusCode, string(body)))
+	}
 	err = json.Unmarshal(body, &address)
 	if err != nil {
 		return PubAddress{}, false, err
@@ -750,17 +838,24 @@ type RemoveAddrReq struct {
 
 // NewRemoveAddrReq allows removal of public token/chain addresses
 func NewRemoveAddrReq(fioAddress Address, toRemove []TokenPubAddr, actor eos.AccountName) (remove *Action, err error) {
-	if !fioAddress.Valid() {
-		return nil, errors.New("invalid address")
+	if fioAddress, err = ValidateAddress(string(fioAddress)); err != nil {
+		return nil, err
 	}
 	if toRemove == nil || len(toRemove) == 0 {
 		return nil, errors.New("empty address list supplied")
 	}
+	// normalized copies are sent, so that the chain and token codes match what was added
+	normalized := make([]TokenPubAddr, len(toRemove))
+	for i := range toRemove {
+		if normalized[i], err = ValidateTokenPubAddr(toRemove[i]); err != nil {
+			return nil, err
+		}
+	}
 	return NewAction(
 		"fio.address", "remaddress", actor,
 		RemoveAddrReq{
 			FioAddress:      string(fioAddress),
-			PublicAddresses: toRemove,
+			PublicAddresses: normalized,
 			MaxFee:          Tokens(GetMaxFee(FeeRemovePubAddress)),
 			Actor:           actor,
 			Tpid:            CurrentTpid(),
@@ -778,8 +873,8 @@ type RemoveAllAddrReq struct {
 
 // NewRemoveAllAddrReq allows removal of ALL public token/chain addresses
 func NewRemoveAllAddrReq(fioAddress Address, actor eos.AccountName) (remove *Action, err error) {
-	if !fioAddress.Valid() {
-		return nil, errors.New("invalid address")
+	if _, err = ValidateAddress(string(fioAddress)); err != nil {
+		return nil, err
 	}
 	return NewAction(
 		"fio.address", "remalladdr", actor,
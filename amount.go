@@ -0,0 +1,196 @@
+package fio
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/fioprotocol/fio-go/eos"
+	"math"
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+// SufPerFio is the number of SUFs (the smallest unit) in 1 FIO
+const SufPerFio Amount = 1_000_000_000
+
+// FioAssetSymbol is the symbol used by the fio.token contract
+var FioAssetSymbol = eos.Symbol{Precision: 9, Symbol: "FIO"}
+
+// Amount is an exact quantity of FIO, stored as SUFs. It avoids the rounding errors of using a float64:
+//
+//	amt, err := fio.ParseAmount("12.345678901 FIO")
+//	fmt.Println(amt.Suf()) // 12345678901
+//	fmt.Println(amt)       // 12.345678901 FIO
+//
+// JSON is encoded as the number of SUFs, the same as amounts in the FIO API and contract actions. When decoding, a
+// string with a decimal point or FIO suffix, such as "1.5 FIO" or "1.5", is parsed as FIO. Other strings, such as
+// "1500000000", are SUFs: the API quotes large integers.
+type Amount uint64
+
+// Fio converts a whole number of FIO to an Amount, it does not check for overflow
+func Fio(tokens uint64) Amount {
+	return Amount(tokens) * SufPerFio
+}
+
+// ParseAmount reads a decimal amount of FIO, with up to nine decimal places and an optional "FIO" suffix
+func ParseAmount(s string) (Amount, error) {
+	orig := s
+	s = strings.TrimSpace(s)
+	s = strings.TrimSpace(strings.TrimSuffix(s, "FIO"))
+	if s == "" {
+		return 0, fmt.Errorf("invalid amount %q", orig)
+	}
+	whole, frac := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		whole, frac = s[:i], s[i+1:]
+	}
+	if len(frac) > 9 {
+		return 0, fmt.Errorf("invalid amount %q: more than 9 decimal places", orig)
+	}
+	if whole == "" {
+		whole = "0"
+	}
+	if frac == "" && strings.HasSuffix(s, ".") {
+		return 0, fmt.Errorf("invalid amount %q", orig)
+	}
+	for _, part := range []string{whole, frac} {
+		for _, c := range part {
+			if c < '0' || c > '9' {
+				return 0, fmt.Errorf("invalid amount %q", orig)
+			}
+		}
+	}
+	w, err := strconv.ParseUint(whole, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q: %s", orig, err)
+	}
+	var f uint64
+	if frac != "" {
+		f, _ = strconv.ParseUint(frac+strings.Repeat("0", 9-len(frac)), 10, 64)
+	}
+	hi, lo := bits.Mul64(w, uint64(SufPerFio))
+	if hi != 0 {
+		return 0, fmt.Errorf("invalid amount %q: overflow", orig)
+	}
+	suf, carry := bits.Add64(lo, f, 0)
+	if carry != 0 {
+		return 0, fmt.Errorf("invalid amount %q: overflow", orig)
+	}
+	return Amount(suf), nil
+}
+
+// MustParseAmount is the same as ParseAmount, but panics on error. It is intended for constants.
+func MustParseAmount(s string) Amount {
+	a, err := ParseAmount(s)
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+// Suf returns the number of SUFs, the value used by contract actions
+func (a Amount) Suf() uint64 {
+	return uint64(a)
+}
+
+// Float converts to FIO as a float64, which may lose precision and should only be used for display
+func (a Amount) Float() float64 {
+	return float64(a) / float64(SufPerFio)
+}
+
+// Decimal formats the amount as FIO with all nine decimal places, without a symbol
+func (a Amount) Decimal() string {
+	return fmt.Sprintf("%d.%09d", a/SufPerFio, a%SufPerFio)
+}
+
+// String formats the amount, for example "12.345678901 FIO"
+func (a Amount) String() string {
+	return a.Decimal() + " FIO"
+}
+
+// Add returns the sum, or an error if it overflows
+func (a Amount) Add(b Amount) (Amount, error) {
+	sum, carry := bits.Add64(uint64(a), uint64(b), 0)
+	if carry != 0 {
+		return 0, errors.New("amount overflow")
+	}
+	return Amount(sum), nil
+}
+
+// Sub returns the difference, or an error if b is larger than a
+func (a Amount) Sub(b Amount) (Amount, error) {
+	if b > a {
+		return 0, fmt.Errorf("cannot subtract %s from %s", b, a)
+	}
+	return a - b, nil
+}
+
+// Mul multiplies by a whole number, or returns an error if it overflows
+func (a Amount) Mul(n uint64) (Amount, error) {
+	hi, lo := bits.Mul64(uint64(a), n)
+	if hi != 0 {
+		return 0, errors.New("amount overflow")
+	}
+	return Amount(lo), nil
+}
+
+// Asset converts to an eos.Asset with the FIO symbol
+func (a Amount) Asset() (eos.Asset, error) {
+	if a > math.MaxInt64 {
+		return eos.Asset{}, errors.New("amount is too large for an asset")
+	}
+	return eos.Asset{Amount: eos.Int64(a), Symbol: FioAssetSymbol}, nil
+}
+
+// AmountFromAsset converts an eos.Asset, which must be a non-negative FIO amount
+func AmountFromAsset(asset eos.Asset) (Amount, error) {
+	if asset.Symbol.Symbol != FioAssetSymbol.Symbol || asset.Symbol.Precision != FioAssetSymbol.Precision {
+		return 0, fmt.Errorf("asset symbol %d,%s is not FIO", asset.Symbol.Precision, asset.Symbol.Symbol)
+	}
+	if asset.Amount < 0 {
+		return 0, errors.New("asset amount is negative")
+	}
+	return Amount(asset.Amount), nil
+}
+
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.FormatUint(uint64(a), 10)), nil
+}
+
+func (a *Amount) UnmarshalJSON(b []byte) error {
+	if len(b) > 0 && b[0] == '"' {
+		var s string
+		if err := json.Unmarshal(b, &s); err != nil {
+			return err
+		}
+		if trimmed := strings.TrimSpace(s); !strings.Contains(trimmed, ".") && !strings.HasSuffix(trimmed, "FIO") {
+			suf, err := strconv.ParseUint(trimmed, 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid amount %q: %s", s, err)
+			}
+			*a = Amount(suf)
+			return nil
+		}
+		parsed, err := ParseAmount(s)
+		if err != nil {
+			return err
+		}
+		*a = parsed
+		return nil
+	}
+	suf, err := strconv.ParseUint(string(b), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid amount %s: %s", string(b), err)
+	}
+	*a = Amount(suf)
+	return nil
+}
+
+// floatToAmount converts FIO as a float64, rounding to the nearest SUF
+func floatToAmount(tokens float64) Amount {
+	if tokens <= 0 || math.IsNaN(tokens) {
+		return 0
+	}
+	return Amount(math.Round(tokens * float64(SufPerFio)))
+}
@@ -0,0 +1,107 @@
+package fio
+
+import (
+	"encoding/json"
+	"github.com/fioprotocol/fio-go/eos"
+	"math"
+	"testing"
+)
+
+func TestParseAmount(t *testing.T) {
+	good := map[string]Amount{
+		"12.345678901 FIO":      12_345_678_901,
+		"12.345678901":          12_345_678_901,
+		"1FIO":                  1_000_000_000,
+		"0.000000001":           1,
+		".5":                    500_000_000,
+		" 2 FIO ":               2_000_000_000,
+		"0":                     0,
+		"18446744073.709551615": math.MaxUint64,
+	}
+	for s, want := range good {
+		got, err := ParseAmount(s)
+		if err != nil || got != want {
+			t.Errorf("ParseAmount(%q) = %d, %v want %d", s, got, err, want)
+		}
+	}
+	for _, s := range []string{"", "FIO", "-1", "1.", "1.0000000001", "1,5", "1.5 EOS", "18446744073.709551616", "1e9"} {
+		if _, err := ParseAmount(s); err == nil {
+			t.Errorf("expected error parsing %q", s)
+		}
+	}
+	if s := MustParseAmount("12.345678901").String(); s != "12.345678901 FIO" {
+		t.Error("unexpected string", s)
+	}
+	if s := Amount(5).Decimal(); s != "0.000000005" {
+		t.Error("unexpected decimal", s)
+	}
+}
+
+func TestAmountMath(t *testing.T) {
+	a := Fio(2)
+	if sum, err := a.Add(MustParseAmount("0.1")); err != nil || sum != 2_100_000_000 {
+		t.Error("unexpected sum", sum, err)
+	}
+	if _, err := Amount(math.MaxUint64).Add(1); err == nil {
+		t.Error("expected overflow")
+	}
+	if diff, err := a.Sub(1); err != nil || diff != 1_999_999_999 {
+		t.Error("unexpected difference", diff, err)
+	}
+	if _, err := a.Sub(a + 1); err == nil {
+		t.Error("expected underflow")
+	}
+	if prod, err := a.Mul(3); err != nil || prod != Fio(6) {
+		t.Error("unexpected product", prod, err)
+	}
+	if _, err := a.Mul(math.MaxUint64); err == nil {
+		t.Error("expected overflow")
+	}
+	// float conversions round to the nearest SUF
+	for f, want := range map[float64]uint64{0.3: 300_000_000, 1.1: 1_100_000_000, 12.345678901: 12_345_678_901, 0: 0, -1: 0} {
+		if Tokens(f) != want || GetMaxFeeAmount("not_a_fee") != 0 {
+			t.Errorf("Tokens(%v) = %d", f, Tokens(f))
+		}
+	}
+}
+
+func TestAmountEncoding(t *testing.T) {
+	type row struct {
+		Amount Amount `json:"amount"`
+	}
+	j, err := json.Marshal(row{Amount: 1_500_000_000})
+	if err != nil || string(j) != `{"amount":1500000000}` {
+		t.Error("unexpected json", string(j), err)
+	}
+	for _, s := range []string{`{"amount":1500000000}`, `{"amount":"1.5 FIO"}`, `{"amount":"1.5"}`, `{"amount":"1500000000"}`} {
+		r := row{}
+		if err = json.Unmarshal([]byte(s), &r); err != nil || r.Amount != 1_500_000_000 {
+			t.Error("could not decode", s, r.Amount, err)
+		}
+	}
+	for _, s := range []string{`{"amount":-1}`, `{"amount":1.5}`, `{"amount":"abc"}`, `{"amount":"-1"}`} {
+		if err = json.Unmarshal([]byte(s), &row{}); err == nil {
+			t.Error("expected error decoding", s)
+		}
+	}
+
+	asset, err := Fio(3).Asset()
+	if err != nil || asset.String() != "3.000000000 FIO" {
+		t.Error("unexpected asset", asset, err)
+	}
+	if a, err := AmountFromAsset(asset); err != nil || a != Fio(3) {
+		t.Error("asset did not round trip", a, err)
+	}
+	if _, err = Amount(math.MaxUint64).Asset(); err == nil {
+		t.Error("expected error for a large asset")
+	}
+	if _, err = AmountFromAsset(eos.Asset{Amount: 1, Symbol: eos.Symbol{Precision: 4, Symbol: "EOS"}}); err == nil {
+		t.Error("expected error for another symbol")
+	}
+	if _, err = AmountFromAsset(eos.Asset{Amount: -1, Symbol: FioAssetSymbol}); err == nil {
+		t.Error("expected error for a negative asset")
+	}
+	if tr := NewTransferTokensPubKeyAmount("alice", "FIO5", MustParseAmount("1.000000001")).Data.(TransferTokensPubKey); tr.Amount != 1_000_000_001 {
+		t.Error("unexpected transfer amount", tr.Amount)
+	}
+}
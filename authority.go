@@ -0,0 +1,349 @@
+package fio
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/fioprotocol/fio-go/eos"
+	"github.com/fioprotocol/fio-go/eos/ecc"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// maxAuthorityDepth limits how far nested account permissions are followed, matching nodeos' default
+const maxAuthorityDepth = 6
+
+// ErrUnknownPermission is returned by AuthorityResolver when an account or permission does not exist, such as
+// actor@eosio.code which is only satisfied by a contract
+var ErrUnknownPermission = errors.New("unknown permission")
+
+// AuthorityResolver answers whether a set of keys or signers can satisfy an account's permission, following
+// permissions that delegate to other accounts. Permissions are cached after the first lookup, so a resolver should
+// not be reused after permissions are changed. A resolver is safe for concurrent use.
+type AuthorityResolver struct {
+	MaxDepth int // how many levels of nested account permissions are followed
+
+	api      *API
+	mux      sync.Mutex
+	accounts map[eos.AccountName][]Permission
+}
+
+// NewAuthorityResolver creates a resolver that looks up permissions with get_account
+func NewAuthorityResolver(api *API) *AuthorityResolver {
+	return &AuthorityResolver{
+		MaxDepth: maxAuthorityDepth,
+		api:      api,
+		accounts: make(map[eos.AccountName][]Permission),
+	}
+}
+
+// AddPermissions provides the permissions for an account instead of querying the API, useful for evaluating a
+// proposed permission change before it is made.
+func (r *AuthorityResolver) AddPermissions(actor eos.AccountName, perms []Permission) {
+	r.mux.Lock()
+	r.accounts[actor] = perms
+	r.mux.Unlock()
+}
+
+// Permission looks up a single permission
+func (r *AuthorityResolver) Permission(level eos.PermissionLevel) (*Permission, error) {
+	r.mux.Lock()
+	perms, ok := r.accounts[level.Actor]
+	r.mux.Unlock()
+	if !ok {
+		if r.api == nil {
+			return nil, fmt.Errorf("%w: permissions for %s are not known", ErrUnknownPermission, level.Actor)
+		}
+		var err error
+		if perms, err = r.getPermissions(level.Actor); err != nil {
+			return nil, err
+		}
+		r.mux.Lock()
+		r.accounts[level.Actor] = perms
+		r.mux.Unlock()
+	}
+	for i := range perms {
+		if perms[i].PermName == string(level.Permission) {
+			return &perms[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: account %s does not have a %s permission", ErrUnknownPermission, level.Actor, level.Permission)
+}
+
+// getPermissions queries get_account. Unlike GetFioAccount it checks the response status, so that a failing node is
+// not mistaken for an account without permissions.
+func (r *AuthorityResolver) getPermissions(actor eos.AccountName) ([]Permission, error) {
+	q := bytes.NewReader([]byte(`{"account_name": "` + string(actor) + `"}`))
+	resp, err := r.api.HttpClient.Post(r.api.BaseURL+"/v1/chain/get_account", "application/json", q)
+	if err != nil {
+		return nil, fmt.Errorf("getting account %s: %s", actor, err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("getting account %s: %s", actor, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		apiErr := eos.APIError{}
+		if json.Unmarshal(body, &apiErr) == nil && apiErr.IsUnknownKeyError() {
+			return nil, fmt.Errorf("%w: account %s does not exist", ErrUnknownPermission, actor)
+		}
+		return nil, fmt.Errorf("getting account %s: %s: %s", actor, resp.Status, string(body))
+	}
+	acc := &AccountResp{}
+	if err = json.Unmarshal(body, acc); err != nil && err.Error() != `public key should start with "FIO"` {
+		return nil, fmt.Errorf("getting account %s: %s", actor, err)
+	}
+	return acc.Permissions, nil
+}
+
+// signerSatisfies checks if a signer's permission is the same as, or a parent of, the required permission
+func (r *AuthorityResolver) signerSatisfies(signer eos.PermissionLevel, level eos.PermissionLevel) (bool, error) {
+	if signer.Actor != level.Actor {
+		return false, nil
+	}
+	current := level.Permission
+	for depth := 0; depth <= r.MaxDepth; depth++ {
+		if signer.Permission == current {
+			return true, nil
+		}
+		perm, err := r.Permission(eos.PermissionLevel{Actor: level.Actor, Permission: current})
+		if err != nil {
+			return false, err
+		}
+		if perm.Parent == "" {
+			return false, nil
+		}
+		current = eos.PermissionName(perm.Parent)
+	}
+	return false, nil
+}
+
+// AuthCheck is the result of evaluating a permission against a set of keys and signers
+type AuthCheck struct {
+	Level     eos.PermissionLevel
+	Threshold uint32
+	Weight    uint32 // weight counted towards the threshold, stops once the threshold is met
+	Satisfied bool
+	Keys      []string              // keys that were used to meet the threshold
+	Signers   []eos.PermissionLevel // signers that were used to meet the threshold
+	Missing   []eos.PermissionLevel // accounts listed in the authority that were not satisfied
+}
+
+// Check evaluates whether the provided keys and signers satisfy a permission. Keys may use either the FIO or EOS
+// prefix, a signer satisfies its own permission and any child permission. Waits are not counted. Nested account
+// permissions that don't exist, such as actor@eosio.code which is only satisfied by a contract, are treated as
+// unsatisfied and listed in Missing. Other errors, such as a failed request, are returned.
+func (r *AuthorityResolver) Check(level eos.PermissionLevel, keys []string, signers []eos.PermissionLevel) (*AuthCheck, error) {
+	available := make(map[string]bool)
+	for _, k := range keys {
+		available[normalizeKey(k)] = true
+	}
+	return r.check(level, available, signers, 0)
+}
+
+func (r *AuthorityResolver) check(level eos.PermissionLevel, keys map[string]bool, signers []eos.PermissionLevel, depth int) (*AuthCheck, error) {
+	result := &AuthCheck{
+		Level:   level,
+		Keys:    make([]string, 0),
+		Signers: make([]eos.PermissionLevel, 0),
+		Missing: make([]eos.PermissionLevel, 0),
+	}
+	for _, s := range signers {
+		ok, err := r.signerSatisfies(s, level)
+		if err != nil && (depth == 0 || !errors.Is(err, ErrUnknownPermission)) {
+			return nil, err
+		}
+		if ok {
+			result.Satisfied = true
+			result.Signers = append(result.Signers, s)
+			return result, nil
+		}
+	}
+	if depth > r.MaxDepth {
+		return result, nil
+	}
+	perm, err := r.Permission(level)
+	if err != nil {
+		if depth > 0 && errors.Is(err, ErrUnknownPermission) {
+			return result, nil
+		}
+		return nil, err
+	}
+	auth := perm.RequiredAuth
+	result.Threshold = auth.Threshold
+	for _, kw := range auth.Keys {
+		key := kw.PublicKey.String()
+		if keys[key] && result.Weight < auth.Threshold {
+			result.Weight += uint32(kw.Weight)
+			result.Keys = append(result.Keys, key)
+		}
+	}
+	for _, a := range auth.Accounts {
+		nested, err := r.check(a.Permission, keys, signers, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		if !nested.Satisfied {
+			result.Missing = append(result.Missing, a.Permission)
+			continue
+		}
+		if result.Weight < auth.Threshold {
+			result.Weight += uint32(a.Weight)
+			result.Keys = append(result.Keys, nested.Keys...)
+			result.Signers = append(result.Signers, nested.Signers...)
+		}
+	}
+	result.Satisfied = result.Weight >= auth.Threshold
+	return result, nil
+}
+
+// RequiredKeys is a local version of the get_required_keys endpoint: it selects the keys from those available that
+// are needed to authorize every action in a transaction, and returns an error if the available keys are not enough.
+func (r *AuthorityResolver) RequiredKeys(tx *eos.Transaction, available []string) ([]string, error) {
+	if tx == nil {
+		return nil, errors.New("transaction is nil")
+	}
+	seen := make(map[string]bool)
+	required := make([]string, 0)
+	for _, level := range transactionAuths(tx) {
+		result, err := r.Check(level, available, nil)
+		if err != nil {
+			return nil, err
+		}
+		if !result.Satisfied {
+			return nil, fmt.Errorf("available keys cannot satisfy %s@%s", level.Actor, level.Permission)
+		}
+		for _, k := range result.Keys {
+			if !seen[k] {
+				seen[k] = true
+				required = append(required, k)
+			}
+		}
+	}
+	sort.Strings(required)
+	return required, nil
+}
+
+// transactionAuths lists each distinct permission used to authorize the actions in a transaction
+func transactionAuths(tx *eos.Transaction) []eos.PermissionLevel {
+	seen := make(map[eos.PermissionLevel]bool)
+	levels := make([]eos.PermissionLevel, 0)
+	actions := make([]*eos.Action, 0, len(tx.ContextFreeActions)+len(tx.Actions))
+	actions = append(actions, tx.ContextFreeActions...)
+	for _, act := range append(actions, tx.Actions...) {
+		for _, level := range act.Authorization {
+			if !seen[level] {
+				seen[level] = true
+				levels = append(levels, level)
+			}
+		}
+	}
+	return levels
+}
+
+// normalizeKey converts a public key to the same format as ecc.PublicKey.String, accepting the EOS prefix
+func normalizeKey(key string) string {
+	if strings.HasPrefix(key, "EOS") {
+		key = pubFromEos(key)
+	}
+	if pub, err := ecc.NewPublicKey(key); err == nil {
+		return pub.String()
+	}
+	return key
+}
+
+// AuthNode is a permission in a tree built by AuthorityResolver.Tree
+type AuthNode struct {
+	Level      eos.PermissionLevel
+	Weight     uint16 // weight within the parent's authority, zero for the root
+	Threshold  uint32
+	Keys       []KeyWeight
+	Waits      []eos.WaitWeight
+	Accounts   []*AuthNode
+	Truncated  bool // the depth limit was reached, the permission was not looked up
+	Unresolved bool // the permission does not exist, such as actor@eosio.code
+}
+
+// Tree resolves a permission and every account permission it delegates to. Nested permissions that don't exist are
+// marked Unresolved.
+func (r *AuthorityResolver) Tree(level eos.PermissionLevel) (*AuthNode, error) {
+	return r.tree(level, 0, 0)
+}
+
+func (r *AuthorityResolver) tree(level eos.PermissionLevel, weight uint16, depth int) (*AuthNode, error) {
+	node := &AuthNode{Level: level, Weight: weight, Accounts: make([]*AuthNode, 0)}
+	if depth > r.MaxDepth {
+		node.Truncated = true
+		return node, nil
+	}
+	perm, err := r.Permission(level)
+	if err != nil {
+		if depth > 0 && errors.Is(err, ErrUnknownPermission) {
+			node.Unresolved = true
+			return node, nil
+		}
+		return nil, err
+	}
+	node.Threshold = perm.RequiredAuth.Threshold
+	node.Keys = perm.RequiredAuth.Keys
+	node.Waits = perm.RequiredAuth.Waits
+	for _, a := range perm.RequiredAuth.Accounts {
+		child, err := r.tree(a.Permission, a.Weight, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		node.Accounts = append(node.Accounts, child)
+	}
+	return node, nil
+}
+
+// String prints the permission tree, with the weight of each entry in brackets
+func (n *AuthNode) String() string {
+	buf := bytes.NewBufferString("")
+	n.print(buf, "", "")
+	return buf.String()
+}
+
+func (n *AuthNode) print(buf *bytes.Buffer, prefix string, childPrefix string) {
+	weight := ""
+	if n.Weight > 0 {
+		weight = fmt.Sprintf("[%d] ", n.Weight)
+	}
+	if n.Truncated {
+		buf.WriteString(fmt.Sprintf("%s%s%s@%s (depth limit reached)\n", prefix, weight, n.Level.Actor, n.Level.Permission))
+		return
+	}
+	if n.Unresolved {
+		buf.WriteString(fmt.Sprintf("%s%s%s@%s (unresolved)\n", prefix, weight, n.Level.Actor, n.Level.Permission))
+		return
+	}
+	buf.WriteString(fmt.Sprintf("%s%s%s@%s threshold %d\n", prefix, weight, n.Level.Actor, n.Level.Permission, n.Threshold))
+
+	lines := make([]string, 0)
+	for _, k := range n.Keys {
+		lines = append(lines, fmt.Sprintf("[%d] %s", k.Weight, k.PublicKey.String()))
+	}
+	for _, w := range n.Waits {
+		lines = append(lines, fmt.Sprintf("[%d] wait %ds", w.Weight, w.WaitSec))
+	}
+	total := len(lines) + len(n.Accounts)
+	for i, line := range lines {
+		branch := "├── "
+		if i == total-1 {
+			branch = "└── "
+		}
+		buf.WriteString(childPrefix + branch + line + "\n")
+	}
+	for i, child := range n.Accounts {
+		if len(lines)+i == total-1 {
+			child.print(buf, childPrefix+"└── ", childPrefix+"    ")
+		} else {
+			child.print(buf, childPrefix+"├── ", childPrefix+"│   ")
+		}
+	}
+}
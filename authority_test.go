@@ -0,0 +1,191 @@
+package fio
+
+import (
+	"errors"
+	"github.com/fioprotocol/fio-go/eos"
+	"github.com/fioprotocol/fio-go/eos/ecc"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAuthorityResolver(t *testing.T) {
+	keys := make(map[string]ecc.PublicKey)
+	for _, name := range []string{"ms", "owner", "alice", "carol", "other"} {
+		priv, err := ecc.NewRandomPrivateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys[name] = priv.PublicKey()
+	}
+	level := func(actor string) eos.PermissionLevel {
+		return eos.PermissionLevel{Actor: eos.AccountName(actor), Permission: "active"}
+	}
+	active := func(threshold uint32, keyNames []string, accounts []string) []Permission {
+		auth := Authority{Threshold: threshold}
+		for _, k := range keyNames {
+			auth.Keys = append(auth.Keys, KeyWeight{PublicKey: keys[k], Weight: 1})
+		}
+		for _, a := range accounts {
+			auth.Accounts = append(auth.Accounts, eos.PermissionLevelWeight{Permission: level(a), Weight: 1})
+		}
+		return []Permission{
+			{PermName: "owner", RequiredAuth: Authority{Threshold: 1, Keys: []KeyWeight{{PublicKey: keys["owner"], Weight: 1}}}},
+			{PermName: "active", Parent: "owner", RequiredAuth: auth},
+		}
+	}
+
+	// multisig@active is 2 of: a key, alice@active, and bob@active which delegates to carol@active
+	r := NewAuthorityResolver(nil)
+	r.AddPermissions("multisig", active(2, []string{"ms"}, []string{"alice", "bob"}))
+	r.AddPermissions("alice", active(1, []string{"alice"}, nil))
+	r.AddPermissions("bob", active(1, nil, []string{"carol"}))
+	r.AddPermissions("carol", active(1, []string{"carol"}, nil))
+
+	check := func(keyNames []string, signers []eos.PermissionLevel) *AuthCheck {
+		available := make([]string, 0)
+		for _, k := range keyNames {
+			available = append(available, keys[k].String())
+		}
+		result, err := r.Check(level("multisig"), available, signers)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	if result := check([]string{"alice"}, nil); result.Satisfied || result.Weight != 1 || len(result.Missing) != 1 || result.Missing[0].Actor != "bob" {
+		t.Errorf("one key should not satisfy %+v", result)
+	}
+	if result := check([]string{"alice", "carol"}, nil); !result.Satisfied || len(result.Keys) != 2 {
+		t.Errorf("nested key should satisfy %+v", result)
+	}
+	if result := check([]string{"ms", "alice", "carol"}, nil); !result.Satisfied || len(result.Keys) != 2 || result.Keys[0] != keys["ms"].String() {
+		t.Errorf("should stop using keys once satisfied %+v", result)
+	}
+	if result := check(nil, []eos.PermissionLevel{{Actor: "multisig", Permission: "owner"}}); !result.Satisfied {
+		t.Errorf("owner should satisfy active %+v", result)
+	}
+	if result := check(nil, []eos.PermissionLevel{{Actor: "alice", Permission: "owner"}, level("carol")}); !result.Satisfied || len(result.Signers) != 2 {
+		t.Errorf("signers should satisfy %+v", result)
+	}
+
+	// EOS prefixed keys are accepted
+	eosKey := "EOS" + strings.TrimPrefix(keys["alice"].String(), "FIO")
+	if result, err := r.Check(level("alice"), []string{eosKey}, nil); err != nil || !result.Satisfied {
+		t.Error("EOS key should satisfy", err)
+	}
+
+	tx := &eos.Transaction{Actions: []*eos.Action{
+		{Account: "fio.token", Name: "trnsfiopubky", Authorization: []eos.PermissionLevel{level("multisig")}},
+		{Account: "fio.token", Name: "trnsfiopubky", Authorization: []eos.PermissionLevel{level("alice")}},
+	}}
+	required, err := r.RequiredKeys(tx, []string{keys["other"].String(), keys["alice"].String(), keys["ms"].String()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(required) != 2 {
+		t.Error("expected two required keys", required)
+	}
+	if _, err = r.RequiredKeys(tx, []string{keys["other"].String()}); err == nil {
+		t.Error("expected error when keys are not sufficient")
+	}
+
+	tree, err := r.Tree(level("multisig"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "multisig@active threshold 2\n" +
+		"├── [1] " + keys["ms"].String() + "\n" +
+		"├── [1] alice@active threshold 1\n" +
+		"│   └── [1] " + keys["alice"].String() + "\n" +
+		"└── [1] bob@active threshold 1\n" +
+		"    └── [1] carol@active threshold 1\n" +
+		"        └── [1] " + keys["carol"].String() + "\n"
+	if tree.String() != want {
+		t.Errorf("got tree:\n%s\nwant:\n%s", tree.String(), want)
+	}
+
+	// circular permissions stop at the depth limit
+	r.AddPermissions("loopa", active(1, nil, []string{"loopb"}))
+	r.AddPermissions("loopb", active(1, nil, []string{"loopa"}))
+	if result, err := r.Check(level("loopa"), nil, nil); err != nil || result.Satisfied {
+		t.Error("circular permission should not be satisfied", err)
+	}
+	if tree, err = r.Tree(level("loopa")); err != nil || !strings.Contains(tree.String(), "(depth limit reached)") {
+		t.Error("tree should be truncated", err)
+	}
+
+	// a contract's eosio.code permission, or an unknown account, is not satisfied but doesn't fail the check
+	contract := active(1, []string{"other"}, nil)
+	contract[1].RequiredAuth.Accounts = []eos.PermissionLevelWeight{
+		{Permission: eos.PermissionLevel{Actor: "contract", Permission: "eosio.code"}, Weight: 1},
+		{Permission: level("nobody"), Weight: 1},
+	}
+	r.AddPermissions("contract", contract)
+	result, err := r.Check(level("contract"), []string{keys["other"].String()}, []eos.PermissionLevel{level("nobody")})
+	if err != nil || !result.Satisfied {
+		t.Error("key should satisfy the permission with an eosio.code entry", result, err)
+	}
+	result, err = r.Check(level("contract"), nil, nil)
+	if err != nil || result.Satisfied || len(result.Missing) != 2 || result.Missing[0].Permission != "eosio.code" {
+		t.Errorf("unresolvable levels should be missing %+v %v", result, err)
+	}
+	if _, err = r.Check(level("nobody"), nil, nil); err == nil {
+		t.Error("expected an error for an unknown account at the top level")
+	}
+	tree, err = r.Tree(level("contract"))
+	if err != nil || !strings.Contains(tree.String(), "[1] contract@eosio.code (unresolved)\n") || !tree.Accounts[1].Unresolved {
+		t.Errorf("unresolvable levels should be leaves in the tree %v\n%s", err, tree)
+	}
+}
+
+func TestAuthorityResolver_RequestErrors(t *testing.T) {
+	priv, err := ecc.NewRandomPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		switch {
+		case strings.Contains(string(body), "gone"):
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(`{"code":500,"message":"Internal Service Error","error":{"code":0,"name":"exception","what":"unspecified","details":[{"message":"unknown key (boost::tuples::tuple<bool, eosio::chain::name>): (0 gone)"}]}}`))
+		default:
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer srv.Close()
+
+	r := NewAuthorityResolver(&API{API: eos.New(srv.URL)})
+	parent := func(nested string) []Permission {
+		return []Permission{{PermName: "active", RequiredAuth: Authority{
+			Threshold: 1,
+			Keys:      []KeyWeight{{PublicKey: priv.PublicKey(), Weight: 1}},
+			Accounts:  []eos.PermissionLevelWeight{{Permission: eos.PermissionLevel{Actor: eos.AccountName(nested), Permission: "active"}, Weight: 1}},
+		}}}
+	}
+	r.AddPermissions("parent", parent("gone"))
+	r.AddPermissions("flakyparent", parent("flaky"))
+
+	result, err := r.Check(eos.PermissionLevel{Actor: "parent", Permission: "active"}, nil, nil)
+	if err != nil || result.Satisfied || len(result.Missing) != 1 {
+		t.Errorf("an account that does not exist should be missing %+v %v", result, err)
+	}
+	if tree, err := r.Tree(eos.PermissionLevel{Actor: "parent", Permission: "active"}); err != nil || !tree.Accounts[0].Unresolved {
+		t.Error("an account that does not exist should be unresolved", err)
+	}
+	if _, err = r.Check(eos.PermissionLevel{Actor: "flakyparent", Permission: "active"}, nil, nil); err == nil || errors.Is(err, ErrUnknownPermission) {
+		t.Error("a failed request should be returned", err)
+	}
+	if _, err = r.Tree(eos.PermissionLevel{Actor: "flakyparent", Permission: "active"}); err == nil {
+		t.Error("a failed request should fail the tree")
+	}
+	if _, err = r.RequiredKeys(&eos.Transaction{Actions: []*eos.Action{
+		{Account: "fio.token", Name: "trnsfiopubky", Authorization: []eos.PermissionLevel{{Actor: "flakyparent", Permission: "active"}}},
+	}}, []string{priv.PublicKey().String()}); err == nil {
+		t.Error("RequiredKeys should return the request error")
+	}
+}
@@ -0,0 +1,414 @@
+package fio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/fioprotocol/fio-go/eos"
+	"github.com/fioprotocol/fio-go/eos/p2p"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// blocksPerRound is the number of consecutive blocks each producer signs
+	blocksPerRound = 12
+	// blockIntervalMs is the time between blocks
+	blockIntervalMs = 500
+	// blockEpochMs is the start of block timestamp slots, Jan 1st 2000
+	blockEpochMs = 946684800000
+)
+
+// Event types sent by a ScheduleMonitor
+const (
+	EventMissedBlocks       = "missed_blocks"       // a producer did not sign one or more of its blocks
+	EventMissedRound        = "missed_round"        // a producer did not sign any blocks in its round
+	EventSchedulePending    = "schedule_pending"    // a new schedule has been proposed, but is not yet active
+	EventScheduleChange     = "schedule_change"     // the active schedule changed
+	EventUnexpectedProducer = "unexpected_producer" // a block was signed by a producer that was not scheduled for it
+)
+
+// MonitorEvent is reported by a ScheduleMonitor
+type MonitorEvent struct {
+	Type     string
+	Producer eos.AccountName // empty for schedule events
+	BlockNum uint32          // the block where the event was detected
+	Count    int             // number of blocks missed, for EventMissedBlocks
+	Schedule *Schedule       // the new schedule, for schedule events
+	Time     time.Time       // block timestamp
+}
+
+func (e MonitorEvent) String() string {
+	switch e.Type {
+	case EventMissedBlocks:
+		return fmt.Sprintf("block %d: %s missed %d blocks", e.BlockNum, e.Producer, e.Count)
+	case EventMissedRound:
+		return fmt.Sprintf("block %d: %s missed a round", e.BlockNum, e.Producer)
+	case EventUnexpectedProducer:
+		return fmt.Sprintf("block %d: signed by %s, which was not scheduled", e.BlockNum, e.Producer)
+	}
+	version := uint32(0)
+	if e.Schedule != nil {
+		version = e.Schedule.Version
+	}
+	return fmt.Sprintf("block %d: %s, version %d", e.BlockNum, e.Type, version)
+}
+
+// ProducerStats holds the counts tracked for each scheduled producer
+type ProducerStats struct {
+	Producer     eos.AccountName
+	Produced     uint64 // blocks signed
+	MissedBlocks uint64
+	Rounds       uint64 // completed rounds that the producer was scheduled for
+	MissedRounds uint64 // rounds without any blocks
+	LastBlock    uint32 // last block signed
+}
+
+// MonitorBlock is the information a ScheduleMonitor needs from each block
+type MonitorBlock struct {
+	Num             uint32
+	Producer        eos.AccountName
+	Timestamp       time.Time
+	ScheduleVersion uint32
+	NewProducers    *Schedule // a proposed schedule included in the header, if any
+}
+
+// MonitorBlockFromHeader converts a block header, such as from a p2p SignedBlock or the get_block endpoint
+func MonitorBlockFromHeader(h *eos.BlockHeader) MonitorBlock {
+	b := MonitorBlock{
+		Num:             h.BlockNumber(),
+		Producer:        h.Producer,
+		Timestamp:       h.Timestamp.Time,
+		ScheduleVersion: h.ScheduleVersion,
+	}
+	if h.NewProducers != nil {
+		b.NewProducers = &Schedule{Version: h.NewProducers.Version, Producers: make([]ProducerKey, 0)}
+		for _, p := range h.NewProducers.Producers {
+			b.NewProducers.Producers = append(b.NewProducers.Producers, ProducerKey{
+				AccountName:     p.AccountName,
+				BlockSigningKey: p.BlockSigningKey,
+			})
+		}
+	}
+	return b
+}
+
+// ScheduleMonitor follows blocks to track each scheduled producer's rounds, counting missed blocks and missed
+// rounds. Blocks must be provided in order, blocks from forks and duplicates are ignored. Blocks can come from
+// the HTTP API using API.MonitorBlocks, or from a p2p client using P2PHandler.
+type ScheduleMonitor struct {
+	OnEvent func(event MonitorEvent) // called synchronously for every event once the monitor is unlocked, may be nil
+
+	mux      sync.Mutex
+	events   []MonitorEvent // waiting to be sent to OnEvent once the lock is released
+	active   *Schedule
+	pending  *Schedule
+	stats    map[eos.AccountName]*ProducerStats
+	lastNum  uint32
+	lastSlot uint64
+	turn     uint64 // the current round, slot / blocksPerRound
+	produced int    // blocks signed in the current round
+}
+
+// NewScheduleMonitor starts monitoring with a known active schedule
+func NewScheduleMonitor(active *Schedule, onEvent func(event MonitorEvent)) *ScheduleMonitor {
+	m := &ScheduleMonitor{
+		OnEvent: onEvent,
+		stats:   make(map[eos.AccountName]*ProducerStats),
+	}
+	m.setActive(active)
+	return m
+}
+
+// NewScheduleMonitor creates a monitor using the current active and pending schedules
+func (api *API) NewScheduleMonitor(onEvent func(event MonitorEvent)) (*ScheduleMonitor, error) {
+	sched, err := api.GetProducerSchedule()
+	if err != nil {
+		return nil, err
+	}
+	if len(sched.Active.Producers) == 0 {
+		return nil, errors.New("active producer schedule is empty")
+	}
+	m := NewScheduleMonitor(&sched.Active, onEvent)
+	if len(sched.Pending.Producers) > 0 {
+		m.SetPending(&sched.Pending)
+	}
+	return m, nil
+}
+
+func (m *ScheduleMonitor) setActive(active *Schedule) {
+	m.active = active
+	if active == nil {
+		return
+	}
+	for _, p := range active.Producers {
+		if m.stats[p.AccountName] == nil {
+			m.stats[p.AccountName] = &ProducerStats{Producer: p.AccountName}
+		}
+	}
+}
+
+// emit queues an event, caller must hold the lock and call unlock to send it
+func (m *ScheduleMonitor) emit(e MonitorEvent) {
+	m.events = append(m.events, e)
+}
+
+// unlock releases the lock and then sends the queued events, so that OnEvent may call the monitor's methods
+func (m *ScheduleMonitor) unlock() {
+	events := m.events
+	m.events = nil
+	m.mux.Unlock()
+	if m.OnEvent == nil {
+		return
+	}
+	for _, e := range events {
+		m.OnEvent(e)
+	}
+}
+
+// SetPending records a proposed schedule, it becomes active when a block with the same schedule version is seen.
+func (m *ScheduleMonitor) SetPending(pending *Schedule) {
+	m.mux.Lock()
+	defer m.unlock()
+	m.setPending(pending, m.lastNum, time.Time{})
+}
+
+func (m *ScheduleMonitor) setPending(pending *Schedule, num uint32, ts time.Time) {
+	if pending == nil || (m.pending != nil && m.pending.Version == pending.Version) {
+		return
+	}
+	if m.active != nil && pending.Version <= m.active.Version {
+		return
+	}
+	m.pending = pending
+	m.emit(MonitorEvent{Type: EventSchedulePending, BlockNum: num, Schedule: pending, Time: ts})
+}
+
+// Schedule returns the active schedule, and the pending schedule if there is one
+func (m *ScheduleMonitor) Schedule() (active *Schedule, pending *Schedule) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	return m.active, m.pending
+}
+
+// Stats returns a copy of the counts for every producer that has been scheduled, sorted by name
+func (m *ScheduleMonitor) Stats() []ProducerStats {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	stats := make([]ProducerStats, 0, len(m.stats))
+	for _, s := range m.stats {
+		stats = append(stats, *s)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Producer < stats[j].Producer
+	})
+	return stats
+}
+
+// scheduled returns the producer expected to sign a slot
+func (m *ScheduleMonitor) scheduled(slot uint64) eos.AccountName {
+	n := uint64(len(m.active.Producers))
+	return m.active.Producers[(slot/blocksPerRound)%n].AccountName
+}
+
+// blockSlot converts a block timestamp to a slot number
+func blockSlot(t time.Time) uint64 {
+	ms := t.UnixNano() / int64(time.Millisecond)
+	if ms < blockEpochMs {
+		return 0
+	}
+	return uint64(ms-blockEpochMs) / blockIntervalMs
+}
+
+// Add processes the next block
+func (m *ScheduleMonitor) Add(b MonitorBlock) {
+	m.mux.Lock()
+	defer m.unlock()
+	if b.Num <= m.lastNum {
+		return
+	}
+	if b.NewProducers != nil {
+		m.setPending(b.NewProducers, b.Num, b.Timestamp)
+	}
+	if m.active == nil || b.ScheduleVersion != m.active.Version {
+		if m.pending == nil || m.pending.Version != b.ScheduleVersion {
+			// the schedule isn't known, so rounds can't be tracked until SetActive is called
+			m.lastNum = b.Num
+			m.lastSlot = 0
+			return
+		}
+		m.finishSchedule(b)
+		m.setActive(m.pending)
+		m.pending = nil
+		m.emit(MonitorEvent{Type: EventScheduleChange, BlockNum: b.Num, Schedule: m.active, Time: b.Timestamp})
+		// the rounds of the old schedule can't be compared with the new one, start counting again
+		m.lastSlot = 0
+	}
+	if len(m.active.Producers) == 0 {
+		return
+	}
+
+	slot := blockSlot(b.Timestamp)
+	if m.lastSlot == 0 {
+		// first block, the round may have started before monitoring
+		m.lastNum, m.lastSlot, m.turn, m.produced = b.Num, slot, slot/blocksPerRound, 1
+		m.produce(b)
+		return
+	}
+	if slot <= m.lastSlot {
+		return
+	}
+	m.advance(slot, b)
+	m.produced += 1
+	m.lastNum, m.lastSlot = b.Num, slot
+	m.produce(b)
+}
+
+// advance counts the slots missed before a block's slot, and finishes every round that ended before it
+func (m *ScheduleMonitor) advance(slot uint64, b MonitorBlock) {
+	missed := make(map[eos.AccountName]int)
+	order := make([]eos.AccountName, 0)
+	for s := m.lastSlot + 1; s < slot; s++ {
+		p := m.scheduled(s)
+		if missed[p] == 0 {
+			order = append(order, p)
+		}
+		missed[p] += 1
+		m.stats[p].MissedBlocks += 1
+	}
+	for _, p := range order {
+		m.emit(MonitorEvent{Type: EventMissedBlocks, Producer: p, BlockNum: b.Num, Count: missed[p], Time: b.Timestamp})
+	}
+
+	for turn := m.turn; turn < slot/blocksPerRound; turn++ {
+		m.finishRound(turn, b)
+	}
+	m.turn = slot / blocksPerRound
+}
+
+// finishRound counts a round for its producer, and whether it was missed
+func (m *ScheduleMonitor) finishRound(turn uint64, b MonitorBlock) {
+	p := m.scheduled(turn * blocksPerRound)
+	m.stats[p].Rounds += 1
+	if m.produced == 0 {
+		m.stats[p].MissedRounds += 1
+		m.emit(MonitorEvent{Type: EventMissedRound, Producer: p, BlockNum: b.Num, Time: b.Timestamp})
+	}
+	m.produced = 0
+}
+
+// finishSchedule accounts for the slots of the active schedule up to the block that changes it, including the
+// round that is cut short by the change
+func (m *ScheduleMonitor) finishSchedule(b MonitorBlock) {
+	if m.active == nil || len(m.active.Producers) == 0 || m.lastSlot == 0 {
+		return
+	}
+	slot := blockSlot(b.Timestamp)
+	if slot <= m.lastSlot {
+		return
+	}
+	m.advance(slot, b)
+	// the old schedule had slots in this round if the change isn't at the start of it, or blocks were produced
+	if m.produced > 0 || slot%blocksPerRound != 0 {
+		m.finishRound(m.turn, b)
+	}
+}
+
+// produce updates the stats for the block's producer
+func (m *ScheduleMonitor) produce(b MonitorBlock) {
+	slot := blockSlot(b.Timestamp)
+	if expected := m.scheduled(slot); expected != b.Producer {
+		m.emit(MonitorEvent{Type: EventUnexpectedProducer, Producer: b.Producer, BlockNum: b.Num, Time: b.Timestamp})
+	}
+	if m.stats[b.Producer] == nil {
+		m.stats[b.Producer] = &ProducerStats{Producer: b.Producer}
+	}
+	m.stats[b.Producer].Produced += 1
+	m.stats[b.Producer].LastBlock = b.Num
+}
+
+// SetActive replaces the active schedule, used when a schedule change is seen without the pending schedule
+func (m *ScheduleMonitor) SetActive(active *Schedule) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	m.setActive(active)
+	if m.pending != nil && active != nil && m.pending.Version <= active.Version {
+		m.pending = nil
+	}
+}
+
+// P2PHandler returns a handler for an eos/p2p client that adds each signed block to the monitor
+func (m *ScheduleMonitor) P2PHandler() p2p.Handler {
+	return p2p.HandlerFunc(func(envelope *p2p.Envelope) {
+		if envelope == nil || envelope.Packet == nil {
+			return
+		}
+		if block, ok := envelope.Packet.P2PMessage.(*eos.SignedBlock); ok {
+			m.Add(MonitorBlockFromHeader(&block.BlockHeader))
+		}
+	})
+}
+
+// MonitorBlocks polls get_block starting at a block number, adding each block to the monitor until the context is
+// cancelled. When the schedule version changes without a known pending schedule, the schedule is fetched from the
+// API.
+func (api *API) MonitorBlocks(ctx context.Context, m *ScheduleMonitor, start uint32) error {
+	return api.pollBlocks(ctx, start, func(block *eos.BlockResp) error {
+		b := MonitorBlockFromHeader(&block.BlockHeader)
+		active, pending := m.Schedule()
+		if active == nil || b.ScheduleVersion != active.Version {
+			if pending == nil || pending.Version != b.ScheduleVersion {
+				sched, err := api.GetProducerSchedule()
+				if err != nil {
+					return err
+				}
+				if sched.Active.Version == b.ScheduleVersion {
+					m.SetPending(&sched.Active)
+				}
+			}
+		}
+		m.Add(b)
+		return nil
+	})
+}
+
+// pollBlocks calls handle for each block starting at a block number until the context is cancelled or handle returns
+// an error. A start of zero begins at the current head block.
+func (api *API) pollBlocks(ctx context.Context, start uint32, handle func(block *eos.BlockResp) error) error {
+	next := start
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+		info, err := api.GetInfo()
+		if err != nil {
+			return err
+		}
+		if next == 0 {
+			next = info.HeadBlockNum
+		}
+		if next > info.HeadBlockNum {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(blockIntervalMs * time.Millisecond):
+			}
+			continue
+		}
+		for ; next <= info.HeadBlockNum; next++ {
+			block, err := api.GetBlockByNum(next)
+			if err != nil {
+				return fmt.Errorf("getting block %d: %s", next, err)
+			}
+			if err = handle(block); err != nil {
+				return err
+			}
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package fio
+
+import (
+	"github.com/fioprotocol/fio-go/eos"
+	"testing"
+	"time"
+)
+
+func TestScheduleMonitor(t *testing.T) {
+	schedule := func(version uint32, names ...string) *Schedule {
+		s := &Schedule{Version: version}
+		for _, n := range names {
+			s.Producers = append(s.Producers, ProducerKey{AccountName: eos.AccountName(n)})
+		}
+		return s
+	}
+	// start on the first slot of a round where alice is scheduled
+	start := time.Unix(0, blockEpochMs*int64(time.Millisecond)).Add(3 * 12 * 100 * blockIntervalMs * time.Millisecond)
+	events := make([]MonitorEvent, 0)
+	var m *ScheduleMonitor
+	m = NewScheduleMonitor(schedule(1, "alice", "bob", "carol"), func(e MonitorEvent) {
+		// the monitor is not locked while events are sent
+		_ = m.Stats()
+		events = append(events, e)
+	})
+
+	num := uint32(100)
+	block := func(slot int, producer string, version uint32) MonitorBlock {
+		num += 1
+		return MonitorBlock{
+			Num:             num,
+			Producer:        eos.AccountName(producer),
+			Timestamp:       start.Add(time.Duration(slot*blockIntervalMs) * time.Millisecond),
+			ScheduleVersion: version,
+		}
+	}
+	// alice produces 12, bob misses 2, carol misses the round, alice produces again
+	for slot := 0; slot < 12; slot++ {
+		m.Add(block(slot, "alice", 1))
+	}
+	for slot := 12; slot < 22; slot++ {
+		m.Add(block(slot, "bob", 1))
+	}
+	m.Add(block(36, "alice", 1))
+	// duplicates and old blocks are ignored
+	m.Add(MonitorBlock{Num: num, Producer: "alice", Timestamp: start, ScheduleVersion: 1})
+
+	stats := make(map[eos.AccountName]ProducerStats)
+	for _, s := range m.Stats() {
+		stats[s.Producer] = s
+	}
+	if s := stats["alice"]; s.Produced != 13 || s.MissedBlocks != 0 || s.Rounds != 1 || s.LastBlock != num {
+		t.Errorf("unexpected stats for alice %+v", s)
+	}
+	if s := stats["bob"]; s.Produced != 10 || s.MissedBlocks != 2 || s.Rounds != 1 || s.MissedRounds != 0 {
+		t.Errorf("unexpected stats for bob %+v", s)
+	}
+	if s := stats["carol"]; s.Produced != 0 || s.MissedBlocks != 12 || s.Rounds != 1 || s.MissedRounds != 1 {
+		t.Errorf("unexpected stats for carol %+v", s)
+	}
+	if len(events) != 3 || events[0].Type != EventMissedBlocks || events[0].Producer != "bob" || events[0].Count != 2 ||
+		events[1].Producer != "carol" || events[2].Type != EventMissedRound || events[2].Producer != "carol" {
+		t.Fatal("unexpected events", events)
+	}
+
+	// a proposed schedule in a header becomes active when the version changes
+	events = events[:0]
+	b := block(37, "alice", 1)
+	b.NewProducers = schedule(2, "alice", "bob", "carol", "dave")
+	m.Add(b)
+	if _, pending := m.Schedule(); pending == nil || pending.Version != 2 || len(events) != 1 || events[0].Type != EventSchedulePending {
+		t.Fatal("expected a pending schedule", events)
+	}
+	m.Add(block(38, "dave", 2))
+	active, pending := m.Schedule()
+	if active.Version != 2 || pending != nil || len(events) != 2 || events[1].Type != EventScheduleChange {
+		t.Fatal("expected a schedule change", events)
+	}
+	// the round alice started under the old schedule is counted when it changes
+	for _, s := range m.Stats() {
+		if s.Producer == "alice" && (s.Rounds != 2 || s.MissedRounds != 0) {
+			t.Error("alice's partial round should be counted", s)
+		}
+	}
+	// bob is scheduled, but carol signs
+	m.Add(block(60, "carol", 2))
+	if events[len(events)-1].Type != EventUnexpectedProducer || events[len(events)-1].Producer != "carol" {
+		t.Error("expected an unexpected producer event", events)
+	}
+	for _, s := range m.Stats() {
+		if s.Producer == "dave" && s.MissedBlocks == 0 {
+			t.Error("dave should have missed blocks", s)
+		}
+	}
+}
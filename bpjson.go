@@ -0,0 +1,417 @@
+package fio
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/fioprotocol/fio-go/eos"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// bpJsonNodeTypes are the node types allowed by the bp.json schema
+var bpJsonNodeTypes = map[string]bool{
+	"producer": true,
+	"full":     true,
+	"query":    true,
+	"seed":     true,
+}
+
+// isoCountries holds the ISO 3166-1 alpha-2 country codes
+var isoCountries = func() map[string]bool {
+	codes := `AD AE AF AG AI AL AM AO AQ AR AS AT AU AW AX AZ BA BB BD BE BF BG BH BI BJ BL BM BN BO BQ BR BS BT BV BW
+	BY BZ CA CC CD CF CG CH CI CK CL CM CN CO CR CU CV CW CX CY CZ DE DJ DK DM DO DZ EC EE EG EH ER ES ET FI FJ FK FM FO
+	FR GA GB GD GE GF GG GH GI GL GM GN GP GQ GR GS GT GU GW GY HK HM HN HR HT HU ID IE IL IM IN IO IQ IR IS IT JE JM JO
+	JP KE KG KH KI KM KN KP KR KW KY KZ LA LB LC LI LK LR LS LT LU LV LY MA MC MD ME MF MG MH MK ML MM MN MO MP MQ MR MS
+	MT MU MV MW MX MY MZ NA NC NE NF NG NI NL NO NP NR NU NZ OM PA PE PF PG PH PK PL PM PN PR PS PT PW PY QA RE RO RS RU
+	RW SA SB SC SD SE SG SH SI SJ SK SL SM SN SO SR SS ST SV SX SY SZ TC TD TF TG TH TJ TK TL TM TN TO TR TT TV TW TZ UA
+	UG UM US UY UZ VA VC VE VG VI VN VU WF WS YE YT ZA ZM ZW`
+	m := make(map[string]bool)
+	for _, c := range strings.Fields(codes) {
+		m[c] = true
+	}
+	return m
+}()
+
+// BpJsonCheck is a single test performed by a BpJsonChecker
+type BpJsonCheck struct {
+	Name     string // the field or endpoint checked, for example "org.email" or "nodes[1].api_endpoint"
+	Passed   bool
+	Required bool // required by the schema, a failure means the bp.json is not valid
+	Message  string
+}
+
+// BpJsonEndpoint is the result of probing an endpoint listed in a bp.json
+type BpJsonEndpoint struct {
+	Node      int    // index in BpJson.Nodes
+	Type      string // api, ssl, or p2p
+	Endpoint  string
+	Reachable bool
+	ChainId   string   `json:",omitempty"`
+	OnlySafe  bool     // from GetSupportedApis, false if the producer or net apis are enabled
+	Apis      []string `json:",omitempty"`
+	Tls       bool
+	TlsExpiry time.Time `json:",omitempty"`
+	Cors      bool
+	Err       string `json:",omitempty"`
+}
+
+// BpJsonReport is the result of validating a bp.json and probing its nodes. Score is a percentage: required
+// checks are weighted three times as much as recommended ones.
+type BpJsonReport struct {
+	Producer  eos.AccountName
+	Url       string
+	Valid     bool // all required checks passed
+	Score     int
+	Checks    []BpJsonCheck
+	Endpoints []*BpJsonEndpoint
+}
+
+// Failed returns the checks that did not pass
+func (r *BpJsonReport) Failed() []BpJsonCheck {
+	failed := make([]BpJsonCheck, 0)
+	for _, c := range r.Checks {
+		if !c.Passed {
+			failed = append(failed, c)
+		}
+	}
+	return failed
+}
+
+// add records a check, message describes the failure and is dropped if the check passed
+func (r *BpJsonReport) add(name string, passed bool, required bool, message string) {
+	if passed {
+		message = ""
+	}
+	r.Checks = append(r.Checks, BpJsonCheck{Name: name, Passed: passed, Required: required, Message: message})
+}
+
+// score updates Valid and Score from the checks
+func (r *BpJsonReport) score() {
+	var total, passed int
+	r.Valid = true
+	for _, c := range r.Checks {
+		weight := 1
+		if c.Required {
+			weight = 3
+		}
+		total += weight
+		if c.Passed {
+			passed += weight
+		} else if c.Required {
+			r.Valid = false
+		}
+	}
+	if total > 0 {
+		r.Score = passed * 100 / total
+	}
+}
+
+// bpJsonMaxBody limits how much of a response is read from an endpoint
+const bpJsonMaxBody = 1 << 20
+
+// BpJsonChecker validates bp.json files and probes the endpoints they list. Like GetBpJson, endpoints must use a
+// hostname that does not resolve to a private address, and redirects are not followed.
+type BpJsonChecker struct {
+	ChainId    string // expected chain ID for every API endpoint
+	HttpClient *http.Client
+	Timeout    time.Duration // for connecting to p2p endpoints
+	Origin     string        // Origin header sent to check CORS
+	Probe      bool          // when false only the schema is checked
+
+	allowPrivate bool // allows override of the private ip check for tests
+}
+
+// NewBpJsonChecker creates a checker that probes endpoints, expecting chainId
+func NewBpJsonChecker(chainId string) *BpJsonChecker {
+	return &BpJsonChecker{
+		ChainId:    chainId,
+		HttpClient: &http.Client{Timeout: 10 * time.Second},
+		Timeout:    10 * time.Second,
+		Origin:     "https://fio.example.com",
+		Probe:      true,
+	}
+}
+
+// CheckBpJson fetches a producer's bp.json with GetBpJson, then validates it and probes each node
+func (api *API) CheckBpJson(producer eos.AccountName) (*BpJsonReport, error) {
+	info, err := api.GetInfo()
+	if err != nil {
+		return nil, err
+	}
+	bpj, err := api.GetBpJson(producer)
+	if err != nil {
+		return nil, err
+	}
+	report := NewBpJsonChecker(info.ChainID.String()).Check(bpj)
+	if report.Producer != producer {
+		report.add("producer_account_name", false, true,
+			fmt.Sprintf("expected %s, bp.json is for %s", producer, report.Producer))
+		report.score()
+	}
+	return report, nil
+}
+
+// Check validates the bp.json against the standard schema, and if Probe is set connects to each endpoint
+func (c *BpJsonChecker) Check(bpj *BpJson) *BpJsonReport {
+	report := &BpJsonReport{
+		Producer:  eos.AccountName(bpj.ProducerAccountName),
+		Url:       bpj.BpJsonUrl,
+		Checks:    make([]BpJsonCheck, 0),
+		Endpoints: make([]*BpJsonEndpoint, 0),
+	}
+	validateBpJson(bpj, report)
+	if c.Probe {
+		for i, node := range bpj.Nodes {
+			prefix := fmt.Sprintf("nodes[%d].", i)
+			if node.ApiEndpoint != "" {
+				report.Endpoints = append(report.Endpoints, c.checkApi(report, prefix+"api_endpoint", i, "api", node.ApiEndpoint))
+			}
+			if node.SslEndpoint != "" {
+				report.Endpoints = append(report.Endpoints, c.checkApi(report, prefix+"ssl_endpoint", i, "ssl", node.SslEndpoint))
+			}
+			if node.P2pEndpoint != "" {
+				report.Endpoints = append(report.Endpoints, c.checkP2p(report, prefix+"p2p_endpoint", i, node.P2pEndpoint))
+			}
+		}
+	}
+	report.score()
+	return report
+}
+
+// validateBpJson checks the fields required or recommended by the bp.json schema
+func validateBpJson(bpj *BpJson, r *BpJsonReport) {
+	r.add("producer_account_name", validName(bpj.ProducerAccountName) && bpj.ProducerAccountName != "", true,
+		fmt.Sprintf("%q is not a valid account", bpj.ProducerAccountName))
+	r.add("org.candidate_name", bpj.Org.CandidateName != "", true, "missing")
+	_, err := mail.ParseAddress(bpj.Org.Email)
+	r.add("org.email", err == nil, true, "not a valid email address")
+	r.add("org.website", validBpJsonUrl(bpj.Org.Website), true, "not a valid http or https url")
+	r.add("org.code_of_conduct", validBpJsonUrl(bpj.Org.CodeOfConduct), false, "not a valid http or https url")
+	r.add("org.ownership_disclosure", validBpJsonUrl(bpj.Org.OwnershipDisclosure), false, "not a valid http or https url")
+	r.add("org.branding.logo_256", validBpJsonUrl(bpj.Org.Branding.Logo256), false, "not a valid http or https url")
+	r.add("org.branding.logo_1024", validBpJsonUrl(bpj.Org.Branding.Logo1024), false, "not a valid http or https url")
+	r.add("org.branding.logo_svg", validBpJsonUrl(bpj.Org.Branding.LogoSvg), false, "not a valid http or https url")
+	validateBpJsonLocation("org.location", bpj.Org.Location, r)
+
+	r.add("nodes", len(bpj.Nodes) > 0, true, "no nodes listed")
+	var producers int
+	for i, node := range bpj.Nodes {
+		prefix := fmt.Sprintf("nodes[%d].", i)
+		validateBpJsonLocation(prefix+"location", node.Location, r)
+		types, ok := bpJsonNodeTypeList(node.NodeType)
+		r.add(prefix+"node_type", ok && len(types) > 0, true,
+			fmt.Sprintf("%v is not one of producer, full, query or seed", node.NodeType))
+		for _, t := range types {
+			switch t {
+			case "producer":
+				producers += 1
+				r.add(prefix+"node_type", node.ApiEndpoint == "" && node.SslEndpoint == "" && node.P2pEndpoint == "", false,
+					"producer nodes should not be publicly reachable")
+			case "query":
+				r.add(prefix+"api_endpoint", node.ApiEndpoint != "" || node.SslEndpoint != "", true,
+					"query nodes must list an api_endpoint or ssl_endpoint")
+			case "seed":
+				r.add(prefix+"p2p_endpoint", node.P2pEndpoint != "", true, "seed nodes must list a p2p_endpoint")
+			}
+		}
+		if node.BnetEndpoint != "" {
+			r.add(prefix+"bnet_endpoint", false, false, "bnet is no longer supported")
+		}
+		if node.SslEndpoint != "" {
+			u, err := url.Parse(node.SslEndpoint)
+			r.add(prefix+"ssl_endpoint", err == nil && u.Scheme == "https" && u.Host != "", true, "must be an https url")
+		}
+		if node.ApiEndpoint != "" {
+			u, err := url.Parse(node.ApiEndpoint)
+			r.add(prefix+"api_endpoint", err == nil && u.Scheme == "http" && u.Host != "", true, "must be an http url")
+		}
+		if node.P2pEndpoint != "" {
+			_, port, err := net.SplitHostPort(node.P2pEndpoint)
+			r.add(prefix+"p2p_endpoint", err == nil && port != "", true, "must be host:port")
+		}
+	}
+	r.add("nodes.producer", producers > 0, true, "no producer node listed")
+}
+
+func validateBpJsonLocation(prefix string, loc BpJsonLocation, r *BpJsonReport) {
+	r.add(prefix+".name", loc.Name != "", false, "missing")
+	r.add(prefix+".country", isoCountries[loc.Country], true,
+		fmt.Sprintf("%q is not an uppercase ISO 3166-1 alpha-2 country code", loc.Country))
+	r.add(prefix+".latitude", loc.Latitude >= -90 && loc.Latitude <= 90 && loc.Latitude != 0, false,
+		"missing or out of range")
+	r.add(prefix+".longitude", loc.Longitude >= -180 && loc.Longitude <= 180 && loc.Longitude != 0, false,
+		"missing or out of range")
+}
+
+// bpJsonNodeTypeList handles node_type being either a string or a list of strings
+func bpJsonNodeTypeList(nodeType interface{}) (types []string, ok bool) {
+	switch v := nodeType.(type) {
+	case string:
+		return []string{v}, bpJsonNodeTypes[v]
+	case []interface{}:
+		types = make([]string, 0, len(v))
+		for _, t := range v {
+			s, isString := t.(string)
+			if !isString || !bpJsonNodeTypes[s] {
+				return nil, false
+			}
+			types = append(types, s)
+		}
+		return types, true
+	}
+	return nil, false
+}
+
+func validBpJsonUrl(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// checkHost ensures an endpoint is 1) a hostname, and 2) does not resolve to a private IP range
+func (c *BpJsonChecker) checkHost(host string) error {
+	if c.allowPrivate {
+		return nil
+	}
+	if net.ParseIP(host) != nil {
+		return errors.New("endpoint is an IP address, refusing to connect")
+	}
+	addrs, err := net.LookupHost(host)
+	if err != nil {
+		return err
+	}
+	if len(addrs) == 0 {
+		return errors.New("could not resolve DNS for endpoint")
+	}
+	for _, ip := range addrs {
+		if isPrivate(net.ParseIP(ip)) {
+			return errors.New("endpoint points to a private IP address, refusing to connect")
+		}
+	}
+	return nil
+}
+
+// client returns a copy of HttpClient that does not follow redirects
+func (c *BpJsonChecker) client() *http.Client {
+	client := &http.Client{}
+	if c.HttpClient != nil {
+		*client = *c.HttpClient
+	}
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	return client
+}
+
+// readBpJsonBody reads at most bpJsonMaxBody bytes of a response and closes it
+func readBpJsonBody(resp *http.Response) ([]byte, error) {
+	defer resp.Body.Close()
+	return ioutil.ReadAll(io.LimitReader(resp.Body, bpJsonMaxBody))
+}
+
+// checkApi calls get_info and get_supported_apis on an endpoint
+func (c *BpJsonChecker) checkApi(r *BpJsonReport, name string, node int, endpointType string, endpoint string) *BpJsonEndpoint {
+	ep := &BpJsonEndpoint{Node: node, Type: endpointType, Endpoint: endpoint}
+	base := strings.TrimRight(endpoint, "/")
+	u, err := url.Parse(base)
+	if err == nil {
+		err = c.checkHost(u.Hostname())
+	}
+	if err != nil {
+		ep.Err = err.Error()
+		r.add(name, false, true, ep.Err)
+		return ep
+	}
+	req, err := http.NewRequest(http.MethodPost, base+"/v1/chain/get_info", bytes.NewReader([]byte("{}")))
+	if err != nil {
+		ep.Err = err.Error()
+		r.add(name, false, true, ep.Err)
+		return ep
+	}
+	req.Header.Set("Origin", c.Origin)
+	client := c.client()
+	resp, err := client.Do(req)
+	if err != nil {
+		ep.Err = err.Error()
+		r.add(name, false, true, "not reachable: "+ep.Err)
+		return ep
+	}
+	body, err := readBpJsonBody(resp)
+	info := &eos.InfoResp{}
+	if err == nil && resp.StatusCode == http.StatusOK {
+		err = json.Unmarshal(body, info)
+	} else if err == nil {
+		err = fmt.Errorf("get_info returned %s", resp.Status)
+	}
+	if err != nil {
+		ep.Err = err.Error()
+		r.add(name, false, true, "not reachable: "+ep.Err)
+		return ep
+	}
+	ep.Reachable = true
+	ep.ChainId = info.ChainID.String()
+	r.add(name, true, true, "")
+	r.add(name+".chain_id", ep.ChainId == c.ChainId, true, fmt.Sprintf("wrong chain id %s", ep.ChainId))
+
+	allowed := resp.Header.Get("Access-Control-Allow-Origin")
+	ep.Cors = allowed == "*" || allowed == c.Origin
+	r.add(name+".cors", ep.Cors, false, "Access-Control-Allow-Origin is not set")
+
+	if resp.TLS != nil {
+		ep.Tls = true
+		if len(resp.TLS.PeerCertificates) > 0 {
+			ep.TlsExpiry = resp.TLS.PeerCertificates[0].NotAfter
+		}
+	}
+	if endpointType == "ssl" {
+		r.add(name+".tls", ep.Tls, true, "did not connect using TLS")
+		if ep.Tls {
+			r.add(name+".tls_expiry", ep.TlsExpiry.After(time.Now().Add(14*24*time.Hour)), false,
+				fmt.Sprintf("certificate expires %s", ep.TlsExpiry.Format(time.RFC3339)))
+		}
+	}
+
+	resp, err = client.Get(base + "/v1/node/get_supported_apis")
+	if err == nil {
+		if body, err = readBpJsonBody(resp); err == nil {
+			ep.OnlySafe, ep.Apis, err = parseSupportedApis(body)
+		}
+	}
+	if err != nil {
+		r.add(name+".supported_apis", false, false, "get_supported_apis failed: "+err.Error())
+		return ep
+	}
+	r.add(name+".supported_apis", ep.OnlySafe, true, "producer or net api is enabled")
+	return ep
+}
+
+// checkP2p connects to a p2p endpoint, it does not perform a handshake
+func (c *BpJsonChecker) checkP2p(r *BpJsonReport, name string, node int, endpoint string) *BpJsonEndpoint {
+	ep := &BpJsonEndpoint{Node: node, Type: "p2p", Endpoint: endpoint}
+	host, _, err := net.SplitHostPort(endpoint)
+	if err == nil {
+		err = c.checkHost(host)
+	}
+	if err != nil {
+		ep.Err = err.Error()
+		r.add(name, false, true, ep.Err)
+		return ep
+	}
+	conn, err := net.DialTimeout("tcp", endpoint, c.Timeout)
+	if err != nil {
+		ep.Err = err.Error()
+		r.add(name, false, true, "not reachable: "+ep.Err)
+		return ep
+	}
+	_ = conn.Close()
+	ep.Reachable = true
+	r.add(name, true, true, "")
+	return ep
+}
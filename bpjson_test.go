@@ -0,0 +1,137 @@
+package fio
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBpJsonChecker(t *testing.T) {
+	const chainId = "b20901380af44ef59c5918439a1f9a41d83669020319a80574b804a5f95cbd7e"
+	handler := func(apis string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/v1/chain/get_info":
+				if apis == "" {
+					w.Header().Set("Access-Control-Allow-Origin", "*")
+				}
+				_, _ = w.Write([]byte(`{"chain_id":"` + chainId + `","head_block_num":1}`))
+			case "/v1/node/get_supported_apis":
+				if apis == "" {
+					apis = `"/v1/chain/get_info"`
+				}
+				_, _ = w.Write([]byte(`{"apis":[` + apis + `]}`))
+			}
+		}
+	}
+	plain := httptest.NewServer(handler(`"/v1/chain/get_info","/v1/producer/pause"`))
+	defer plain.Close()
+	secure := httptest.NewTLSServer(handler(""))
+	defer secure.Close()
+	p2p, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed := p2p.Addr().String()
+	_ = p2p.Close()
+	p2p, err = net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p2p.Close()
+
+	loc := BpJsonLocation{Name: "Zug", Country: "CH", Latitude: 47.17, Longitude: 8.51}
+	bpj := &BpJson{
+		ProducerAccountName: "bp1",
+		Org: BpJsonOrg{
+			CandidateName: "Example BP",
+			Website:       "https://example.com",
+			Email:         "bp@example.com",
+			Location:      loc,
+		},
+		Nodes: []BpJsonNode{
+			{Location: loc, NodeType: "producer"},
+			{Location: loc, NodeType: []interface{}{"query", "seed"}, ApiEndpoint: plain.URL, SslEndpoint: secure.URL, P2pEndpoint: p2p.Addr().String()},
+			{Location: loc, NodeType: "seed", P2pEndpoint: closed},
+		},
+	}
+	c := NewBpJsonChecker(chainId)
+	c.HttpClient = secure.Client()
+	c.allowPrivate = true
+	report := c.Check(bpj)
+
+	failed := make(map[string]bool)
+	for _, f := range report.Failed() {
+		failed[f.Name] = true
+	}
+	for _, name := range []string{"nodes[1].api_endpoint.supported_apis", "nodes[1].api_endpoint.cors", "nodes[2].p2p_endpoint", "org.code_of_conduct"} {
+		if !failed[name] {
+			t.Error("expected failed check", name)
+		}
+	}
+	for _, name := range []string{"nodes[1].ssl_endpoint", "nodes[1].ssl_endpoint.tls", "nodes[1].ssl_endpoint.cors",
+		"nodes[1].ssl_endpoint.chain_id", "nodes[1].p2p_endpoint", "org.location.country", "nodes.producer"} {
+		if failed[name] {
+			t.Error("unexpected failed check", name)
+		}
+	}
+	if report.Valid || report.Score <= 50 || report.Score >= 100 {
+		t.Errorf("unexpected score %d valid %v", report.Score, report.Valid)
+	}
+	if len(report.Endpoints) != 4 || !report.Endpoints[1].Tls || !report.Endpoints[1].OnlySafe || report.Endpoints[0].OnlySafe {
+		t.Errorf("unexpected endpoints %+v", report.Endpoints)
+	}
+
+	// schema only
+	bad := &BpJson{
+		ProducerAccountName: "BP1",
+		Org:                 BpJsonOrg{Email: "nope", Location: BpJsonLocation{Country: "ch"}},
+		Nodes:               []BpJsonNode{{NodeType: "archive", ApiEndpoint: "https://api.example.com"}},
+	}
+	c.Probe = false
+	report = c.Check(bad)
+	failed = make(map[string]bool)
+	for _, f := range report.Failed() {
+		if f.Message == "" {
+			t.Error("failed check without a message", f.Name)
+		}
+		failed[f.Name] = true
+	}
+	for _, name := range []string{"producer_account_name", "org.candidate_name", "org.email", "org.website",
+		"org.location.country", "nodes[0].node_type", "nodes[0].api_endpoint", "nodes.producer"} {
+		if !failed[name] {
+			t.Error("expected failed check", name)
+		}
+	}
+	if report.Valid || len(report.Endpoints) != 0 || !strings.Contains(report.Failed()[0].Message, "BP1") {
+		t.Errorf("unexpected report %+v", report)
+	}
+
+	// private addresses are refused, redirects are not followed, and large responses are cut off
+	c.Probe = true
+	c.allowPrivate = false
+	r := &BpJsonReport{}
+	if ep := c.checkApi(r, "api", 0, "api", plain.URL); ep.Err == "" || !strings.Contains(ep.Err, "IP address") {
+		t.Error("expected private endpoint to be refused", ep.Err)
+	}
+	if ep := c.checkP2p(r, "p2p", 0, p2p.Addr().String()); ep.Reachable {
+		t.Error("expected private p2p endpoint to be refused")
+	}
+	c.allowPrivate = true
+	redirect := httptest.NewServer(http.RedirectHandler(plain.URL+"/v1/chain/get_info", http.StatusFound))
+	defer redirect.Close()
+	if ep := c.checkApi(r, "api", 0, "api", redirect.URL); ep.Reachable || !strings.Contains(ep.Err, "302") {
+		t.Error("redirect should not be followed", ep.Err)
+	}
+	large := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"chain_id":"` + chainId + `","server_version_string":"`))
+		_, _ = w.Write([]byte(strings.Repeat("a", 2*bpJsonMaxBody)))
+		_, _ = w.Write([]byte(`"}`))
+	}))
+	defer large.Close()
+	if ep := c.checkApi(r, "api", 0, "api", large.URL); ep.Reachable {
+		t.Error("large response should not be read completely")
+	}
+}
@@ -26,6 +26,8 @@ const (
 // API struct allows extending the eos.API with FIO-specific functions
 type API struct {
 	*eos.API
+
+	history HistoryBackend
 }
 
 // Action struct duplicates eos.Action
@@ -99,7 +101,7 @@ func NewConnection(keyBag *eos.KeyBag, url string) (*API, *TxOptions, error) {
 	if err != nil {
 		return &API{}, nil, err
 	}
-	a := &API{api}
+	a := &API{API: api}
 	if !maxFeesUpdated {
 		_ = a.RefreshFees()
 	}
@@ -566,6 +568,11 @@ func (api *API) GetSupportedApis() (onlySafe bool, apis []string, err error) {
 	if err != nil {
 		return false, nil, err
 	}
+	return parseSupportedApis(body)
+}
+
+// parseSupportedApis decodes a get_supported_apis response, and checks for the producer and net apis
+func parseSupportedApis(body []byte) (onlySafe bool, apis []string, err error) {
 	supported := &getSupportedApisResp{}
 	err = json.Unmarshal(body, supported)
 	if err != nil {
@@ -0,0 +1,365 @@
+package fio
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/mr-tron/base58"
+	"golang.org/x/crypto/sha3"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ReasonInvalidFormat is the ValidationReason code for a public address that is not valid for its chain
+const ReasonInvalidFormat = "invalid_format"
+
+// PubAddressValidator checks that a public address is well formed for a chain, see RegisterPubAddressValidator
+type PubAddressValidator func(publicAddress string) error
+
+var (
+	pubAddressValidators = map[string]PubAddressValidator{
+		"BCH": validateBchAddress,
+		"BTC": validateBtcAddress,
+		"EOS": validateEosAccount,
+		"ETH": validateEthAddress,
+		"FIO": validateFioPubKey,
+		"LTC": validateLtcAddress,
+		"XRP": validateXrpAddress,
+	}
+	pubAddressValidatorsMux sync.RWMutex
+)
+
+// RegisterPubAddressValidator sets the validator used for a chain code, replacing any existing validator. A nil
+// validator removes it, and public addresses for the chain will only get the basic length and whitespace checks.
+func RegisterPubAddressValidator(chain string, validator PubAddressValidator) {
+	pubAddressValidatorsMux.Lock()
+	defer pubAddressValidatorsMux.Unlock()
+	if validator == nil {
+		delete(pubAddressValidators, strings.ToUpper(chain))
+		return
+	}
+	pubAddressValidators[strings.ToUpper(chain)] = validator
+}
+
+// ValidateChainPubAddress checks a public address using the validator registered for the chain. Built in validators
+// cover BTC, LTC, BCH, ETH, XRP, FIO and EOS, chains without a validator only get the checks in
+// ValidatePublicAddress.
+func ValidateChainPubAddress(chain string, publicAddress string) error {
+	if err := ValidatePublicAddress(publicAddress); err != nil {
+		return err
+	}
+	pubAddressValidatorsMux.RLock()
+	validator := pubAddressValidators[strings.ToUpper(chain)]
+	pubAddressValidatorsMux.RUnlock()
+	if validator == nil {
+		return nil
+	}
+	if err := validator(publicAddress); err != nil {
+		ve := &ValidationError{Field: "public_address", Value: publicAddress}
+		ve.add(ReasonInvalidFormat, "not a valid %s address: %s", strings.ToUpper(chain), err.Error())
+		return ve
+	}
+	return nil
+}
+
+// base58CheckDecode decodes a base58 string with a double sha256 checksum, and returns the payload including the
+// version prefix
+func base58CheckDecode(s string, alphabet *base58.Alphabet) ([]byte, error) {
+	decoded, err := base58.DecodeAlphabet(s, alphabet)
+	if err != nil {
+		return nil, err
+	}
+	if len(decoded) < 5 {
+		return nil, errors.New("too short")
+	}
+	payload := decoded[:len(decoded)-4]
+	first := sha256.Sum256(payload)
+	second := sha256.Sum256(first[:])
+	if !bytes.Equal(second[:4], decoded[len(decoded)-4:]) {
+		return nil, errors.New("invalid checksum")
+	}
+	return payload, nil
+}
+
+// validBase58Version checks a base58check address with a single version byte and a 20 byte hash
+func validBase58Version(s string, versions ...byte) error {
+	payload, err := base58CheckDecode(s, base58.BTCAlphabet)
+	if err != nil {
+		return err
+	}
+	if len(payload) != 21 {
+		return errors.New("invalid length")
+	}
+	for _, v := range versions {
+		if payload[0] == v {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown version %d", payload[0])
+}
+
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+// Checksum constants for bech32 (BIP-173) and bech32m (BIP-350)
+const (
+	bech32Const  = 1
+	bech32mConst = 0x2bc830a3
+)
+
+// decode5Bit converts characters from the bech32 character set to 5 bit values
+func decode5Bit(s string) ([]byte, error) {
+	values := make([]byte, len(s))
+	for i := range s {
+		v := strings.IndexByte(bech32Charset, s[i])
+		if v < 0 {
+			return nil, fmt.Errorf("invalid character %q", s[i])
+		}
+		values[i] = byte(v)
+	}
+	return values, nil
+}
+
+func bech32Polymod(values []byte) uint32 {
+	gen := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+	chk := uint32(1)
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if (top>>uint(i))&1 == 1 {
+				chk ^= gen[i]
+			}
+		}
+	}
+	return chk
+}
+
+// bech32Decode returns the human readable part, the 5 bit data without the checksum, and the checksum constant
+func bech32Decode(s string) (hrp string, data []byte, checksum uint32, err error) {
+	if strings.ToLower(s) != s && strings.ToUpper(s) != s {
+		return "", nil, 0, errors.New("mixed case")
+	}
+	s = strings.ToLower(s)
+	pos := strings.LastIndexByte(s, '1')
+	if pos < 1 || pos+7 > len(s) || len(s) > 90 {
+		return "", nil, 0, errors.New("invalid bech32 length")
+	}
+	hrp = s[:pos]
+	if data, err = decode5Bit(s[pos+1:]); err != nil {
+		return "", nil, 0, err
+	}
+	values := make([]byte, 0, len(hrp)*2+1+len(data))
+	for i := range hrp {
+		values = append(values, hrp[i]>>5)
+	}
+	values = append(values, 0)
+	for i := range hrp {
+		values = append(values, hrp[i]&31)
+	}
+	checksum = bech32Polymod(append(values, data...))
+	if checksum != bech32Const && checksum != bech32mConst {
+		return "", nil, 0, errors.New("invalid checksum")
+	}
+	return hrp, data[:len(data)-6], checksum, nil
+}
+
+// convertBits regroups bits, used to convert between 5 and 8 bit values
+func convertBits(data []byte, from uint, to uint, pad bool) ([]byte, error) {
+	var acc, bits uint
+	maxv := uint(1)<<to - 1
+	out := make([]byte, 0, len(data)*int(from)/int(to)+1)
+	for _, v := range data {
+		acc = acc<<from | uint(v)
+		bits += from
+		for bits >= to {
+			bits -= to
+			out = append(out, byte(acc>>bits&maxv))
+		}
+	}
+	if pad {
+		if bits > 0 {
+			out = append(out, byte(acc<<(to-bits)&maxv))
+		}
+	} else if bits >= from || acc<<(to-bits)&maxv != 0 {
+		return nil, errors.New("invalid padding")
+	}
+	return out, nil
+}
+
+// validSegwit checks a segwit address, version 0 uses bech32 and later versions use bech32m
+func validSegwit(s string, hrps ...string) error {
+	hrp, data, checksum, err := bech32Decode(s)
+	if err != nil {
+		return err
+	}
+	known := false
+	for _, h := range hrps {
+		known = known || h == hrp
+	}
+	if !known {
+		return fmt.Errorf("unknown prefix %q", hrp)
+	}
+	if len(data) < 1 || data[0] > 16 {
+		return errors.New("invalid witness version")
+	}
+	program, err := convertBits(data[1:], 5, 8, false)
+	if err != nil {
+		return err
+	}
+	switch {
+	case len(program) < 2 || len(program) > 40:
+		return errors.New("invalid witness program length")
+	case data[0] == 0 && len(program) != 20 && len(program) != 32:
+		return errors.New("invalid witness program length")
+	case data[0] == 0 && checksum != bech32Const, data[0] != 0 && checksum != bech32mConst:
+		return errors.New("wrong checksum type for witness version")
+	}
+	return nil
+}
+
+func validateBtcAddress(s string) error {
+	if strings.HasPrefix(strings.ToLower(s), "bc1") || strings.HasPrefix(strings.ToLower(s), "tb1") ||
+		strings.HasPrefix(strings.ToLower(s), "bcrt1") {
+		return validSegwit(s, "bc", "tb", "bcrt")
+	}
+	return validBase58Version(s, 0x00, 0x05, 0x6f, 0xc4)
+}
+
+func validateLtcAddress(s string) error {
+	if strings.HasPrefix(strings.ToLower(s), "ltc1") || strings.HasPrefix(strings.ToLower(s), "tltc1") {
+		return validSegwit(s, "ltc", "tltc")
+	}
+	return validBase58Version(s, 0x30, 0x32, 0x05, 0x6f, 0x3a, 0xc4)
+}
+
+func cashAddrPolymod(values []byte) uint64 {
+	gen := [5]uint64{0x98f2bc8e61, 0x79b76d99e2, 0xf33e5fb3c4, 0xae2eabe2a8, 0x1e4f43e470}
+	c := uint64(1)
+	for _, v := range values {
+		top := c >> 35
+		c = (c&0x07ffffffff)<<5 ^ uint64(v)
+		for i := 0; i < 5; i++ {
+			if (top>>uint(i))&1 == 1 {
+				c ^= gen[i]
+			}
+		}
+	}
+	return c ^ 1
+}
+
+// validateBchAddress accepts cashaddr, with or without the bitcoincash: prefix, and legacy addresses
+func validateBchAddress(s string) error {
+	lower := strings.ToLower(s)
+	prefix := "bitcoincash"
+	if i := strings.IndexByte(lower, ':'); i >= 0 {
+		prefix, lower = lower[:i], lower[i+1:]
+		if prefix != "bitcoincash" && prefix != "bchtest" && prefix != "bchreg" {
+			return fmt.Errorf("unknown prefix %q", prefix)
+		}
+	} else if strings.HasPrefix(lower, "1") || strings.HasPrefix(lower, "3") {
+		return validBase58Version(s, 0x00, 0x05)
+	}
+	if strings.ToLower(s) != s && strings.ToUpper(s) != s {
+		return errors.New("mixed case")
+	}
+	data, err := decode5Bit(lower)
+	if err != nil {
+		return err
+	}
+	if len(data) < 9 {
+		return errors.New("too short")
+	}
+	values := make([]byte, 0, len(prefix)+1+len(data))
+	for i := range prefix {
+		values = append(values, prefix[i]&31)
+	}
+	values = append(values, 0)
+	if cashAddrPolymod(append(values, data...)) != 0 {
+		return errors.New("invalid checksum")
+	}
+	payload, err := convertBits(data[:len(data)-8], 5, 8, false)
+	if err != nil {
+		return err
+	}
+	sizes := [8]int{20, 24, 28, 32, 40, 48, 56, 64}
+	if len(payload) < 1 || payload[0]&0x80 != 0 || len(payload)-1 != sizes[payload[0]&0x07] {
+		return errors.New("invalid length")
+	}
+	return nil
+}
+
+// validateEthAddress checks for 20 hex encoded bytes, mixed case addresses must have a valid EIP-55 checksum
+func validateEthAddress(s string) error {
+	if !strings.HasPrefix(s, "0x") && !strings.HasPrefix(s, "0X") {
+		return errors.New("missing 0x prefix")
+	}
+	addr := s[2:]
+	if len(addr) != 40 {
+		return errors.New("must be 40 hex characters")
+	}
+	if _, err := hex.DecodeString(addr); err != nil {
+		return errors.New("must be 40 hex characters")
+	}
+	if strings.ToLower(addr) == addr || strings.ToUpper(addr) == addr {
+		return nil
+	}
+	h := sha3.NewLegacyKeccak256()
+	_, _ = h.Write([]byte(strings.ToLower(addr)))
+	hash := h.Sum(nil)
+	for i := range addr {
+		if addr[i] < 'A' {
+			continue // digit
+		}
+		nibble := hash[i/2] >> 4
+		if i%2 == 1 {
+			nibble = hash[i/2] & 0x0f
+		}
+		if (nibble >= 8) != (addr[i] <= 'F') {
+			return errors.New("invalid EIP-55 checksum")
+		}
+	}
+	return nil
+}
+
+var rippleAlphabet = base58.NewAlphabet("rpshnaf39wBUDNEGHJKLM4PQRST7VWXYZ2bcdeCg65jkm8oFqi1tuvAxyz")
+
+// validateXrpAddress accepts classic addresses, optionally followed by a ?dt= destination tag, and X-addresses
+func validateXrpAddress(s string) error {
+	if i := strings.Index(s, "?dt="); i > 0 {
+		if _, err := strconv.ParseUint(s[i+4:], 10, 32); err != nil {
+			return errors.New("invalid destination tag")
+		}
+		s = s[:i]
+	}
+	payload, err := base58CheckDecode(s, rippleAlphabet)
+	if err != nil {
+		return err
+	}
+	switch {
+	case strings.HasPrefix(s, "r") && len(payload) == 21 && payload[0] == 0:
+		return nil
+	case strings.HasPrefix(s, "X") && len(payload) == 31 && payload[0] == 0x05 && payload[1] == 0x44:
+		return nil
+	case strings.HasPrefix(s, "T") && len(payload) == 31 && payload[0] == 0x04 && payload[1] == 0x93:
+		return nil
+	}
+	return errors.New("not a classic or X-address")
+}
+
+func validateFioPubKey(s string) error {
+	if !strings.HasPrefix(s, "FIO") {
+		return errors.New("must be a FIO public key")
+	}
+	_, err := ActorFromPub(s)
+	return err
+}
+
+func validateEosAccount(s string) error {
+	if len(s) > 12 || !validName(s) {
+		return errors.New("must be an account name")
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package fio
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateChainPubAddress(t *testing.T) {
+	acc, err := NewRandomAccount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	valid := map[string][]string{
+		"BTC": {
+			"1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2",
+			"3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy",
+			"bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4",
+			"BC1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KV8F3T4",
+			"bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj0",
+		},
+		"LTC": {"LVg2kJoFNg45Nbpy53h7Fe1wKyeXVRhMH9", "ltc1qw508d6qejxtdg4y5r3zarvary0c5xw7kgmn4n9"},
+		"BCH": {
+			"bitcoincash:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a",
+			"qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a",
+			"1BpEi6DfDAUFd7GtittLSdBeYJvcoaVggu",
+		},
+		"ETH":  {"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"},
+		"XRP":  {"rHb9CJAWyB4rj91VRWn96DkukG4bwdtyTh", "rHb9CJAWyB4rj91VRWn96DkukG4bwdtyTh?dt=123", "X7AcgcsBL6XDcUb289X4mJ8djcdyKaB5hJDWMArnXr61cqZ"},
+		"FIO":  {acc.PubKey},
+		"EOS":  {"eosio.token"},
+		"DOGE": {"anything"},
+	}
+	for chain, addrs := range valid {
+		for _, a := range addrs {
+			if err = ValidateChainPubAddress(chain, a); err != nil {
+				t.Error(err)
+			}
+		}
+	}
+	invalid := map[string][]string{
+		"BTC": {
+			"bc1qtest",
+			"1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN3",
+			"bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t5",
+			"bc1qW508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4",
+			"bc1pw508d6qejxtdg4y5r3zarvary0c5xw7kw508d6qejxtdg4y5r3zarvary0c5xw7k7grplx",
+			"ltc1qw508d6qejxtdg4y5r3zarvary0c5xw7kgmn4n9",
+			"LVg2kJoFNg45Nbpy53h7Fe1wKyeXVRhMH9",
+		},
+		"LTC": {"1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4"},
+		"BCH": {"bitcoincash:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6b", "bitcoincash:Qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a", "bitcoin:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a"},
+		"ETH": {"0xtest", "5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD", "0x5aaeb6053f3e94c9b9a09f33669435e7ef1bea"},
+		"XRP": {"rHb9CJAWyB4rj91VRWn96DkukG4bwdtyTi", "rHb9CJAWyB4rj91VRWn96DkukG4bwdtyTh?dt=abc", "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2"},
+		"FIO": {"EOS" + strings.TrimPrefix(acc.PubKey, "FIO"), "FIO1234"},
+		"EOS": {"Invalid!", "thisnameistoolong"},
+	}
+	for chain, addrs := range invalid {
+		for _, a := range addrs {
+			err = ValidateChainPubAddress(chain, a)
+			if ve, ok := err.(*ValidationError); !ok || !ve.Has(ReasonInvalidFormat) {
+				t.Errorf("expected invalid %s address %s: %v", chain, a, err)
+			}
+		}
+	}
+	if err = ValidateChainPubAddress("DOGE", "much wow"); err == nil {
+		t.Error("chains without a validator should still get the basic checks")
+	}
+}
+
+func TestRegisterPubAddressValidator(t *testing.T) {
+	acc, err := NewRandomAccount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	RegisterPubAddressValidator("doge", func(publicAddress string) error {
+		if !strings.HasPrefix(publicAddress, "D") {
+			return errors.New("must start with D")
+		}
+		return nil
+	})
+	defer RegisterPubAddressValidator("DOGE", nil)
+	if _, err = NewValidAddAddress(acc.Actor, "test@test", "DOGE", "DOGE", "xyz"); err == nil {
+		t.Error("expected registered validator to reject the address")
+	}
+	if _, err = NewValidAddAddress(acc.Actor, "test@test", "DOGE", "DOGE", "DH5yaieqoZN36fDVciNyRueRGvGLR3mr7L"); err != nil {
+		t.Error(err)
+	}
+	// builders check each mapping before the fee is spent
+	addrs := []TokenPubAddr{
+		{ChainCode: "ETH", TokenCode: "USDT", PublicAddress: "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"},
+		{ChainCode: "BTC", TokenCode: "BTC", PublicAddress: "bc1qtest"},
+	}
+	if _, ok := NewAddAddresses(acc.Actor, "test@test", addrs); ok {
+		t.Error("expected invalid BTC address to be rejected")
+	}
+	RegisterPubAddressValidator("BTC", nil)
+	defer RegisterPubAddressValidator("BTC", validateBtcAddress)
+	if _, ok := NewAddAddresses(acc.Actor, "test@test", addrs); !ok {
+		t.Error("BTC address should only get the basic checks without a validator")
+	}
+}
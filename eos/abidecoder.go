@@ -3,9 +3,11 @@ package eos
 import (
 	"encoding/hex"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 	"go.uber.org/zap"
 )
@@ -68,9 +70,13 @@ func (a *ABI) decodeFields(binaryDecoder *Decoder, fields []FieldDef, json []byt
 	resultingJSON := json
 	for _, field := range fields {
 		fieldType, isOptional, isArray, isBinaryExtension := analyzeFieldType(field.Type)
-		if isBinaryExtension && !binaryDecoder.hasRemaining() {
-			abiDecoderLog.Debug("type is a binary extension and no more data, skipping field", zap.String("type", field.Type))
-			continue
+		if isBinaryExtension {
+			if !binaryDecoder.hasRemaining() {
+				abiDecoderLog.Debug("type is a binary extension and no more data, skipping field", zap.String("type", field.Type))
+				continue
+			}
+			// the extension may wrap an optional or array, ie "string[]$"
+			fieldType, isOptional, isArray, _ = analyzeFieldType(fieldType)
 		}
 
 		typeName, isAlias := a.TypeNameForNewTypeName(fieldType)
@@ -105,10 +111,25 @@ func (a *ABI) decodeField(binaryDecoder *Decoder, fieldName string, fieldType st
 		}
 	}
 
+	isFixedArray := false
+	var length uint64
+	if !isArray {
+		if elementType, size, ok := analyzeFixedArrayType(fieldType); ok {
+			fieldType, length, isArray, isFixedArray = elementType, uint64(size), true, true
+		} else if innerType, innerOptional, innerArray, _ := analyzeFieldType(fieldType); innerOptional || innerArray {
+			// nested type such as optional<vector<T>>, ie "string[]?"
+			innerType, _ = a.TypeNameForNewTypeName(innerType)
+			return a.decodeField(binaryDecoder, fieldName, innerType, innerOptional, innerArray, resultingJSON)
+		}
+	}
+
 	if isArray {
-		length, err := binaryDecoder.ReadUvarint64()
-		if err != nil {
-			return nil, fmt.Errorf("reading field [%s] array length: %s", fieldName, err)
+		var err error
+		if !isFixedArray {
+			length, err = binaryDecoder.ReadUvarint64()
+			if err != nil {
+				return nil, fmt.Errorf("reading field [%s] array length: %s", fieldName, err)
+			}
 		}
 
 		if length == 0 {
@@ -122,7 +143,7 @@ func (a *ABI) decodeField(binaryDecoder *Decoder, fieldName string, fieldType st
 		for i := uint64(0); i < length; i++ {
 			abiDecoderLog.Debug("adding value for field", zap.String("name", fieldName), zap.Uint64("index", i))
 			indexedFieldName := fmt.Sprintf("%s.%d", fieldName, i)
-			resultingJSON, err = a.read(binaryDecoder, indexedFieldName, fieldType, resultingJSON)
+			resultingJSON, err = a.readElement(binaryDecoder, indexedFieldName, fieldType, resultingJSON)
 			if err != nil {
 				return nil, fmt.Errorf("reading field [%s] index [%d]: %s", fieldName, i, err)
 			}
@@ -139,6 +160,27 @@ func (a *ABI) decodeField(binaryDecoder *Decoder, fieldName string, fieldType st
 	return resultingJSON, nil
 }
 
+// readElement reads a single item of an array. The item may itself be an optional or an array, missing optional
+// items are set to null so the indexes of the following items are preserved.
+func (a *ABI) readElement(binaryDecoder *Decoder, fieldName string, fieldType string, json []byte) ([]byte, error) {
+	fieldType, _ = a.TypeNameForNewTypeName(fieldType)
+	elementType, isOptional, isArray, _ := analyzeFieldType(fieldType)
+	_, _, isFixedArray := analyzeFixedArrayType(fieldType)
+	if !isOptional && !isArray && !isFixedArray {
+		return a.read(binaryDecoder, fieldName, fieldType, json)
+	}
+
+	elementType, _ = a.TypeNameForNewTypeName(elementType)
+	resultingJSON, err := a.decodeField(binaryDecoder, fieldName, elementType, isOptional, isArray, json)
+	if err != nil {
+		return nil, err
+	}
+	if !gjson.GetBytes(resultingJSON, fieldName).Exists() {
+		return sjson.SetRawBytes(resultingJSON, fieldName, []byte("null"))
+	}
+	return resultingJSON, nil
+}
+
 func (a *ABI) read(binaryDecoder *Decoder, fieldName string, fieldType string, json []byte) ([]byte, error) {
 	variant := a.VariantForName(fieldType)
 	if variant != nil {
@@ -317,3 +359,17 @@ func analyzeFieldType(fieldType string) (typeName string, isOptional bool, isArr
 
 	return fieldType, false, false, false
 }
+
+// analyzeFixedArrayType checks for a fixed-size array (ABI 1.2), ie "checksum256[2]", which is encoded without a
+// length prefix.
+func analyzeFixedArrayType(fieldType string) (typeName string, size int, isFixedArray bool) {
+	open := strings.LastIndex(fieldType, "[")
+	if open < 0 || !strings.HasSuffix(fieldType, "]") {
+		return fieldType, 0, false
+	}
+	size, err := strconv.Atoi(fieldType[open+1 : len(fieldType)-1])
+	if err != nil || size < 0 {
+		return fieldType, 0, false
+	}
+	return fieldType[:open], size, true
+}
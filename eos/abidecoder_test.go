@@ -592,6 +592,68 @@ func TestABI_decode_BinaryExtension(t *testing.T) {
 	assert.JSONEq(t, `{"id":0,"name":"name"}`, string(json))
 }
 
+func TestABI_decode_BinaryExtensionWrapsArray(t *testing.T) {
+	abi := &ABI{
+		Structs: []StructDef{
+			{
+				Name: "root",
+				Fields: []FieldDef{
+					{Name: "id", Type: "uint8"},
+					{Name: "items", Type: "uint8[]$"},
+				},
+			},
+		},
+	}
+
+	json, err := abi.decode(NewDecoder(HexString("00")), "root")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"id":0}`, string(json))
+
+	json, err = abi.decode(NewDecoder(HexString("0002010a")), "root")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"id":0,"items":[1,10]}`, string(json))
+}
+
+func TestABI_decode_FixedArray(t *testing.T) {
+	abi := &ABI{
+		Types: []ABIType{
+			{NewTypeName: "pair", Type: "uint8[2]"},
+		},
+		Structs: []StructDef{
+			{
+				Name: "root",
+				Fields: []FieldDef{
+					{Name: "fixed", Type: "uint8[3]"},
+					{Name: "pairs", Type: "pair[]"},
+				},
+			},
+		},
+	}
+
+	json, err := abi.decode(NewDecoder(HexString("0102030203040506")), "root")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"fixed":[1,2,3],"pairs":[[3,4],[5,6]]}`, string(json))
+}
+
+func TestABI_decode_NestedOptionalArray(t *testing.T) {
+	abi := &ABI{
+		Structs: []StructDef{
+			{
+				Name: "root",
+				Fields: []FieldDef{
+					{Name: "optional_array", Type: "uint8[]?"},
+					{Name: "array_of_optionals", Type: "uint8?[]"},
+					{Name: "missing", Type: "uint8[]?"},
+				},
+			},
+		},
+	}
+
+	json, err := abi.decode(NewDecoder(HexString("01020102030001030000")), "root")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"optional_array":[1,2],"array_of_optionals":[null,3,null]}`, string(json))
+}
+
 func TestABI_decodeFields(t *testing.T) {
 	types := []ABIType{
 		{NewTypeName: "action.type.1", Type: "name"},
@@ -799,6 +861,30 @@ func TestABIDecoder_analyseFieldType(t *testing.T) {
 	}
 }
 
+func TestABIDecoder_analyzeFixedArrayType(t *testing.T) {
+	testCases := []struct {
+		fieldType    string
+		expectedName string
+		expectedSize int
+		expectedOk   bool
+	}{
+		{"checksum256[2]", "checksum256", 2, true},
+		{"uint8[0]", "uint8", 0, true},
+		{"uint8[2][3]", "uint8[2]", 3, true},
+		{"uint8[]", "uint8[]", 0, false},
+		{"uint8", "uint8", 0, false},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.fieldType, func(t *testing.T) {
+			name, size, ok := analyzeFixedArrayType(test.fieldType)
+			assert.Equal(t, test.expectedName, name)
+			assert.Equal(t, test.expectedSize, size)
+			assert.Equal(t, test.expectedOk, ok)
+		})
+	}
+}
+
 func HexString(input string) []byte {
 	buffer, err := hex.DecodeString(input)
 	if err != nil {
@@ -50,7 +50,8 @@ func (a *ABI) encode(binaryEncoder *Encoder, structureName string, json []byte)
 
 	if structure.Base != "" {
 		abiEncoderLog.Debug("struct has base struct", zap.String("struct", structureName), zap.String("base", structure.Base))
-		err := a.encode(binaryEncoder, structure.Base, json)
+		baseName, _ := a.TypeNameForNewTypeName(structure.Base)
+		err := a.encode(binaryEncoder, baseName, json)
 		if err != nil {
 			return fmt.Errorf("encode base [%s]: %s", structureName, err)
 		}
@@ -65,11 +66,23 @@ func (a *ABI) encodeFields(binaryEncoder *Encoder, fields []FieldDef, json []byt
 	defer func(prev *zap.Logger) { encoderLog = prev }(encoderLog)
 	encoderLog = encoderLog.Named("fields")
 
+	missingExtension := ""
 	for _, field := range fields {
 
 		abiEncoderLog.Debug("encode field", zap.String("name", field.Name), zap.String("type", field.Type))
 
-		fieldType, isOptional, isArray, _ := analyzeFieldType(field.Type)
+		fieldType, isOptional, isArray, isBinaryExtension := analyzeFieldType(field.Type)
+		if isBinaryExtension {
+			if !gjson.GetBytes(json, field.Name).Exists() {
+				abiEncoderLog.Debug("field is a binary extension and not present, skipping", zap.String("name", field.Name))
+				missingExtension = field.Name
+				continue
+			}
+			if missingExtension != "" {
+				return fmt.Errorf("encoding fields: binary extension [%s] is present but [%s] is not, only trailing extensions can be omitted", field.Name, missingExtension)
+			}
+			fieldType, isOptional, isArray, _ = analyzeFieldType(fieldType)
+		}
 		typeName, isAlias := a.TypeNameForNewTypeName(fieldType)
 		fieldName := field.Name
 		if isAlias {
@@ -89,35 +102,63 @@ func (a *ABI) encodeField(binaryEncoder *Encoder, fieldName string, fieldType st
 	abiEncoderLog.Debug("encode field json", zap.ByteString("json", json))
 
 	value := gjson.GetBytes(json, fieldName)
-	if isOptional {
-		if value.Exists() {
-			abiEncoderLog.Debug("field is optional and present", zap.String("name", fieldName), zap.String("type", fieldType))
-			if e := binaryEncoder.writeByte(1); e != nil {
-				return e
-			}
-		} else {
-			abiEncoderLog.Debug("field is optional and *not* present", zap.String("name", fieldName), zap.String("type", fieldType))
-			return binaryEncoder.writeByte(0)
-		}
-
-	} else if !value.Exists() {
+	if !isOptional && !value.Exists() {
 		return fmt.Errorf("encode field: none optional field [%s] as a nil value", fieldName)
 	}
 
 	if isArray {
+		fieldType += "[]"
+	}
+	if isOptional {
+		fieldType += "?"
+	}
+	return a.writeValue(binaryEncoder, fieldName, fieldType, value)
+}
 
+// writeValue writes a value whose type can have optional, array and fixed-size array modifiers, which may be nested
+// such as "string[]?" for an optional<vector<string>>.
+func (a *ABI) writeValue(binaryEncoder *Encoder, fieldName string, fieldType string, value gjson.Result) error {
+	fieldType, _ = a.TypeNameForNewTypeName(fieldType)
+	elementType, isOptional, isArray, _ := analyzeFieldType(fieldType)
+	length := -1
+	if !isOptional && !isArray {
+		if t, size, ok := analyzeFixedArrayType(fieldType); ok {
+			elementType, length, isArray = t, size, true
+		}
+	}
+
+	switch {
+	case isOptional:
+		if !value.Exists() || value.Type == gjson.Null {
+			abiEncoderLog.Debug("field is optional and *not* present", zap.String("name", fieldName), zap.String("type", fieldType))
+			return binaryEncoder.writeByte(0)
+		}
+		abiEncoderLog.Debug("field is optional and present", zap.String("name", fieldName), zap.String("type", fieldType))
+		if e := binaryEncoder.writeByte(1); e != nil {
+			return e
+		}
+		return a.writeValue(binaryEncoder, fieldName, elementType, value)
+
+	case isArray:
 		abiEncoderLog.Debug("field is an array", zap.String("name", fieldName), zap.String("type", fieldType))
 		if !value.IsArray() {
 			return fmt.Errorf("encode field: expected array for field [%s] got [%s]", fieldName, value.Type.String())
 		}
 
 		results := value.Array()
-		binaryEncoder.writeUVarInt(len(results))
-
-		for _, r := range results {
-			a.writeField(binaryEncoder, fieldName, fieldType, r)
+		if length < 0 {
+			if err := binaryEncoder.writeUVarInt(len(results)); err != nil {
+				return err
+			}
+		} else if len(results) != length {
+			return fmt.Errorf("encode field: expected %d items for field [%s] got %d", length, fieldName, len(results))
 		}
 
+		for i, r := range results {
+			if err := a.writeValue(binaryEncoder, fmt.Sprintf("%s.%d", fieldName, i), elementType, r); err != nil {
+				return err
+			}
+		}
 		return nil
 	}
 
@@ -132,11 +173,7 @@ func (a *ABI) writeField(binaryEncoder *Encoder, fieldName string, fieldType str
 	if structure != nil {
 		abiEncoderLog.Debug("field is a struct", zap.String("name", fieldName))
 
-		err := a.encodeFields(binaryEncoder, structure.Fields, []byte(value.Raw))
-		if err != nil {
-			return err
-		}
-		return nil
+		return a.encode(binaryEncoder, fieldType, []byte(value.Raw))
 	}
 
 	var object interface{}
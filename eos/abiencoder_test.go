@@ -78,7 +78,7 @@ var abiData = []byte(`{
 	},
 	"struct_1_field_3": "struct_1_field_3_value",
 	//"struct_1_field_4": "struct_1_field_4_value",
-	"struct_1_field_5": ["struct_1_field_5_value_1","struct_1_field_5_value_2"],
+	"struct_1_field_5": [{"struct_4_field_1": "struct_1_field_5_value_1"},{"struct_4_field_1": "struct_1_field_5_value_2"}],
 }`)
 
 func TestABIEncoder_Encode(t *testing.T) {
@@ -342,3 +342,61 @@ func (w mockWriter) Write(p []byte) (n int, err error) {
 func (w mockWriter) Bytes() []byte {
 	return []byte{}
 }
+
+func TestABIEncoder_RoundTrip(t *testing.T) {
+	abi := &ABI{
+		Types: []ABIType{
+			{NewTypeName: "pair", Type: "uint8[2]"},
+		},
+		Structs: []StructDef{
+			{
+				Name: "base",
+				Fields: []FieldDef{
+					{Name: "id", Type: "uint8"},
+				},
+			},
+			{
+				Name: "root",
+				Base: "base",
+				Fields: []FieldDef{
+					{Name: "pairs", Type: "pair[]"},
+					{Name: "optional_array", Type: "uint8[]?"},
+					{Name: "array_of_optionals", Type: "uint8?[]"},
+					{Name: "nested", Type: "base[]"},
+					{Name: "ext_1", Type: "string$"},
+					{Name: "ext_2", Type: "uint8[]$"},
+				},
+			},
+		},
+	}
+
+	testCases := []struct {
+		name     string
+		json     string
+		expected string
+	}{
+		{"all extensions", `{"id":1,"pairs":[[1,2]],"optional_array":[3],"array_of_optionals":[null,4],"nested":[{"id":5}],"ext_1":"a","ext_2":[6]}`, "0101010201010302000104010501610106"},
+		{"trailing extension missing", `{"id":1,"pairs":[],"array_of_optionals":[],"nested":[],"ext_1":"a"}`, "01000000000161"},
+		{"all extensions missing", `{"id":1,"pairs":[],"array_of_optionals":[],"nested":[]}`, "0100000000"},
+	}
+
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			var buffer bytes.Buffer
+			err := abi.encode(NewEncoder(&buffer), "root", []byte(c.json))
+			require.NoError(t, err)
+			assert.Equal(t, c.expected, hex.EncodeToString(buffer.Bytes()))
+
+			json, err := abi.decode(NewDecoder(buffer.Bytes()), "root")
+			require.NoError(t, err)
+			assert.JSONEq(t, c.json, string(json))
+		})
+	}
+
+	var buffer bytes.Buffer
+	err := abi.encode(NewEncoder(&buffer), "root", []byte(`{"id":1,"pairs":[],"array_of_optionals":[],"nested":[],"ext_2":[1]}`))
+	assert.Error(t, err, "extension present after a missing extension")
+
+	err = abi.encode(NewEncoder(&buffer), "root", []byte(`{"id":1,"pairs":[[1]],"array_of_optionals":[],"nested":[]}`))
+	assert.Equal(t, fmt.Errorf("encoding fields: encode field: expected 2 items for field [pairs.0] got 1"), err)
+}
@@ -0,0 +1,293 @@
+// Package abigen generates Go bindings from a contract ABI: a struct for every ABI struct with the json and binary
+// tags needed by the eos encoder, a constructor for every action, and constants for the action and table names.
+//
+// The generated code only depends on the eos (and eos/ecc) packages. See eos/cmd/eos-abigen for a command line tool.
+package abigen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/fioprotocol/fio-go/eos"
+)
+
+const (
+	eosImport = "github.com/fioprotocol/fio-go/eos"
+	eccImport = "github.com/fioprotocol/fio-go/eos/ecc"
+)
+
+// builtins maps the ABI built-in types to the Go type used by the eos encoder, and the import it requires
+var builtins = map[string][2]string{
+	"bool":                 {"bool", ""},
+	"int8":                 {"int8", ""},
+	"uint8":                {"uint8", ""},
+	"int16":                {"int16", ""},
+	"uint16":               {"uint16", ""},
+	"int32":                {"int32", ""},
+	"uint32":               {"uint32", ""},
+	"int64":                {"eos.Int64", eosImport},
+	"uint64":               {"eos.Uint64", eosImport},
+	"int128":               {"eos.Int128", eosImport},
+	"uint128":              {"eos.Uint128", eosImport},
+	"varint32":             {"eos.Varint32", eosImport},
+	"varuint32":            {"eos.Varuint32", eosImport},
+	"float32":              {"float32", ""},
+	"float64":              {"float64", ""},
+	"float128":             {"eos.Float128", eosImport},
+	"time_point":           {"eos.TimePoint", eosImport},
+	"time_point_sec":       {"eos.TimePointSec", eosImport},
+	"block_timestamp_type": {"eos.BlockTimestamp", eosImport},
+	"name":                 {"eos.Name", eosImport},
+	"bytes":                {"eos.HexBytes", eosImport},
+	"string":               {"string", ""},
+	"checksum160":          {"eos.Checksum160", eosImport},
+	"checksum256":          {"eos.Checksum256", eosImport},
+	"checksum512":          {"eos.Checksum512", eosImport},
+	"public_key":           {"ecc.PublicKey", eccImport},
+	"signature":            {"ecc.Signature", eccImport},
+	"symbol":               {"eos.Symbol", eosImport},
+	"symbol_code":          {"eos.SymbolCode", eosImport},
+	"asset":                {"eos.Asset", eosImport},
+	"extended_asset":       {"eos.ExtendedAsset", eosImport},
+}
+
+// Options controls the generated code
+type Options struct {
+	Package  string          // name of the generated package, required
+	Contract eos.AccountName // account the contract is deployed on, required
+	Source   string          // optional description of where the ABI came from, added to the header comment
+}
+
+// generator holds the state for a single file
+type generator struct {
+	abi     *eos.ABI
+	opts    Options
+	imports map[string]bool
+	buf     *bytes.Buffer
+}
+
+// Generate creates formatted Go source for an ABI
+func Generate(abi *eos.ABI, opts Options) ([]byte, error) {
+	if abi == nil {
+		return nil, fmt.Errorf("abi is nil")
+	}
+	if opts.Package == "" || opts.Contract == "" {
+		return nil, fmt.Errorf("package and contract are required")
+	}
+	g := &generator{
+		abi:     abi,
+		opts:    opts,
+		imports: map[string]bool{eosImport: true},
+		buf:     bytes.NewBuffer(nil),
+	}
+	if err := g.body(); err != nil {
+		return nil, err
+	}
+
+	out := bytes.NewBuffer(nil)
+	source := ""
+	if opts.Source != "" {
+		source = " (" + opts.Source + ")"
+	}
+	fmt.Fprintf(out, "// Code generated by eos-abigen from the %s ABI%s. DO NOT EDIT.\n\n", opts.Contract, source)
+	fmt.Fprintf(out, "package %s\n\n", opts.Package)
+	imports := make([]string, 0, len(g.imports))
+	for imp := range g.imports {
+		imports = append(imports, imp)
+	}
+	sort.Strings(imports)
+	out.WriteString("import (\n")
+	for _, imp := range imports {
+		fmt.Fprintf(out, "\t%q\n", imp)
+	}
+	out.WriteString(")\n\n")
+	out.Write(g.buf.Bytes())
+
+	formatted, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %s", err)
+	}
+	return formatted, nil
+}
+
+func (g *generator) printf(format string, a ...interface{}) {
+	fmt.Fprintf(g.buf, format, a...)
+}
+
+func (g *generator) body() error {
+	g.printf("// Contract is the account the %s contract is deployed on\n", g.opts.Contract)
+	g.printf("const Contract = eos.AccountName(%q)\n\n", g.opts.Contract)
+
+	if len(g.abi.Actions) > 0 {
+		g.printf("// Action names\nconst (\n")
+		for _, a := range g.abi.Actions {
+			g.printf("\tAction%s = eos.ActionName(%q)\n", GoName(string(a.Name)), a.Name)
+		}
+		g.printf(")\n\n")
+	}
+	if len(g.abi.Tables) > 0 {
+		g.printf("// Table names\nconst (\n")
+		for _, t := range g.abi.Tables {
+			g.printf("\tTable%s = eos.TableName(%q)\n", GoName(string(t.Name)), t.Name)
+		}
+		g.printf(")\n\n")
+	}
+
+	for _, t := range g.abi.Types {
+		goType, err := g.goType(t.Type)
+		if err != nil {
+			return fmt.Errorf("type %s: %s", t.NewTypeName, err)
+		}
+		g.printf("// %s is the %s type, an alias for %s\n", GoName(t.NewTypeName), t.NewTypeName, t.Type)
+		g.printf("type %s = %s\n\n", GoName(t.NewTypeName), goType)
+	}
+
+	for _, s := range g.abi.Structs {
+		if err := g.structDef(s); err != nil {
+			return fmt.Errorf("struct %s: %s", s.Name, err)
+		}
+	}
+
+	for _, a := range g.abi.Actions {
+		if g.abi.StructForName(a.Type) == nil {
+			if _, isAlias := g.abi.TypeNameForNewTypeName(a.Type); !isAlias {
+				return fmt.Errorf("action %s: type %s not found in abi", a.Name, a.Type)
+			}
+		}
+		name := GoName(string(a.Name))
+		g.printf("// New%s creates a %s::%s action authorized by actor@active\n", name, g.opts.Contract, a.Name)
+		g.printf("func New%s(actor eos.AccountName, data %s) *eos.Action {\n", name, GoName(a.Type))
+		g.printf("\treturn &eos.Action{\n")
+		g.printf("\t\tAccount: Contract,\n")
+		g.printf("\t\tName: Action%s,\n", name)
+		g.printf("\t\tAuthorization: []eos.PermissionLevel{{Actor: actor, Permission: \"active\"}},\n")
+		g.printf("\t\tActionData: eos.NewActionData(data),\n")
+		g.printf("\t}\n}\n\n")
+	}
+	return nil
+}
+
+func (g *generator) structDef(s eos.StructDef) error {
+	name := GoName(s.Name)
+	tables := make([]string, 0)
+	for _, t := range g.abi.Tables {
+		if t.Type == s.Name {
+			tables = append(tables, string(t.Name))
+		}
+	}
+	if len(tables) > 0 {
+		g.printf("// %s is the %s struct, and the row type of the %s table\n", name, s.Name, strings.Join(tables, ", "))
+	} else {
+		g.printf("// %s is the %s struct\n", name, s.Name)
+	}
+	g.printf("type %s struct {\n", name)
+	if s.Base != "" {
+		if g.abi.StructForName(s.Base) == nil {
+			return fmt.Errorf("base %s not found in abi", s.Base)
+		}
+		g.printf("\t%s\n", GoName(s.Base))
+	}
+	for _, f := range s.Fields {
+		fieldType := f.Type
+		eosTag := ""
+		omitEmpty := ""
+		if strings.HasSuffix(fieldType, "$") {
+			fieldType = strings.TrimSuffix(fieldType, "$")
+			eosTag = "binary_extension"
+			omitEmpty = ",omitempty"
+		}
+		if strings.HasSuffix(fieldType, "?") {
+			if eosTag != "" {
+				return fmt.Errorf("field %s: optional binary extensions are not supported", f.Name)
+			}
+			eosTag = "optional"
+			omitEmpty = ",omitempty"
+		}
+		goType, err := g.goType(fieldType)
+		if err != nil {
+			return fmt.Errorf("field %s: %s", f.Name, err)
+		}
+		tag := fmt.Sprintf(`json:"%s%s"`, f.Name, omitEmpty)
+		if eosTag != "" {
+			tag += fmt.Sprintf(` eos:"%s"`, eosTag)
+		}
+		g.printf("\t%s %s `%s`\n", GoName(f.Name), goType, tag)
+	}
+	g.printf("}\n\n")
+	return nil
+}
+
+// goType converts an ABI type, including any optional and array modifiers, to the Go type
+func (g *generator) goType(abiType string) (string, error) {
+	switch {
+	case strings.HasSuffix(abiType, "?"):
+		inner, err := g.goType(strings.TrimSuffix(abiType, "?"))
+		if err != nil {
+			return "", err
+		}
+		return "*" + inner, nil
+	case strings.HasSuffix(abiType, "[]"):
+		elem := strings.TrimSuffix(abiType, "[]")
+		if strings.HasSuffix(elem, "?") {
+			return "", fmt.Errorf("arrays of optional values (%s) are not supported by the eos encoder", abiType)
+		}
+		inner, err := g.goType(elem)
+		if err != nil {
+			return "", err
+		}
+		return "[]" + inner, nil
+	case strings.HasSuffix(abiType, "]"):
+		open := strings.LastIndex(abiType, "[")
+		size, err := strconv.Atoi(abiType[open+1 : len(abiType)-1])
+		if open < 0 || err != nil {
+			return "", fmt.Errorf("invalid array type %s", abiType)
+		}
+		elem := abiType[:open]
+		if strings.HasSuffix(elem, "?") {
+			return "", fmt.Errorf("arrays of optional values (%s) are not supported by the eos encoder", abiType)
+		}
+		inner, err := g.goType(elem)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("[%d]%s", size, inner), nil
+	}
+
+	if b, ok := builtins[abiType]; ok {
+		if b[1] != "" {
+			g.imports[b[1]] = true
+		}
+		return b[0], nil
+	}
+	if _, isAlias := g.abi.TypeNameForNewTypeName(abiType); isAlias {
+		return GoName(abiType), nil
+	}
+	if g.abi.StructForName(abiType) != nil {
+		return GoName(abiType), nil
+	}
+	if g.abi.VariantForName(abiType) != nil {
+		return "", fmt.Errorf("variant %s is not supported", abiType)
+	}
+	return "", fmt.Errorf("unknown type %s", abiType)
+}
+
+// GoName converts an ABI identifier to an exported Go identifier, ie "add_to_whitelist" becomes "AddToWhitelist"
+func GoName(name string) string {
+	parts := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	buf := bytes.NewBuffer(nil)
+	for _, p := range parts {
+		buf.WriteString(strings.ToUpper(p[:1]) + p[1:])
+	}
+	s := buf.String()
+	if s == "" || unicode.IsDigit(rune(s[0])) {
+		s = "X" + s
+	}
+	return s
+}
@@ -0,0 +1,214 @@
+package abigen
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fioprotocol/fio-go/eos"
+)
+
+const testAbi = `{
+  "version": "eosio::abi/1.1",
+  "types": [{"new_type_name": "fio_name", "type": "string"}],
+  "structs": [
+    {"name": "base_action", "base": "", "fields": [{"name": "actor", "type": "name"}]},
+    {"name": "regaddress", "base": "base_action", "fields": [
+      {"name": "fio_address", "type": "fio_name"},
+      {"name": "owner_fio_public_key", "type": "string"},
+      {"name": "max_fee", "type": "int64"},
+      {"name": "tpid", "type": "string"}
+    ]},
+    {"name": "add_to_whitelist", "base": "", "fields": [
+      {"name": "hashes", "type": "checksum256[2]"},
+      {"name": "owner_key", "type": "public_key?"},
+      {"name": "amount", "type": "uint64?"},
+      {"name": "note", "type": "string?"},
+      {"name": "memo", "type": "string$"}
+    ]},
+    {"name": "fioname", "base": "", "fields": [
+      {"name": "id", "type": "uint64"},
+      {"name": "name", "type": "string"},
+      {"name": "addresses", "type": "tokenpubaddr[]"}
+    ]},
+    {"name": "tokenpubaddr", "base": "", "fields": [
+      {"name": "token_code", "type": "string"},
+      {"name": "chain_code", "type": "string"},
+      {"name": "public_address", "type": "string"}
+    ]}
+  ],
+  "actions": [
+    {"name": "regaddress", "type": "regaddress", "ricardian_contract": ""},
+    {"name": "addtowl", "type": "add_to_whitelist", "ricardian_contract": ""}
+  ],
+  "tables": [
+    {"name": "fionames", "index_type": "i64", "key_names": [], "key_types": [], "type": "fioname"}
+  ]
+}`
+
+func TestGenerate(t *testing.T) {
+	abi, err := eos.NewABI(strings.NewReader(testAbi))
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := Generate(abi, Options{Package: "fioaddress", Contract: "fio.address"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// compare with whitespace collapsed, so the tests do not depend on gofmt alignment
+	collapse := func(s string) string {
+		return strings.Join(strings.Fields(s), " ")
+	}
+	src := collapse(string(code))
+	for _, want := range []string{
+		`package fioaddress`,
+		`"github.com/fioprotocol/fio-go/eos/ecc"`,
+		`const Contract = eos.AccountName("fio.address")`,
+		`ActionAddtowl     = eos.ActionName("addtowl")`,
+		`TableFionames = eos.TableName("fionames")`,
+		`type FioName = string`,
+		"BaseAction\n",
+		"FioAddress        FioName    `json:\"fio_address\"`",
+		"MaxFee            eos.Int64  `json:\"max_fee\"`",
+		"Hashes   [2]eos.Checksum256 `json:\"hashes\"`",
+		"OwnerKey *ecc.PublicKey     `json:\"owner_key,omitempty\" eos:\"optional\"`",
+		"Memo     string             `json:\"memo,omitempty\" eos:\"binary_extension\"`",
+		"Addresses []Tokenpubaddr `json:\"addresses\"`",
+		`// Fioname is the fioname struct, and the row type of the fionames table`,
+		`func NewAddtowl(actor eos.AccountName, data AddToWhitelist) *eos.Action {`,
+	} {
+		if !strings.Contains(src, collapse(want)) {
+			t.Errorf("generated code is missing %q", want)
+		}
+	}
+	if t.Failed() {
+		t.Log(string(code))
+	}
+}
+
+// roundTripMain builds actions with the generated code, and checks that the data decodes to the same value with
+// optional fields present and absent
+const roundTripMain = `package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/fioprotocol/fio-go/eos"
+	"github.com/fioprotocol/fio-go/eos/ecc"
+)
+
+func roundTrip(in AddToWhitelist) error {
+	if _, err := eos.MarshalBinary(NewAddtowl("alice", in)); err != nil {
+		return fmt.Errorf("encoding action: %s", err)
+	}
+	b, err := eos.MarshalBinary(in)
+	if err != nil {
+		return fmt.Errorf("encoding: %s", err)
+	}
+	out := AddToWhitelist{}
+	if err = eos.UnmarshalBinary(b, &out); err != nil {
+		return fmt.Errorf("decoding: %s", err)
+	}
+	again, err := eos.MarshalBinary(out)
+	if err != nil {
+		return fmt.Errorf("encoding decoded value: %s", err)
+	}
+	if !bytes.Equal(b, again) {
+		return fmt.Errorf("round trip changed the data: %x != %x", b, again)
+	}
+	if (in.OwnerKey == nil) != (out.OwnerKey == nil) || (in.Amount == nil) != (out.Amount == nil) ||
+		(in.Note == nil) != (out.Note == nil) {
+		return fmt.Errorf("optional fields changed: %+v", out)
+	}
+	if out.Amount != nil && *out.Amount != *in.Amount || out.Note != nil && *out.Note != *in.Note ||
+		out.OwnerKey != nil && out.OwnerKey.String() != in.OwnerKey.String() || out.Memo != in.Memo {
+		return fmt.Errorf("values changed: %+v", out)
+	}
+	return nil
+}
+
+func main() {
+	key := ecc.MustNewPublicKey("FIO6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV")
+	amount := eos.Uint64(5)
+	note := "note"
+	hashes := [2]eos.Checksum256{make(eos.Checksum256, 32), make(eos.Checksum256, 32)}
+	for i, v := range []AddToWhitelist{
+		{Hashes: hashes, OwnerKey: &key, Amount: &amount, Note: &note, Memo: "memo"},
+		{Hashes: hashes},
+	} {
+		if err := roundTrip(v); err != nil {
+			fmt.Println(i, err)
+			os.Exit(1)
+		}
+	}
+	fmt.Println("ok")
+}
+`
+
+func TestGenerate_RoundTrip(t *testing.T) {
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go tool not found")
+	}
+	abi, err := eos.NewABI(strings.NewReader(testAbi))
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := Generate(abi, Options{Package: "main", Contract: "fio.address"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the directory is inside the module so the generated code uses this copy of the eos package, the leading
+	// underscore keeps it out of ./... patterns
+	dir, err := ioutil.TempDir(".", "_roundtrip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err = ioutil.WriteFile(filepath.Join(dir, "fioaddress.go"), code, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(filepath.Join(dir, "main.go"), []byte(roundTripMain), 0644); err != nil {
+		t.Fatal(err)
+	}
+	out, err := exec.Command(goBin, "run", "./"+filepath.Base(dir)).CombinedOutput()
+	if err != nil || strings.TrimSpace(string(out)) != "ok" {
+		t.Errorf("generated code failed: %s\n%s", err, out)
+		t.Log(string(code))
+	}
+}
+
+func TestGenerate_Unsupported(t *testing.T) {
+	abi := &eos.ABI{
+		Structs:  []eos.StructDef{{Name: "s", Fields: []eos.FieldDef{{Name: "v", Type: "var"}}}},
+		Variants: []eos.VariantDef{{Name: "var", Types: []string{"string", "uint8"}}},
+	}
+	if _, err := Generate(abi, Options{Package: "p", Contract: "c"}); err == nil {
+		t.Error("expected variants to be rejected")
+	}
+	abi = &eos.ABI{
+		Structs: []eos.StructDef{{Name: "s", Fields: []eos.FieldDef{{Name: "v", Type: "string?[]"}}}},
+	}
+	if _, err := Generate(abi, Options{Package: "p", Contract: "c"}); err == nil {
+		t.Error("expected arrays of optionals to be rejected")
+	}
+}
+
+func TestGoName(t *testing.T) {
+	for in, want := range map[string]string{
+		"add_to_whitelist": "AddToWhitelist",
+		"fio.address":      "FioAddress",
+		"regaddress":       "Regaddress",
+		"1up":              "X1up",
+	} {
+		if got := GoName(in); got != want {
+			t.Errorf("GoName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fioprotocol/fio-go"
+	"github.com/fioprotocol/fio-go/eos"
+	"github.com/fioprotocol/fio-go/eos/abigen"
+)
+
+func main() {
+	var abiFile, contract, url, pkg, out string
+	flag.StringVar(&abiFile, "abi", "", "ABI json file, either a bare ABI or a get_abi response")
+	flag.StringVar(&contract, "contract", "", "contract account, required with -abi. With -url only this contract is generated")
+	flag.StringVar(&url, "url", "", "nodeos API url, generates every contract if -contract is not set")
+	flag.StringVar(&pkg, "package", "", "package name, defaults to the contract name without punctuation")
+	flag.StringVar(&out, "out", ".", "output directory, each contract is written to <out>/<package>/<package>.go")
+	flag.Parse()
+
+	abis := make(map[eos.AccountName]*eos.ABI)
+	source := ""
+	switch {
+	case abiFile != "":
+		if contract == "" {
+			log.Fatalln("-contract is required with -abi")
+		}
+		abi, err := readAbi(abiFile)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		abis[eos.AccountName(contract)] = abi
+		source = filepath.Base(abiFile)
+	case url != "":
+		api := &fio.API{API: eos.New(url)}
+		if contract != "" {
+			resp, err := api.GetABI(eos.AccountName(contract))
+			if err != nil {
+				log.Fatalln("getting abi:", err)
+			}
+			abis[resp.AccountName] = &resp.ABI
+		} else {
+			var err error
+			if abis, err = api.AllABIs(); err != nil {
+				log.Fatalln("getting abis:", err)
+			}
+		}
+		source = url
+	default:
+		flag.Usage()
+		os.Exit(1)
+	}
+	if pkg != "" && len(abis) > 1 {
+		log.Fatalln("-package can only be used when generating a single contract")
+	}
+
+	failed := false
+	for account, abi := range abis {
+		name := pkg
+		if name == "" {
+			name = packageName(string(account))
+		}
+		code, err := abigen.Generate(abi, abigen.Options{Package: name, Contract: account, Source: source})
+		if err != nil {
+			log.Printf("%s: %s\n", account, err)
+			failed = true
+			continue
+		}
+		dir := filepath.Join(out, name)
+		if err = os.MkdirAll(dir, 0755); err != nil {
+			log.Fatalln(err)
+		}
+		file := filepath.Join(dir, name+".go")
+		if err = ioutil.WriteFile(file, code, 0644); err != nil {
+			log.Fatalln(err)
+		}
+		fmt.Println("wrote", file)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// readAbi accepts either a bare ABI or the response from get_abi
+func readAbi(file string) (*eos.ABI, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	resp := &eos.GetABIResp{}
+	if err = json.Unmarshal(b, resp); err == nil && resp.ABI.Version != "" {
+		return &resp.ABI, nil
+	}
+	abi := &eos.ABI{}
+	if err = json.Unmarshal(b, abi); err != nil {
+		return nil, fmt.Errorf("reading %s: %s", file, err)
+	}
+	return abi, nil
+}
+
+func packageName(account string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, strings.ToLower(account))
+}
@@ -117,7 +117,7 @@ func (d *Decoder) Decode(v interface{}) (err error) {
 		rv = reflect.Indirect(newRV)
 	}
 
-	switch realV := v.(type) {
+	switch v.(type) {
 	case *string:
 		s, e := d.ReadString()
 		if e != nil {
@@ -193,6 +193,48 @@ func (d *Decoder) Decode(v interface{}) (err error) {
 		n, err = d.ReadUint64()
 		rv.SetUint(n)
 		return
+	case *Uint64:
+		var n uint64
+		n, err = d.ReadUint64()
+		rv.SetUint(n)
+		return
+	case *int8:
+		var n int8
+		n, err = d.ReadInt8()
+		rv.SetInt(int64(n))
+		return
+	case *Varint32:
+		var n int32
+		n, err = d.ReadVarint32()
+		rv.SetInt(int64(n))
+		return
+	case *float32:
+		var n float32
+		n, err = d.ReadFloat32()
+		rv.SetFloat(float64(n))
+		return
+	case *float64:
+		var n float64
+		n, err = d.ReadFloat64()
+		rv.SetFloat(n)
+		return
+	case *TimePoint:
+		var n TimePoint
+		n, err = d.ReadTimePoint()
+		rv.SetUint(uint64(n))
+		return
+	case *SymbolCode:
+		var n SymbolCode
+		n, err = d.ReadSymbolCode()
+		rv.SetUint(uint64(n))
+		return
+	case *Symbol:
+		var sym *Symbol
+		if sym, err = d.ReadSymbol(); err != nil {
+			return
+		}
+		rv.Set(reflect.ValueOf(*sym))
+		return
 	case *Varuint32:
 		var r uint64
 		r, err = d.ReadUvarint64()
@@ -223,6 +265,16 @@ func (d *Decoder) Decode(v interface{}) (err error) {
 		s, err = d.ReadChecksum256()
 		rv.SetBytes(s)
 		return
+	case *Checksum160:
+		var s Checksum160
+		s, err = d.ReadChecksum160()
+		rv.SetBytes(s)
+		return
+	case *Checksum512:
+		var s Checksum512
+		s, err = d.ReadChecksum512()
+		rv.SetBytes(s)
+		return
 	case *ecc.PublicKey:
 		var p ecc.PublicKey
 		p, err = d.ReadPublicKey()
@@ -290,19 +342,6 @@ func (d *Decoder) Decode(v interface{}) (err error) {
 			return nil
 		}
 
-	case **OptionalProducerSchedule:
-		isPresent, e := d.ReadByte()
-		if e != nil {
-			err = fmt.Errorf("decode: OptionalProducerSchedule isPresent, %s", e)
-			return
-		}
-
-		if isPresent == 0 {
-			decoderLog.Debug("skipping optional OptionalProducerSchedule")
-			*realV = nil
-			return
-		}
-
 	case **Action:
 		err = d.decodeStruct(v, t, rv)
 		if err != nil {
@@ -413,6 +452,13 @@ func (d *Decoder) decodeStruct(v interface{}, t reflect.Type, rv reflect.Value)
 			}
 		}
 
+		if tag == "optional" {
+			if err = d.decodeOptional(typeField, rv.Field(i)); err != nil {
+				return
+			}
+			continue
+		}
+
 		if v := rv.Field(i); v.CanSet() && typeField.Name != "_" {
 			iface := v.Addr().Interface()
 			decoderLog.Debug("field", zap.String("name", typeField.Name))
@@ -424,6 +470,32 @@ func (d *Decoder) decodeStruct(v interface{}, t reflect.Type, rv reflect.Value)
 	return
 }
 
+// decodeOptional reads an `eos:"optional"` pointer field: a presence flag, followed by the value when it is set
+func (d *Decoder) decodeOptional(field reflect.StructField, v reflect.Value) error {
+	if field.Type.Kind() != reflect.Ptr {
+		return fmt.Errorf("decode: optional field %s must be a pointer, not %s", field.Name, field.Type)
+	}
+	isPresent, err := d.ReadByte()
+	if err != nil {
+		return fmt.Errorf("decode: %s isPresent, %s", field.Name, err)
+	}
+	if isPresent == 0 {
+		decoderLog.Debug("skipping optional", zap.String("name", field.Name))
+		if v.CanSet() {
+			v.Set(reflect.Zero(field.Type))
+		}
+		return nil
+	}
+	value := reflect.New(field.Type.Elem())
+	if err = d.Decode(value.Interface()); err != nil {
+		return err
+	}
+	if v.CanSet() {
+		v.Set(value)
+	}
+	return nil
+}
+
 var ErrVarIntBufferSize = errors.New("varint: invalid buffer size")
 
 func (d *Decoder) ReadUvarint64() (uint64, error) {
@@ -646,6 +646,39 @@ func TestDecoder_Decode_struct_tag_BinaryExtension_AllAtStart(t *testing.T) {
 	})
 }
 
+func TestDecoder_Decode_struct_tag_Optional(t *testing.T) {
+	type OptionalTestStruct struct {
+		S   *string        `eos:"optional"`
+		U   *Uint64        `eos:"optional"`
+		Key *ecc.PublicKey `eos:"optional"`
+	}
+
+	str := "abc"
+	u := Uint64(7)
+	key, err := ecc.NewPublicKey("FIO6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV")
+	require.NoError(t, err)
+
+	cnt, err := MarshalBinary(&OptionalTestStruct{S: &str, U: &u, Key: &key})
+	require.NoError(t, err)
+	var s OptionalTestStruct
+	require.NoError(t, UnmarshalBinary(cnt, &s))
+	require.NotNil(t, s.S)
+	require.NotNil(t, s.U)
+	require.NotNil(t, s.Key)
+	assert.Equal(t, "abc", *s.S)
+	assert.Equal(t, Uint64(7), *s.U)
+	assert.Equal(t, key.String(), s.Key.String())
+
+	cnt, err = MarshalBinary(&OptionalTestStruct{S: &str})
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x1, 0x3, 0x61, 0x62, 0x63, 0x0, 0x0}, cnt)
+	s = OptionalTestStruct{U: &u}
+	require.NoError(t, UnmarshalBinary(cnt, &s))
+	assert.Equal(t, "abc", *s.S)
+	assert.Nil(t, s.U)
+	assert.Nil(t, s.Key)
+}
+
 func TestDecoder_readUint16_missing_data(t *testing.T) {
 
 	_, err := NewDecoder([]byte{}).ReadByte()
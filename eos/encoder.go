@@ -216,6 +216,10 @@ func (e *Encoder) Encode(v interface{}) (err error) {
 						}
 
 						if isPresent {
+							if tag == "optional" && v.Kind() == reflect.Ptr {
+								// encode the value rather than the pointer, so built-in types use their own encoding
+								v = v.Elem()
+							}
 							if err = e.Encode(v.Interface()); err != nil {
 								return
 							}
@@ -212,8 +212,9 @@ type GetTableRowsRequest struct {
 }
 
 type GetTableRowsResp struct {
-	More bool            `json:"more"`
-	Rows json.RawMessage `json:"rows"` // defer loading, as it depends on `JSON` being true/false.
+	More    bool            `json:"more"`
+	Rows    json.RawMessage `json:"rows"`               // defer loading, as it depends on `JSON` being true/false.
+	NextKey string          `json:"next_key,omitempty"` // lower bound for the next page, only provided by nodeos v2.0+
 }
 
 func (resp *GetTableRowsResp) JSONToStructs(v interface{}) error {
@@ -3,8 +3,10 @@ package fio
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"github.com/fioprotocol/fio-go/eos"
 	"io/ioutil"
+	"net/http"
 	"sync"
 )
 
@@ -202,6 +204,17 @@ func GetMaxFeeByAction(name string) (fioTokens float64) {
 	return fioTokens
 }
 
+// GetMaxFeeAmount is the same as GetMaxFee, but returns an Amount. Fees are stored as FIO, and are exact when
+// rounded to the nearest SUF.
+func GetMaxFeeAmount(name string) Amount {
+	return floatToAmount(GetMaxFee(name))
+}
+
+// GetMaxFeeAmountByAction is the same as GetMaxFeeByAction, but returns an Amount
+func GetMaxFeeAmountByAction(name string) Amount {
+	return floatToAmount(GetMaxFeeByAction(name))
+}
+
 type GetFeeRequest struct {
 	FioAddress string `json:"fio_address"`
 	EndPoint   string `json:"end_point"`
@@ -228,6 +241,9 @@ func (api *API) GetFee(fioAddress string, endPoint string) (fee uint64, err erro
 	if err != nil {
 		return 0, err
 	}
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("error %d: %s", resp.StatusCode, string(f))
+	}
 	feeResp := &GetFeeResponse{}
 	err = json.Unmarshal(f, feeResp)
 	if err != nil {
@@ -236,6 +252,12 @@ func (api *API) GetFee(fioAddress string, endPoint string) (fee uint64, err erro
 	return feeResp.Fee, nil
 }
 
+// GetFeeAmount is the same as GetFee, but returns an Amount
+func (api *API) GetFeeAmount(fioAddress string, endPoint string) (Amount, error) {
+	fee, err := api.GetFee(fioAddress, endPoint)
+	return Amount(fee), err
+}
+
 // MaxFeesUpdated checks if the fee map has been updated, or if using the default (possibly wrong) values
 func MaxFeesUpdated() bool {
 	return maxFeesUpdated
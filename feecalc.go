@@ -0,0 +1,157 @@
+package fio
+
+import (
+	"errors"
+	"fmt"
+	"github.com/fioprotocol/fio-go/eos"
+	"math"
+	"sort"
+)
+
+// PriceSource provides the current price of 1 FIO in USD, for example from an exchange or oracle
+type PriceSource interface {
+	FioUsd() (float64, error)
+}
+
+// StaticPrice is a PriceSource with a fixed price
+type StaticPrice float64
+
+func (p StaticPrice) FioUsd() (float64, error) {
+	return float64(p), nil
+}
+
+// PriceSourceFunc allows using a function as a PriceSource
+type PriceSourceFunc func() (float64, error)
+
+func (f PriceSourceFunc) FioUsd() (float64, error) {
+	return f()
+}
+
+// FeeCalculator turns USD targets for each fee endpoint into fee ratio and multiplier votes.
+//
+// The on-chain fee is the ratio multiplied by the multiplier. The calculator votes each ratio as the USD target
+// expressed in SUF, and the multiplier as FIO per USD. For example, with FIO at $0.08 and a $2 target for
+// register_fio_address, the ratio is 2_000_000_000 and the multiplier is 12.5, so the fee is 25 FIO. Because the
+// ratios do not depend on the price, usually only the multiplier vote needs to change.
+type FeeCalculator struct {
+	Source    PriceSource
+	Targets   map[string]float64 // USD cost for each fee endpoint, such as FeeRegisterFioAddress
+	Tolerance float64            // relative change in the multiplier that is not worth a new vote
+}
+
+// NewFeeCalculator creates a calculator that ignores multiplier changes under 1%
+func NewFeeCalculator(source PriceSource, targets map[string]float64) *FeeCalculator {
+	return &FeeCalculator{
+		Source:    source,
+		Targets:   targets,
+		Tolerance: 0.01,
+	}
+}
+
+// FeePlan is the result of a FeeCalculator, Actions only includes votes that change something
+type FeePlan struct {
+	Price             float64
+	Multiplier        float64
+	CurrentMultiplier float64 // zero if the producer has not voted
+	MultiplierChanged bool
+	Ratios            []*FeeValue // every ratio, sorted by endpoint
+	Changed           []*FeeValue // ratios that differ from the current vote
+	Actions           []*Action
+}
+
+// Calculate compares the target votes with the producer's current votes, either of which may be nil if the producer
+// has not voted.
+func (c *FeeCalculator) Calculate(actor eos.AccountName, votes *FeeVote2, voter *FeeVoter) (*FeePlan, error) {
+	if c.Source == nil {
+		return nil, errors.New("no price source")
+	}
+	if len(c.Targets) == 0 {
+		return nil, errors.New("no fee targets")
+	}
+	price, err := c.Source.FioUsd()
+	if err != nil {
+		return nil, fmt.Errorf("getting price: %s", err)
+	}
+	if price <= 0 || math.IsNaN(price) || math.IsInf(price, 0) {
+		return nil, fmt.Errorf("invalid price %v", price)
+	}
+
+	current := make(map[string]int64)
+	if votes != nil {
+		for _, v := range votes.FeeVotes {
+			current[v.EndPoint] = v.Value
+		}
+	}
+	plan := &FeePlan{
+		Price:      price,
+		Multiplier: 1 / price,
+		Ratios:     make([]*FeeValue, 0, len(c.Targets)),
+		Changed:    make([]*FeeValue, 0),
+		Actions:    make([]*Action, 0),
+	}
+	maxFeeMutex.RLock()
+	for endpoint, usd := range c.Targets {
+		if _, ok := maxFees[endpoint]; !ok {
+			maxFeeMutex.RUnlock()
+			return nil, fmt.Errorf("unknown fee endpoint %q", endpoint)
+		}
+		if usd <= 0 || math.IsNaN(usd) || math.IsInf(usd, 0) {
+			maxFeeMutex.RUnlock()
+			return nil, fmt.Errorf("invalid target %v for %s", usd, endpoint)
+		}
+		plan.Ratios = append(plan.Ratios, &FeeValue{EndPoint: endpoint, Value: int64(math.Round(usd * 1_000_000_000))})
+	}
+	maxFeeMutex.RUnlock()
+	sort.Slice(plan.Ratios, func(i, j int) bool {
+		return plan.Ratios[i].EndPoint < plan.Ratios[j].EndPoint
+	})
+	for _, r := range plan.Ratios {
+		if v, ok := current[r.EndPoint]; !ok || v != r.Value {
+			plan.Changed = append(plan.Changed, r)
+		}
+	}
+	if len(plan.Changed) > 0 {
+		plan.Actions = append(plan.Actions, NewSetFeeVote(plan.Changed, actor))
+	}
+
+	if voter != nil {
+		plan.CurrentMultiplier = voter.FeeMultiplier
+	}
+	plan.MultiplierChanged = plan.CurrentMultiplier <= 0 ||
+		math.Abs(plan.Multiplier-plan.CurrentMultiplier)/plan.CurrentMultiplier > c.Tolerance
+	if plan.MultiplierChanged {
+		plan.Actions = append(plan.Actions, NewSetFeeMult(plan.Multiplier, actor))
+	}
+	return plan, nil
+}
+
+// GetFeeVotes gets a producer's current fee ratio and multiplier votes, either will be nil if there is no vote
+func (api *API) GetFeeVotes(producer eos.AccountName) (votes *FeeVote2, voter *FeeVoter, err error) {
+	votes = &FeeVote2{}
+	found, err := NewTableQuery(api, "fio.fee", "feevotes2").Index("block_producer_name").Equal(producer).First(votes)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !found {
+		votes = nil
+	}
+	voter = &FeeVoter{}
+	found, err = NewTableQuery(api, "fio.fee", "feevoters").Index("block_producer_name").Equal(producer).First(voter)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !found {
+		voter = nil
+	}
+	return votes, voter, nil
+}
+
+// PlanFeeVotes calculates the fee votes for a producer, comparing against the current on-chain votes. The
+// setfeevote action can be large, so pushing it with CompressionZlib is recommended.
+func (api *API) PlanFeeVotes(calc *FeeCalculator, producer eos.AccountName) (*FeePlan, error) {
+	votes, voter, err := api.GetFeeVotes(producer)
+	if err != nil {
+		return nil, err
+	}
+	return calc.Calculate(producer, votes, voter)
+}
@@ -0,0 +1,82 @@
+package fio
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/fioprotocol/fio-go/eos"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFeeCalculator(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := GetTableRowsOrderRequest{}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if req.LowerBound != "bp1" {
+			_, _ = w.Write([]byte(`{"rows":[]}`))
+			return
+		}
+		switch req.Table {
+		case "feevotes2":
+			_, _ = w.Write([]byte(`{"rows":[{"id":0,"block_producer_name":"bp1","feevotes":[
+				{"end_point":"register_fio_address","value":2000000000,"timestamp":1},
+				{"end_point":"add_pub_address","value":30000000,"timestamp":1}]}]}`))
+		case "feevoters":
+			_, _ = w.Write([]byte(`{"rows":[{"block_producer_name":"bp1","fee_multiplier":12.45,"lastvotetimestamp":1}]}`))
+		}
+	}))
+	defer srv.Close()
+	api := &API{API: eos.New(srv.URL)}
+
+	calc := NewFeeCalculator(StaticPrice(0.08), map[string]float64{
+		FeeRegisterFioAddress: 2,
+		FeeAddPubAddress:      0.03,
+		FeeNewFundsRequest:    0.06,
+	})
+	plan, err := api.PlanFeeVotes(calc, "bp1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Multiplier != 12.5 || plan.CurrentMultiplier != 12.45 || plan.MultiplierChanged {
+		t.Errorf("multiplier within tolerance should not change %+v", plan)
+	}
+	if len(plan.Ratios) != 3 || len(plan.Changed) != 1 || plan.Changed[0].EndPoint != FeeNewFundsRequest || plan.Changed[0].Value != 60_000_000 {
+		t.Errorf("only new_funds_request should change %+v", plan.Changed)
+	}
+	if len(plan.Actions) != 1 || plan.Actions[0].Name != "setfeevote" {
+		t.Fatal("expected a single setfeevote action", plan.Actions)
+	}
+
+	// a price change only needs a new multiplier
+	calc.Source = PriceSourceFunc(func() (float64, error) { return 0.1, nil })
+	calc.Targets = map[string]float64{FeeRegisterFioAddress: 2}
+	plan, err = api.PlanFeeVotes(calc, "bp1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Actions) != 1 || plan.Actions[0].Name != "setfeemult" || plan.Actions[0].Data.(SetFeeMult).Multiplier != 10 {
+		t.Errorf("expected only a multiplier vote %+v", plan.Actions)
+	}
+
+	// a producer without votes gets both
+	plan, err = api.PlanFeeVotes(calc, "bp2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Actions) != 2 || plan.CurrentMultiplier != 0 {
+		t.Errorf("expected ratio and multiplier votes %+v", plan.Actions)
+	}
+
+	for _, c := range []*FeeCalculator{
+		NewFeeCalculator(StaticPrice(0), calc.Targets),
+		NewFeeCalculator(PriceSourceFunc(func() (float64, error) { return 0, errors.New("offline") }), calc.Targets),
+		NewFeeCalculator(StaticPrice(0.1), map[string]float64{"not_a_fee": 1}),
+		NewFeeCalculator(StaticPrice(0.1), map[string]float64{FeeAddPubAddress: -1}),
+		NewFeeCalculator(StaticPrice(0.1), nil),
+	} {
+		if _, err = c.Calculate("bp1", nil, nil); err == nil {
+			t.Errorf("expected error for %+v", c)
+		}
+	}
+}
@@ -0,0 +1,358 @@
+// Package fiotest provides an in-memory fake FIO node for unit testing code built on fio-go without a running nodeos.
+//
+// The Server answers the subset of the chain API used by most clients: get_info, get_table_rows, get_fee,
+// avail_check, get_pub_address, get_fio_names, and push_transaction. Pushed transactions are unpacked and their
+// signatures checked against the keys registered for the authorizing actors before any ActionHandler is called.
+//
+//	srv := fiotest.NewServer()
+//	defer srv.Close()
+//	_, _ = srv.State.AddAccount(account.PubKey)
+//	api, opts, err := fio.NewConnection(account.KeyBag, srv.URL)
+package fiotest
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/fioprotocol/fio-go"
+	"github.com/fioprotocol/fio-go/eos"
+	"github.com/fioprotocol/fio-go/eos/eoserr"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	errUnsatisfiedAuth = eoserr.Error{Name: "unsatisfied_authorization", Code: 3090003}
+	errExpiredTx       = eoserr.Error{Name: "expired_tx_exception", Code: 3040005}
+	errTxDecode        = eoserr.Error{Name: "transaction_decompression_error", Code: 3040004}
+	errAssert          = eoserr.Error{Name: "eosio_assert_message_exception", Code: 3050003}
+	errUnknownAction   = eoserr.Error{Name: "action_validate_exception", Code: 3050004}
+	errNotFound        = eoserr.Error{Name: "unknown_endpoint", Code: 404}
+)
+
+// Server is an httptest.Server that behaves like a FIO API node backed by State.
+type Server struct {
+	*httptest.Server
+	State *State
+
+	pushMux sync.Mutex
+}
+
+// NewServer starts a Server with an empty State, it must be closed when finished.
+func NewServer() *Server {
+	return NewServerWithState(NewState())
+}
+
+// NewServerWithState starts a Server using an existing State.
+func NewServerWithState(state *State) *Server {
+	srv := &Server{State: state}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/chain/get_info", srv.getInfo)
+	mux.HandleFunc("/v1/chain/get_table_rows", srv.getTableRows)
+	mux.HandleFunc("/v1/chain/get_fee", srv.getFee)
+	mux.HandleFunc("/v1/chain/avail_check", srv.availCheck)
+	mux.HandleFunc("/v1/chain/get_pub_address", srv.getPubAddress)
+	mux.HandleFunc("/v1/chain/get_fio_names", srv.getFioNames)
+	mux.HandleFunc("/v1/chain/push_transaction", srv.pushTransaction)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeApiError(w, http.StatusNotFound, "Not Found", errNotFound, "unknown endpoint "+r.URL.Path)
+	})
+	srv.Server = httptest.NewServer(mux)
+	return srv
+}
+
+// fioFieldError mimics the 400 response FIO API endpoints return when given invalid input
+type fioFieldError struct {
+	Type    string     `json:"type"`
+	Message string     `json:"message"`
+	Fields  []fioField `json:"fields"`
+}
+
+type fioField struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	Error string `json:"error"`
+}
+
+type fioMessage struct {
+	Message string `json:"message"`
+}
+
+func writeJson(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeApiError(w http.ResponseWriter, status int, msg string, e eoserr.Error, detail string) {
+	apiErr := eos.NewAPIError(status, msg, e)
+	apiErr.ErrorStruct.What = detail
+	apiErr.ErrorStruct.Details[0].Message = detail
+	writeJson(w, status, apiErr)
+}
+
+func writeFieldError(w http.ResponseWriter, name string, value string, err string) {
+	writeJson(w, http.StatusBadRequest, fioFieldError{
+		Type:    "invalid_input",
+		Message: "An invalid request was sent in, please check the nested errors for details.",
+		Fields:  []fioField{{Name: name, Value: value, Error: err}},
+	})
+}
+
+// decode reads a JSON request body, an empty body is allowed for endpoints such as get_info.
+func decode(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if r.Body == nil {
+		return true
+	}
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil && !errors.Is(err, io.EOF) {
+		writeApiError(w, http.StatusBadRequest, "Invalid Request", eoserr.ErrInvalidArgException, err.Error())
+		return false
+	}
+	return true
+}
+
+func (srv *Server) getInfo(w http.ResponseWriter, r *http.Request) {
+	num, t := srv.State.HeadBlock()
+	writeJson(w, http.StatusOK, &eos.InfoResp{
+		ServerVersion:            "fiotest",
+		ChainID:                  srv.State.ChainID,
+		HeadBlockNum:             num,
+		LastIrreversibleBlockNum: num,
+		LastIrreversibleBlockID:  blockId(num),
+		HeadBlockID:              blockId(num),
+		HeadBlockTime:            eos.JSONTime{Time: t},
+		HeadBlockProducer:        "fiotest",
+		ServerVersionString:      "fiotest",
+	})
+}
+
+func (srv *Server) getTableRows(w http.ResponseWriter, r *http.Request) {
+	req := fio.GetTableRowsOrderRequest{}
+	if !decode(w, r, &req) {
+		return
+	}
+	rows, more, nextKey, err := srv.State.tableRows(req)
+	if err != nil {
+		writeApiError(w, http.StatusInternalServerError, "Internal Service Error", eoserr.ErrInvalidArgException, err.Error())
+		return
+	}
+	j, err := json.Marshal(rows)
+	if err != nil {
+		writeApiError(w, http.StatusInternalServerError, "Internal Service Error", eoserr.ErrInvalidArgException, err.Error())
+		return
+	}
+	writeJson(w, http.StatusOK, &eos.GetTableRowsResp{More: more, NextKey: nextKey, Rows: j})
+}
+
+func (srv *Server) getFee(w http.ResponseWriter, r *http.Request) {
+	req := fio.GetFeeRequest{}
+	if !decode(w, r, &req) {
+		return
+	}
+	fee, err := srv.State.Fee(req.FioAddress, req.EndPoint)
+	if err != nil {
+		writeFieldError(w, "end_point", req.EndPoint, err.Error())
+		return
+	}
+	writeJson(w, http.StatusOK, &fio.GetFeeResponse{Fee: fee})
+}
+
+func (srv *Server) availCheck(w http.ResponseWriter, r *http.Request) {
+	req := fio.AvailCheckReq{}
+	if !decode(w, r, &req) {
+		return
+	}
+	if req.FioName == "" {
+		writeFieldError(w, "fio_name", req.FioName, "Invalid FIO name")
+		return
+	}
+	resp := &fio.AvailCheckResp{}
+	_, isAddress := srv.State.Address(req.FioName)
+	_, isDomain := srv.State.Domain(req.FioName)
+	if isAddress || isDomain {
+		resp.IsRegistered = 1
+	}
+	writeJson(w, http.StatusOK, resp)
+}
+
+type pubAddressRequest struct {
+	FioAddress string `json:"fio_address"`
+	TokenCode  string `json:"token_code"`
+	ChainCode  string `json:"chain_code"`
+}
+
+func (srv *Server) getPubAddress(w http.ResponseWriter, r *http.Request) {
+	req := pubAddressRequest{}
+	if !decode(w, r, &req) {
+		return
+	}
+	if !fio.Address(req.FioAddress).Valid() {
+		writeFieldError(w, "fio_address", req.FioAddress, "Invalid FIO Address")
+		return
+	}
+	name, ok := srv.State.Address(req.FioAddress)
+	if !ok {
+		writeJson(w, http.StatusNotFound, fioMessage{Message: "Public address not found"})
+		return
+	}
+	for _, a := range name.Addresses {
+		if strings.EqualFold(a.ChainCode, req.ChainCode) && strings.EqualFold(a.TokenCode, req.TokenCode) {
+			writeJson(w, http.StatusOK, fio.PubAddress{PublicAddress: a.PublicAddress})
+			return
+		}
+	}
+	// a token code of "*" matches any token on the chain
+	for _, a := range name.Addresses {
+		if strings.EqualFold(a.ChainCode, req.ChainCode) && a.TokenCode == "*" {
+			writeJson(w, http.StatusOK, fio.PubAddress{PublicAddress: a.PublicAddress})
+			return
+		}
+	}
+	writeJson(w, http.StatusNotFound, fioMessage{Message: "Public address not found"})
+}
+
+type fioNamesRequest struct {
+	FioPublicKey string `json:"fio_public_key"`
+}
+
+func (srv *Server) getFioNames(w http.ResponseWriter, r *http.Request) {
+	req := fioNamesRequest{}
+	if !decode(w, r, &req) {
+		return
+	}
+	if _, err := fio.ActorFromPub(req.FioPublicKey); err != nil {
+		writeFieldError(w, "fio_public_key", req.FioPublicKey, "Invalid FIO Public Key")
+		return
+	}
+	names := srv.State.namesFor(req.FioPublicKey)
+	if len(names.FioAddresses) == 0 && len(names.FioDomains) == 0 {
+		writeJson(w, http.StatusNotFound, fioMessage{Message: "No FIO names"})
+		return
+	}
+	writeJson(w, http.StatusOK, names)
+}
+
+// namesFor lists the addresses and domains owned by a public key
+func (s *State) namesFor(pubKey string) fio.FioNames {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+	names := fio.FioNames{
+		FioDomains:   make([]fio.FioName, 0),
+		FioAddresses: make([]fio.FioName, 0),
+	}
+	for _, d := range s.domains {
+		if d.Owner != pubKey {
+			continue
+		}
+		var public int
+		if d.IsPublic {
+			public = 1
+		}
+		names.FioDomains = append(names.FioDomains, fio.FioName{
+			FioDomain:  d.Name,
+			Expiration: d.Expiration.UTC().Format(eos.JSONTimeFormat),
+			IsPublic:   public,
+		})
+	}
+	for _, n := range s.names {
+		if n.Owner != pubKey {
+			continue
+		}
+		names.FioAddresses = append(names.FioAddresses, fio.FioName{
+			FioAddress: n.Name,
+			Expiration: n.Expiration.UTC().Format(eos.JSONTimeFormat),
+		})
+	}
+	sort.Slice(names.FioDomains, func(i, j int) bool {
+		return names.FioDomains[i].FioDomain < names.FioDomains[j].FioDomain
+	})
+	sort.Slice(names.FioAddresses, func(i, j int) bool {
+		return names.FioAddresses[i].FioAddress < names.FioAddresses[j].FioAddress
+	})
+	return names
+}
+
+// pushTransaction verifies the signatures on a transaction and runs the registered ActionHandlers. Pushes are
+// handled one at a time, and the state is restored if any action fails.
+func (srv *Server) pushTransaction(w http.ResponseWriter, r *http.Request) {
+	packed := &eos.PackedTransaction{}
+	if !decode(w, r, packed) {
+		return
+	}
+	signed, err := packed.UnpackBare()
+	if err != nil {
+		writeApiError(w, http.StatusBadRequest, "Invalid packed transaction", errTxDecode, err.Error())
+		return
+	}
+	if signed.Expiration.Before(time.Now().UTC()) {
+		writeApiError(w, http.StatusInternalServerError, "Expired Transaction", errExpiredTx,
+			fmt.Sprintf("expired transaction %s", signed.Expiration.Format(eos.JSONTimeFormat)))
+		return
+	}
+	signers, err := signed.SignedByKeys(srv.State.ChainID)
+	if err != nil {
+		writeApiError(w, http.StatusInternalServerError, "Internal Service Error", errUnsatisfiedAuth, err.Error())
+		return
+	}
+	for _, act := range signed.Actions {
+		for _, auth := range act.Authorization {
+			if !srv.State.authorized(auth.Actor, signers) {
+				writeApiError(w, http.StatusInternalServerError, "Internal Service Error", errUnsatisfiedAuth,
+					fmt.Sprintf("transaction declares authority '{\"actor\":\"%s\",\"permission\":\"%s\"}', but does not have signatures for it.", auth.Actor, auth.Permission))
+				return
+			}
+		}
+	}
+
+	srv.pushMux.Lock()
+	defer srv.pushMux.Unlock()
+	saved := srv.State.snapshot()
+	for _, act := range signed.Actions {
+		h := srv.State.handler(act.Account, act.Name)
+		if h == nil {
+			if srv.State.RejectUnknownActions {
+				srv.State.restore(saved)
+				writeApiError(w, http.StatusInternalServerError, "Internal Service Error", errUnknownAction,
+					fmt.Sprintf("no handler for action %s::%s", act.Account, act.Name))
+				return
+			}
+			continue
+		}
+		if err = h(srv.State, act); err != nil {
+			srv.State.restore(saved)
+			writeApiError(w, http.StatusInternalServerError, "Internal Service Error", errAssert,
+				"assertion failure with message: "+err.Error())
+			return
+		}
+	}
+
+	id, err := packed.ID()
+	if err != nil {
+		srv.State.restore(saved)
+		writeApiError(w, http.StatusBadRequest, "Invalid packed transaction", errTxDecode, err.Error())
+		return
+	}
+	srv.State.record(signed)
+	num := srv.State.ProduceBlock()
+	traces := make([]eos.Trace, len(signed.Actions))
+	for i := range signed.Actions {
+		traces[i] = eos.Trace{Receiver: signed.Actions[i].Account}
+	}
+	writeJson(w, http.StatusAccepted, &eos.PushTransactionFullResp{
+		TransactionID: hex.EncodeToString(id),
+		Processed: eos.TransactionProcessed{
+			Status:       "executed",
+			ID:           id,
+			ActionTraces: traces,
+		},
+		BlockID:  hex.EncodeToString(blockId(num)),
+		BlockNum: num,
+	})
+}
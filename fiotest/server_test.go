@@ -0,0 +1,197 @@
+package fiotest
+
+import (
+	"errors"
+	"github.com/fioprotocol/fio-go"
+	"github.com/fioprotocol/fio-go/eos"
+	"testing"
+	"time"
+)
+
+func addAddress(actor eos.AccountName, address fio.Address, chain string, pubAddress string) *fio.Action {
+	act, _ := fio.NewAddAddress(actor, address, chain, chain, pubAddress)
+	return act
+}
+
+func TestServer(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	alice, err := fio.NewRandomAccount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	bob, err := fio.NewRandomAccount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expires := time.Now().Add(365 * 24 * time.Hour)
+	if err = srv.State.AddDomain("fiotest", alice.PubKey, true, expires); err != nil {
+		t.Fatal(err)
+	}
+	if err = srv.State.AddAddress("alice@fiotest", alice.PubKey, 100, expires); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = srv.State.AddAccount(bob.PubKey); err != nil {
+		t.Fatal(err)
+	}
+	srv.State.SetFee(fio.FeeAddPubAddress, 400_000_000)
+	srv.State.HandleAction("fio.address", "addaddress", func(state *State, act *eos.Action) error {
+		add := fio.AddAddress{}
+		if err := DecodeAction(act, &add); err != nil {
+			return err
+		}
+		name, ok := state.Address(add.FioAddress)
+		if !ok {
+			return errors.New("fio address not registered")
+		}
+		if name.OwnerAccount != add.Actor {
+			return errors.New("not owner of fio address")
+		}
+		for _, a := range add.PublicAddresses {
+			if err := state.SetPublicAddress(add.FioAddress, a.ChainCode, a.TokenCode, a.PublicAddress); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	api, _, err := fio.NewConnection(alice.KeyBag, srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if fee, err := api.GetFee("alice@fiotest", fio.FeeAddPubAddress); err != nil || fee != 0 {
+		t.Error("expected bundled transaction to have no fee", fee, err)
+	}
+	if fee, err := api.GetFee("", fio.FeeAddPubAddress); err != nil || fee != 400_000_000 {
+		t.Error("expected fee", fee, err)
+	}
+	if fio.GetMaxFee(fio.FeeAddPubAddress) != 0.4 {
+		t.Error("fees were not refreshed from the fiofees table")
+	}
+	if avail, err := api.AvailCheck("alice@fiotest"); err != nil || avail {
+		t.Error("address should be registered", err)
+	}
+	if avail, err := api.AvailCheck("nobody@fiotest"); err != nil || !avail {
+		t.Error("address should be available", err)
+	}
+	names, found, err := api.GetFioNames(alice.PubKey)
+	if err != nil || !found || len(names.FioAddresses) != 1 || len(names.FioDomains) != 1 {
+		t.Error("did not get names", names, err)
+	}
+	if _, found, _ = api.GetFioNames(bob.PubKey); found {
+		t.Error("bob should not have any names")
+	}
+	if remaining, err := api.GetBundleRemaining("alice@fiotest"); err != nil || remaining != 100 {
+		t.Error("wrong bundle count", remaining, err)
+	}
+
+	_, err = api.SignPushActions(addAddress(alice.Actor, "alice@fiotest", "BTC", "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, found, err := api.PubAddressLookup("alice@fiotest", "BTC", "BTC")
+	if err != nil || !found || pub.PublicAddress != "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4" {
+		t.Error("public address was not added", pub, err)
+	}
+	if len(srv.State.Transactions()) != 1 {
+		t.Error("transaction was not recorded")
+	}
+
+	// signed by alice, but authorized by bob
+	_, err = api.SignPushActions(addAddress(bob.Actor, "alice@fiotest", "ETH", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"))
+	if err == nil {
+		t.Error("expected unsatisfied authorization")
+	}
+
+	// signed by bob, but the handler rejects the action and the state is not modified
+	bobApi, _, err := fio.NewConnection(bob.KeyBag, srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = bobApi.SignPushActions(addAddress(bob.Actor, "alice@fiotest", "ETH", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"))
+	if err == nil {
+		t.Error("expected handler to reject transaction")
+	}
+	if _, found, _ = api.PubAddressLookup("alice@fiotest", "ETH", "ETH"); found {
+		t.Error("rejected transaction modified state")
+	}
+
+	srv.State.RejectUnknownActions = true
+	if _, err = api.SignPushActions(fio.NewRenewDomain(alice.Actor, "fiotest")); err == nil {
+		t.Error("expected unknown action to be rejected")
+	}
+}
+
+func TestState_TableRows(t *testing.T) {
+	s := NewState()
+	s.SetTableRows("eosio", "eosio", "producers", map[string]string{"owner": "a"}, map[string]string{"owner": "b"})
+	rows, more, _, err := s.tableRows(fio.GetTableRowsOrderRequest{Code: "eosio", Scope: "eosio", Table: "producers", Limit: 1, Reverse: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !more || len(rows) != 1 || rows[0].(map[string]string)["owner"] != "b" {
+		t.Error("limit and reverse not applied", rows)
+	}
+}
+
+func TestServer_TableRowsPaging(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	owner, err := fio.NewRandomAccount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expires := time.Now().Add(365 * 24 * time.Hour)
+	if err = srv.State.AddDomain("fiotest", owner.PubKey, true, expires); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a@fiotest", "b@fiotest", "c@fiotest", "d@fiotest", "e@fiotest"} {
+		if err = srv.State.AddAddress(name, owner.PubKey, 100, expires); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rows, more, nextKey, err := srv.State.tableRows(fio.GetTableRowsOrderRequest{
+		Code: "fio.address", Scope: "fio.address", Table: "fionames", Index: "1", LowerBound: "1", UpperBound: "3", Limit: 2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || !more || nextKey != "3" || rows[0].(nameRow).Id != 1 || rows[1].(nameRow).Id != 2 {
+		t.Error("bounds not applied", rows, more, nextKey)
+	}
+	rows, more, _, err = srv.State.tableRows(fio.GetTableRowsOrderRequest{
+		Code: "fio.address", Scope: "fio.address", Table: "fionames", Index: "1", UpperBound: "3", Reverse: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 4 || more || rows[0].(nameRow).Id != 3 {
+		t.Error("reverse range not applied", rows, more)
+	}
+
+	api, _, err := fio.NewConnection(owner.KeyBag, srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make([]struct {
+		Id   uint64 `json:"id"`
+		Name string `json:"name"`
+	}, 0)
+	if err = fio.NewTableQuery(api, "fio.address", "fionames").Index("id").Limit(2).All(&names); err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 5 || names[0].Name != "a@fiotest" || names[4].Name != "e@fiotest" {
+		t.Error("did not page through all rows", names)
+	}
+	name := struct {
+		Name string `json:"name"`
+	}{}
+	found, err := fio.NewTableQuery(api, "fio.address", "fionames").Index("name").Equal(fio.Address("c@fiotest")).First(&name)
+	if err != nil || !found || name.Name != "c@fiotest" {
+		t.Error("hashed index lookup failed", name, err)
+	}
+}
@@ -0,0 +1,774 @@
+package fiotest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/fioprotocol/fio-go"
+	"github.com/fioprotocol/fio-go/eos"
+	"github.com/fioprotocol/fio-go/eos/ecc"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultChainId is the chain ID reported by a new State, transactions must be signed using this value.
+const DefaultChainId = `f10fe5f7b3b7e0c1ae4e7e0b9e3b02e1eaf5d8bc2e6e5f5d2dbf7f1b3b8d3c11`
+
+// Name is a FIO address held in the fake node's state
+type Name struct {
+	Name         string
+	Owner        string // FIO public key of the owner
+	OwnerAccount eos.AccountName
+	Expiration   time.Time
+	Bundle       uint64
+	Addresses    []fio.TokenPubAddr
+}
+
+// Domain is a FIO domain held in the fake node's state
+type Domain struct {
+	Name         string
+	Owner        string
+	OwnerAccount eos.AccountName
+	IsPublic     bool
+	Expiration   time.Time
+}
+
+// ActionHandler is called for every action in a pushed transaction that matches the contract and action it was
+// registered for. Returning an error rejects the whole transaction, and nothing is recorded.
+// The action's HexData holds the serialized action, use DecodeAction to unpack it.
+type ActionHandler func(state *State, act *eos.Action) error
+
+// TableFunc answers a get_table_rows query, the returned rows are marshalled as JSON.
+type TableFunc func(state *State, req fio.GetTableRowsOrderRequest) (rows []interface{}, err error)
+
+// KeyedRow is implemented by rows that know their key for an index. When every row returned for a table is a
+// KeyedRow, get_table_rows sorts the rows by the requested index, applies lower_bound and upper_bound, and returns
+// next_key when there are more rows, the same as nodeos. TableKey returns a decimal number, a "0x" prefixed hex
+// number (for i128 hashes) or an account name, index is the position as a string ("1" is the primary key), and an
+// empty string means the row does not have the index.
+type KeyedRow interface {
+	TableKey(index string) string
+}
+
+type tableKey struct {
+	code  string
+	scope string
+	table string
+}
+
+// State is the scriptable, in-memory chain state served by Server. It is safe for concurrent use, and
+// handlers may call any State method.
+type State struct {
+	ChainID eos.Checksum256
+
+	mux          sync.RWMutex
+	headBlock    uint32
+	headTime     time.Time
+	accounts     map[eos.AccountName][]ecc.PublicKey
+	pubKeys      map[eos.AccountName]string
+	names        map[string]*Name
+	domains      map[string]*Domain
+	fees         map[string]uint64
+	tables       map[tableKey][]interface{}
+	tableFuncs   map[tableKey]TableFunc
+	handlers     map[string]ActionHandler
+	transactions []*eos.SignedTransaction
+
+	// RejectUnknownActions causes a pushed transaction to fail if an action has no registered handler, by
+	// default these actions are accepted without changing state.
+	RejectUnknownActions bool
+}
+
+// NewState creates an empty State with a starting head block of 1, and tables for fio.address fionames, domains,
+// and accountmap, and fio.fee fiofees backed by the Name, Domain, account, and fee records.
+func NewState() *State {
+	chainId, _ := hex.DecodeString(DefaultChainId)
+	s := &State{
+		ChainID:    chainId,
+		headBlock:  1,
+		headTime:   time.Now().UTC().Truncate(500 * time.Millisecond),
+		accounts:   make(map[eos.AccountName][]ecc.PublicKey),
+		pubKeys:    make(map[eos.AccountName]string),
+		names:      make(map[string]*Name),
+		domains:    make(map[string]*Domain),
+		fees:       make(map[string]uint64),
+		tables:     make(map[tableKey][]interface{}),
+		tableFuncs: make(map[tableKey]TableFunc),
+		handlers:   make(map[string]ActionHandler),
+	}
+	s.HandleTable("fio.address", "fio.address", "fionames", namesTable)
+	s.HandleTable("fio.address", "fio.address", "domains", domainsTable)
+	s.HandleTable("fio.address", "fio.address", "accountmap", accountMapTable)
+	s.HandleTable("fio.fee", "fio.fee", "fiofees", feesTable)
+	return s
+}
+
+// AddAccount creates an account for a FIO public key, returning the derived actor.
+// Transactions authorized by this actor must be signed with the key.
+func (s *State) AddAccount(pubKey string) (eos.AccountName, error) {
+	actor, err := fio.ActorFromPub(pubKey)
+	if err != nil {
+		return "", err
+	}
+	pk, err := ecc.NewPublicKey(pubKey)
+	if err != nil {
+		return "", err
+	}
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if s.accounts[actor] == nil {
+		s.pubKeys[actor] = pubKey
+	}
+	s.accounts[actor] = append(s.accounts[actor], pk)
+	return actor, nil
+}
+
+// AddKey adds an additional key that may sign for an existing actor.
+func (s *State) AddKey(actor eos.AccountName, pubKey string) error {
+	pk, err := ecc.NewPublicKey(pubKey)
+	if err != nil {
+		return err
+	}
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if s.accounts[actor] == nil {
+		return fmt.Errorf("account %s does not exist", actor)
+	}
+	s.accounts[actor] = append(s.accounts[actor], pk)
+	return nil
+}
+
+// PubKey returns the public key used to create an account.
+func (s *State) PubKey(actor eos.AccountName) (pubKey string, ok bool) {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+	pubKey, ok = s.pubKeys[actor]
+	return
+}
+
+// authorized checks that an actor's key is in the list of keys that signed a transaction.
+func (s *State) authorized(actor eos.AccountName, signers []ecc.PublicKey) bool {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+	for _, want := range s.accounts[actor] {
+		for _, have := range signers {
+			if bytes.Equal(want.Content, have.Content) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// AddDomain adds a domain owned by a public key, an account is created for the owner if it does not exist.
+func (s *State) AddDomain(domain string, ownerPubKey string, public bool, expiration time.Time) error {
+	owner, err := s.ensureAccount(ownerPubKey)
+	if err != nil {
+		return err
+	}
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.domains[strings.ToLower(domain)] = &Domain{
+		Name:         strings.ToLower(domain),
+		Owner:        ownerPubKey,
+		OwnerAccount: owner,
+		IsPublic:     public,
+		Expiration:   expiration,
+	}
+	return nil
+}
+
+// AddAddress adds a FIO address owned by a public key with the provided number of bundled transactions. The
+// owner's FIO public key is automatically mapped as the FIO:FIO public address.
+func (s *State) AddAddress(address string, ownerPubKey string, bundle uint64, expiration time.Time) error {
+	if !fio.Address(address).Valid() {
+		return errors.New("invalid fio address")
+	}
+	owner, err := s.ensureAccount(ownerPubKey)
+	if err != nil {
+		return err
+	}
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.names[strings.ToLower(address)] = &Name{
+		Name:         strings.ToLower(address),
+		Owner:        ownerPubKey,
+		OwnerAccount: owner,
+		Expiration:   expiration,
+		Bundle:       bundle,
+		Addresses: []fio.TokenPubAddr{{
+			TokenCode:     "FIO",
+			ChainCode:     "FIO",
+			PublicAddress: ownerPubKey,
+		}},
+	}
+	return nil
+}
+
+func (s *State) ensureAccount(pubKey string) (eos.AccountName, error) {
+	actor, err := fio.ActorFromPub(pubKey)
+	if err != nil {
+		return "", err
+	}
+	if _, ok := s.PubKey(actor); ok {
+		return actor, nil
+	}
+	return s.AddAccount(pubKey)
+}
+
+// Address returns a copy of a FIO address record
+func (s *State) Address(address string) (name Name, ok bool) {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+	n := s.names[strings.ToLower(address)]
+	if n == nil {
+		return Name{}, false
+	}
+	name = *n
+	name.Addresses = append([]fio.TokenPubAddr{}, n.Addresses...)
+	return name, true
+}
+
+// Domain returns a copy of a domain record
+func (s *State) Domain(domain string) (d Domain, ok bool) {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+	dom := s.domains[strings.ToLower(domain)]
+	if dom == nil {
+		return Domain{}, false
+	}
+	return *dom, true
+}
+
+// UpdateAddress calls f with the stored record for a FIO address, allowing handlers to modify it. The state is
+// locked while f runs, so it must not call other State methods.
+func (s *State) UpdateAddress(address string, f func(name *Name) error) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	n := s.names[strings.ToLower(address)]
+	if n == nil {
+		return fmt.Errorf("fio address %s does not exist", address)
+	}
+	return f(n)
+}
+
+// UpdateDomain calls f with the stored record for a domain, allowing handlers to modify it. The state is locked
+// while f runs, so it must not call other State methods.
+func (s *State) UpdateDomain(domain string, f func(d *Domain) error) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	d := s.domains[strings.ToLower(domain)]
+	if d == nil {
+		return fmt.Errorf("domain %s does not exist", domain)
+	}
+	return f(d)
+}
+
+// RemoveAddress deletes a FIO address
+func (s *State) RemoveAddress(address string) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	delete(s.names, strings.ToLower(address))
+}
+
+// SetPublicAddress maps a public address for a chain and token code on a FIO address, replacing any existing
+// entry for the same chain and token.
+func (s *State) SetPublicAddress(address string, chain string, token string, publicAddress string) error {
+	return s.UpdateAddress(address, func(name *Name) error {
+		for i := range name.Addresses {
+			if strings.EqualFold(name.Addresses[i].ChainCode, chain) && strings.EqualFold(name.Addresses[i].TokenCode, token) {
+				name.Addresses[i].PublicAddress = publicAddress
+				return nil
+			}
+		}
+		name.Addresses = append(name.Addresses, fio.TokenPubAddr{
+			TokenCode:     strings.ToUpper(token),
+			ChainCode:     strings.ToUpper(chain),
+			PublicAddress: publicAddress,
+		})
+		return nil
+	})
+}
+
+// SetFee sets the fee in SUFs for an endpoint, this is used by both get_fee and the fiofees table.
+func (s *State) SetFee(endpoint string, suf uint64) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.fees[endpoint] = suf
+}
+
+// Fee returns the fee for an endpoint, taking the remaining bundled transactions for the address into account.
+func (s *State) Fee(address string, endpoint string) (suf uint64, err error) {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+	fee, ok := s.fees[endpoint]
+	if !ok {
+		return 0, fmt.Errorf("invalid end point %s", endpoint)
+	}
+	if bundledEndpoints[endpoint] && address != "" {
+		n := s.names[strings.ToLower(address)]
+		if n == nil {
+			return 0, fmt.Errorf("fio address %s does not exist", address)
+		}
+		if n.Bundle > 0 {
+			return 0, nil
+		}
+	}
+	return fee, nil
+}
+
+// bundledEndpoints are the endpoints that consume a bundled transaction instead of charging a fee.
+var bundledEndpoints = map[string]bool{
+	"add_pub_address":             true,
+	"remove_pub_address":          true,
+	"remove_all_pub_addresses":    true,
+	"new_funds_request":           true,
+	"reject_funds_request":        true,
+	"cancel_funds_request":        true,
+	"record_obt_data":             true,
+	"add_nft":                     true,
+	"remove_nft":                  true,
+	"remove_all_nfts":             true,
+	"transfer_tokens_fio_address": true,
+}
+
+// SetTableRows replaces the rows returned for a table, the rows are marshalled to JSON when queried. Bounds and
+// indexes are ignored for these tables, only limit is honored, unless the rows implement KeyedRow. Use HandleTable if
+// a query needs to be interpreted.
+func (s *State) SetTableRows(code string, scope string, table string, rows ...interface{}) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.tables[tableKey{code, scope, table}] = rows
+}
+
+// HandleTable registers a function used to answer queries for a table, replacing any built-in handler.
+func (s *State) HandleTable(code string, scope string, table string, f TableFunc) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.tableFuncs[tableKey{code, scope, table}] = f
+}
+
+// HandleAction registers a handler for a contract action pushed in a transaction.
+func (s *State) HandleAction(contract eos.AccountName, action eos.ActionName, h ActionHandler) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.handlers[string(contract)+"::"+string(action)] = h
+}
+
+func (s *State) handler(contract eos.AccountName, action eos.ActionName) ActionHandler {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+	return s.handlers[string(contract)+"::"+string(action)]
+}
+
+// Transactions lists the transactions that have been successfully pushed
+func (s *State) Transactions() []*eos.SignedTransaction {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+	return append([]*eos.SignedTransaction{}, s.transactions...)
+}
+
+// HeadBlock returns the current head block number and time
+func (s *State) HeadBlock() (num uint32, t time.Time) {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+	return s.headBlock, s.headTime
+}
+
+// ProduceBlock advances the head block by one, it is called after every successful transaction.
+func (s *State) ProduceBlock() uint32 {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.headBlock += 1
+	s.headTime = s.headTime.Add(500 * time.Millisecond)
+	return s.headBlock
+}
+
+// blockId creates a deterministic block ID, the first four bytes are the block number as used for TaPoS.
+func blockId(num uint32) eos.Checksum256 {
+	id := sha256.Sum256([]byte(fmt.Sprintf("fiotest block %d", num)))
+	binary.BigEndian.PutUint32(id[:4], num)
+	return id[:]
+}
+
+// DecodeAction unpacks the HexData in an action into a struct, for example a *fio.RegAddress
+func DecodeAction(act *eos.Action, v interface{}) error {
+	if len(act.HexData) == 0 {
+		return errors.New("action has no data")
+	}
+	return eos.UnmarshalBinary(act.HexData, v)
+}
+
+type nameRow struct {
+	Id                      uint64             `json:"id"`
+	Name                    string             `json:"name"`
+	NameHash                string             `json:"namehash"`
+	Domain                  string             `json:"domain"`
+	DomainHash              string             `json:"domainhash"`
+	Expiration              int64              `json:"expiration"`
+	OwnerAccount            eos.AccountName    `json:"owner_account"`
+	Addresses               []fio.TokenPubAddr `json:"addresses"`
+	BundleEligibleCountdown uint64             `json:"bundleeligiblecountdown"`
+}
+
+type domainRow struct {
+	Id         uint64          `json:"id"`
+	Name       string          `json:"name"`
+	DomainHash string          `json:"domainhash"`
+	Account    eos.AccountName `json:"account"`
+	IsPublic   uint8           `json:"is_public"`
+	Expiration int64           `json:"expiration"`
+}
+
+type accountMapRow struct {
+	Account   eos.AccountName `json:"account"`
+	Clientkey string          `json:"clientkey"`
+}
+
+type feeRow struct {
+	FeeId        uint64 `json:"fee_id"`
+	EndPoint     string `json:"end_point"`
+	EndPointHash string `json:"end_point_hash"`
+	Type         uint64 `json:"type"`
+	SufAmount    uint64 `json:"suf_amount"`
+	VotesPending uint8  `json:"votes_pending"`
+}
+
+// TableKey implements KeyedRow: 1 is the id, 5 the name hash
+func (r nameRow) TableKey(index string) string {
+	switch index {
+	case "", "1":
+		return strconv.FormatUint(r.Id, 10)
+	case "5":
+		return r.NameHash
+	}
+	return ""
+}
+
+// TableKey implements KeyedRow: 1 is the id, 3 the expiration, and 4 the name hash
+func (r domainRow) TableKey(index string) string {
+	switch index {
+	case "", "1":
+		return strconv.FormatUint(r.Id, 10)
+	case "3":
+		return strconv.FormatInt(r.Expiration, 10)
+	case "4":
+		return r.DomainHash
+	}
+	return ""
+}
+
+// TableKey implements KeyedRow, the primary key is the account
+func (r accountMapRow) TableKey(index string) string {
+	if index == "" || index == "1" {
+		n, err := eos.StringToName(string(r.Account))
+		if err != nil {
+			return ""
+		}
+		return strconv.FormatUint(n, 10)
+	}
+	return ""
+}
+
+// TableKey implements KeyedRow, the primary key is the fee id
+func (r feeRow) TableKey(index string) string {
+	if index == "" || index == "1" {
+		return strconv.FormatUint(r.FeeId, 10)
+	}
+	return ""
+}
+
+// tableKeyValue converts a key or bound to a number for comparison, see KeyedRow
+func tableKeyValue(key string) (*big.Int, bool) {
+	if strings.HasPrefix(key, "0x") || strings.HasPrefix(key, "0X") {
+		return new(big.Int).SetString(key[2:], 16)
+	}
+	if v, ok := new(big.Int).SetString(key, 10); ok {
+		return v, true
+	}
+	n, err := eos.StringToName(key)
+	if err != nil {
+		return nil, false
+	}
+	return new(big.Int).SetUint64(n), true
+}
+
+func sortedKeys(m interface{}) []string {
+	keys := make([]string, 0)
+	switch v := m.(type) {
+	case map[string]*Name:
+		for k := range v {
+			keys = append(keys, k)
+		}
+	case map[string]*Domain:
+		for k := range v {
+			keys = append(keys, k)
+		}
+	case map[string]uint64:
+		for k := range v {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func namesTable(s *State, req fio.GetTableRowsOrderRequest) ([]interface{}, error) {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+	rows := make([]interface{}, 0)
+	for i, k := range sortedKeys(s.names) {
+		n := s.names[k]
+		parts := strings.Split(n.Name, "@")
+		rows = append(rows, nameRow{
+			Id:                      uint64(i),
+			Name:                    n.Name,
+			NameHash:                fio.AddressHash(n.Name),
+			Domain:                  parts[len(parts)-1],
+			DomainHash:              fio.DomainNameHash(parts[len(parts)-1]),
+			Expiration:              n.Expiration.Unix(),
+			OwnerAccount:            n.OwnerAccount,
+			Addresses:               n.Addresses,
+			BundleEligibleCountdown: n.Bundle,
+		})
+	}
+	return rows, nil
+}
+
+func domainsTable(s *State, req fio.GetTableRowsOrderRequest) ([]interface{}, error) {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+	rows := make([]interface{}, 0)
+	for i, k := range sortedKeys(s.domains) {
+		d := s.domains[k]
+		var public uint8
+		if d.IsPublic {
+			public = 1
+		}
+		rows = append(rows, domainRow{
+			Id:         uint64(i),
+			Name:       d.Name,
+			DomainHash: fio.DomainNameHash(d.Name),
+			Account:    d.OwnerAccount,
+			IsPublic:   public,
+			Expiration: d.Expiration.Unix(),
+		})
+	}
+	return rows, nil
+}
+
+func accountMapTable(s *State, req fio.GetTableRowsOrderRequest) ([]interface{}, error) {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+	actors := make([]string, 0)
+	for actor := range s.pubKeys {
+		actors = append(actors, string(actor))
+	}
+	sort.Strings(actors)
+	rows := make([]interface{}, 0)
+	for _, actor := range actors {
+		rows = append(rows, accountMapRow{
+			Account:   eos.AccountName(actor),
+			Clientkey: s.pubKeys[eos.AccountName(actor)],
+		})
+	}
+	return rows, nil
+}
+
+func feesTable(s *State, req fio.GetTableRowsOrderRequest) ([]interface{}, error) {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+	rows := make([]interface{}, 0)
+	for i, endpoint := range sortedKeys(s.fees) {
+		var feeType uint64
+		if bundledEndpoints[endpoint] {
+			feeType = 1
+		}
+		rows = append(rows, feeRow{
+			FeeId:        uint64(i),
+			EndPoint:     endpoint,
+			EndPointHash: fio.I128Hash(endpoint),
+			Type:         feeType,
+			SufAmount:    s.fees[endpoint],
+		})
+	}
+	return rows, nil
+}
+
+// tableRows answers a get_table_rows request, using a TableFunc if registered, otherwise the rows set with SetTableRows.
+func (s *State) tableRows(req fio.GetTableRowsOrderRequest) (rows []interface{}, more bool, nextKey string, err error) {
+	key := tableKey{req.Code, req.Scope, req.Table}
+	s.mux.RLock()
+	f := s.tableFuncs[key]
+	static := s.tables[key]
+	s.mux.RUnlock()
+	if f != nil {
+		rows, err = f(s, req)
+		if err != nil {
+			return nil, false, "", err
+		}
+	} else {
+		rows = append([]interface{}{}, static...)
+	}
+	if keyed, ok := keyRows(rows, req.Index); ok {
+		return keyed.page(req)
+	}
+	if req.Reverse {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+	}
+	limit := int(req.Limit)
+	if limit == 0 {
+		limit = 10
+	}
+	if len(rows) > limit {
+		return rows[:limit], true, "", nil
+	}
+	return rows, false, "", nil
+}
+
+// keyedRows holds rows with their keys for an index
+type keyedRows struct {
+	rows []interface{}
+	keys []string
+	vals []*big.Int
+}
+
+func (k keyedRows) Len() int           { return len(k.rows) }
+func (k keyedRows) Less(i, j int) bool { return k.vals[i].Cmp(k.vals[j]) < 0 }
+func (k keyedRows) Swap(i, j int) {
+	k.rows[i], k.rows[j] = k.rows[j], k.rows[i]
+	k.keys[i], k.keys[j] = k.keys[j], k.keys[i]
+	k.vals[i], k.vals[j] = k.vals[j], k.vals[i]
+}
+
+// keyRows gets the key of each row for an index, and reports false if any row is not a KeyedRow with that index
+func keyRows(rows []interface{}, index string) (keyedRows, bool) {
+	k := keyedRows{
+		rows: rows,
+		keys: make([]string, len(rows)),
+		vals: make([]*big.Int, len(rows)),
+	}
+	for i := range rows {
+		kr, ok := rows[i].(KeyedRow)
+		if !ok {
+			return k, false
+		}
+		k.keys[i] = kr.TableKey(index)
+		if k.vals[i], ok = tableKeyValue(k.keys[i]); !ok {
+			return k, false
+		}
+	}
+	return k, len(rows) > 0
+}
+
+// page sorts the rows, applies the bounds and limit, and returns the key of the first row left out as next_key
+func (k keyedRows) page(req fio.GetTableRowsOrderRequest) (rows []interface{}, more bool, nextKey string, err error) {
+	sort.Stable(k)
+	var lower, upper *big.Int
+	var ok bool
+	if req.LowerBound != "" {
+		if lower, ok = tableKeyValue(req.LowerBound); !ok {
+			return nil, false, "", fmt.Errorf("invalid lower bound %q", req.LowerBound)
+		}
+	}
+	if req.UpperBound != "" {
+		if upper, ok = tableKeyValue(req.UpperBound); !ok {
+			return nil, false, "", fmt.Errorf("invalid upper bound %q", req.UpperBound)
+		}
+	}
+	matched := make([]int, 0, len(k.rows))
+	for i := range k.rows {
+		if (lower == nil || k.vals[i].Cmp(lower) >= 0) && (upper == nil || k.vals[i].Cmp(upper) <= 0) {
+			matched = append(matched, i)
+		}
+	}
+	if req.Reverse {
+		for i, j := 0, len(matched)-1; i < j; i, j = i+1, j-1 {
+			matched[i], matched[j] = matched[j], matched[i]
+		}
+	}
+	limit := int(req.Limit)
+	if limit == 0 {
+		limit = 10
+	}
+	if len(matched) > limit {
+		nextKey = k.keys[matched[limit]]
+		matched, more = matched[:limit], true
+	}
+	rows = make([]interface{}, len(matched))
+	for i, m := range matched {
+		rows[i] = k.rows[m]
+	}
+	return rows, more, nextKey, nil
+}
+
+func (s *State) record(tx *eos.SignedTransaction) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.transactions = append(s.transactions, tx)
+}
+
+// stateSnapshot holds copies of the mutable records so a failed transaction can be rolled back.
+type stateSnapshot struct {
+	accounts map[eos.AccountName][]ecc.PublicKey
+	pubKeys  map[eos.AccountName]string
+	names    map[string]*Name
+	domains  map[string]*Domain
+	fees     map[string]uint64
+	tables   map[tableKey][]interface{}
+}
+
+func (s *State) snapshot() *stateSnapshot {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+	snap := &stateSnapshot{
+		accounts: make(map[eos.AccountName][]ecc.PublicKey),
+		pubKeys:  make(map[eos.AccountName]string),
+		names:    make(map[string]*Name),
+		domains:  make(map[string]*Domain),
+		fees:     make(map[string]uint64),
+		tables:   make(map[tableKey][]interface{}),
+	}
+	for k, v := range s.accounts {
+		snap.accounts[k] = append([]ecc.PublicKey{}, v...)
+	}
+	for k, v := range s.pubKeys {
+		snap.pubKeys[k] = v
+	}
+	for k, v := range s.names {
+		n := *v
+		n.Addresses = append([]fio.TokenPubAddr{}, v.Addresses...)
+		snap.names[k] = &n
+	}
+	for k, v := range s.domains {
+		d := *v
+		snap.domains[k] = &d
+	}
+	for k, v := range s.fees {
+		snap.fees[k] = v
+	}
+	for k, v := range s.tables {
+		snap.tables[k] = append([]interface{}{}, v...)
+	}
+	return snap
+}
+
+func (s *State) restore(snap *stateSnapshot) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.accounts = snap.accounts
+	s.pubKeys = snap.pubKeys
+	s.names = snap.names
+	s.domains = snap.domains
+	s.fees = snap.fees
+	s.tables = snap.tables
+}
@@ -0,0 +1,188 @@
+package fio
+
+import (
+	"errors"
+	"github.com/fioprotocol/fio-go/eos"
+	"sort"
+	"sync"
+)
+
+// HistoryBackend provides access to historical actions and transactions. The v1 history plugin is used by default,
+// but it is deprecated and rarely available on public nodes, so alternatives can be supplied with API.SetHistory.
+//
+// Implementations provided are V1History (nodeos history plugin), HyperionHistory (Hyperion v2 API), and
+// IndexedHistory (an in-memory store populated by the caller.)
+type HistoryBackend interface {
+	// GetActions returns action traces for an account, pos and offset follow the semantics of the v1 get_actions
+	// endpoint: a pos of -1 is the most recent action, and a negative offset pages backwards.
+	GetActions(account eos.AccountName, pos int64, offset int64) (*eos.ActionsResp, error)
+
+	// GetTransaction returns a transaction and its action traces
+	GetTransaction(id eos.Checksum256) (*eos.TransactionResp, error)
+
+	// GetBlockTxids lists the IDs of all transactions in a block
+	GetBlockTxids(blockNum uint32) (*BlockTxidsResp, error)
+}
+
+// SetHistory overrides the HistoryBackend used by the history helpers such as GetActionsUniq and GetMaxActions.
+func (api *API) SetHistory(history HistoryBackend) {
+	api.history = history
+}
+
+// History returns the HistoryBackend in use, if none has been set with SetHistory the node's v1 history plugin is used.
+func (api *API) History() HistoryBackend {
+	if api.history == nil {
+		return NewV1History(api)
+	}
+	return api.history
+}
+
+// BlockTxidsResp contains a list of transactions in a block.
+type BlockTxidsResp struct {
+	Ids                   []eos.Checksum256 `json:"ids"`
+	LastIrreversibleBlock uint32            `json:"last_irreversible_block"`
+}
+
+// IndexedHistory is an in-memory HistoryBackend. It does not fetch anything on its own, transactions are added
+// using AddTransaction, for example from a block stream or a local indexer, and are indexed by account, block and id.
+// It is safe for concurrent use.
+type IndexedHistory struct {
+	mux       sync.RWMutex
+	txs       map[string]*eos.TransactionResp
+	blocks    map[uint32][]eos.Checksum256
+	accounts  map[eos.AccountName][]*eos.ActionResp
+	seen      map[eos.AccountName]map[eos.Uint64]bool
+	lastBlock uint32
+}
+
+// NewIndexedHistory creates an empty IndexedHistory
+func NewIndexedHistory() *IndexedHistory {
+	return &IndexedHistory{
+		txs:      make(map[string]*eos.TransactionResp),
+		blocks:   make(map[uint32][]eos.Checksum256),
+		accounts: make(map[eos.AccountName][]*eos.ActionResp),
+		seen:     make(map[eos.AccountName]map[eos.Uint64]bool),
+	}
+}
+
+// AddTransaction indexes a transaction, each action trace (including inline traces) is added to the history of the
+// receiving account and the authorizing actors. Adding the same transaction more than once has no effect.
+func (ih *IndexedHistory) AddTransaction(tx *eos.TransactionResp) error {
+	if tx == nil || len(tx.ID) == 0 {
+		return errors.New("transaction must have an id")
+	}
+	ih.mux.Lock()
+	defer ih.mux.Unlock()
+	if ih.txs[tx.ID.String()] != nil {
+		return nil
+	}
+	ih.txs[tx.ID.String()] = tx
+	ih.blocks[tx.BlockNum] = append(ih.blocks[tx.BlockNum], tx.ID)
+	if tx.BlockNum > ih.lastBlock {
+		ih.lastBlock = tx.BlockNum
+	}
+	var index func(trace *eos.ActionTrace)
+	index = func(trace *eos.ActionTrace) {
+		if trace == nil {
+			return
+		}
+		accounts := []eos.AccountName{trace.Receipt.Receiver}
+		if trace.Action != nil {
+			for _, auth := range trace.Action.Authorization {
+				accounts = append(accounts, auth.Actor)
+			}
+		}
+		for _, account := range accounts {
+			ih.addAction(account, tx, trace)
+		}
+		for _, inline := range trace.InlineTraces {
+			index(inline)
+		}
+	}
+	for i := range tx.Traces {
+		index(&tx.Traces[i])
+	}
+	return nil
+}
+
+// addAction appends a trace to an account's history, keeping it ordered by global sequence. Caller must hold the lock.
+func (ih *IndexedHistory) addAction(account eos.AccountName, tx *eos.TransactionResp, trace *eos.ActionTrace) {
+	if account == "" {
+		return
+	}
+	if ih.seen[account] == nil {
+		ih.seen[account] = make(map[eos.Uint64]bool)
+	}
+	if ih.seen[account][trace.Receipt.GlobalSequence] {
+		return
+	}
+	ih.seen[account][trace.Receipt.GlobalSequence] = true
+	acts := append(ih.accounts[account], &eos.ActionResp{
+		GlobalSeq: eos.JSONInt64(trace.Receipt.GlobalSequence),
+		BlockNum:  tx.BlockNum,
+		BlockTime: tx.BlockTime,
+		Trace:     *trace,
+	})
+	sort.Slice(acts, func(i, j int) bool {
+		return acts[i].GlobalSeq < acts[j].GlobalSeq
+	})
+	for i := range acts {
+		acts[i].AccountSeq = eos.JSONInt64(i)
+	}
+	ih.accounts[account] = acts
+}
+
+// GetActions returns the indexed actions for an account using the same pos/offset rules as the v1 history plugin.
+func (ih *IndexedHistory) GetActions(account eos.AccountName, pos int64, offset int64) (*eos.ActionsResp, error) {
+	ih.mux.RLock()
+	defer ih.mux.RUnlock()
+	resp := &eos.ActionsResp{
+		Actions:               make([]eos.ActionResp, 0),
+		LastIrreversibleBlock: ih.lastBlock,
+	}
+	acts := ih.accounts[account]
+	if len(acts) == 0 {
+		return resp, nil
+	}
+	last := int64(len(acts) - 1)
+	if pos < 0 || pos > last {
+		pos = last
+	}
+	start, end := pos, pos+offset
+	if offset < 0 {
+		start, end = pos+offset, pos
+	}
+	if start < 0 {
+		start = 0
+	}
+	if end > last {
+		end = last
+	}
+	for i := start; i <= end; i++ {
+		resp.Actions = append(resp.Actions, *acts[i])
+	}
+	return resp, nil
+}
+
+// GetTransaction returns a previously indexed transaction
+func (ih *IndexedHistory) GetTransaction(id eos.Checksum256) (*eos.TransactionResp, error) {
+	ih.mux.RLock()
+	defer ih.mux.RUnlock()
+	tx := ih.txs[id.String()]
+	if tx == nil {
+		return nil, errors.New("transaction not found in index")
+	}
+	return tx, nil
+}
+
+// GetBlockTxids lists the indexed transactions for a block
+func (ih *IndexedHistory) GetBlockTxids(blockNum uint32) (*BlockTxidsResp, error) {
+	ih.mux.RLock()
+	defer ih.mux.RUnlock()
+	ids := make([]eos.Checksum256, len(ih.blocks[blockNum]))
+	copy(ids, ih.blocks[blockNum])
+	return &BlockTxidsResp{
+		Ids:                   ids,
+		LastIrreversibleBlock: ih.lastBlock,
+	}, nil
+}
@@ -0,0 +1,197 @@
+package fio
+
+import (
+	"encoding/hex"
+	"fmt"
+	"github.com/fioprotocol/fio-go/eos"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func testTxid(i int) eos.Checksum256 {
+	b, _ := hex.DecodeString(fmt.Sprintf("%064x", i))
+	return b
+}
+
+func testTrace(receiver eos.AccountName, actor eos.AccountName, seq uint64) eos.ActionTrace {
+	return eos.ActionTrace{
+		Receipt: eos.ActionTraceReceipt{
+			Receiver:       receiver,
+			ActionDigest:   fmt.Sprintf("digest%d", seq),
+			GlobalSequence: eos.Uint64(seq),
+		},
+		Action: &eos.Action{
+			Account:       "fio.token",
+			Name:          "trnsfiopubky",
+			Authorization: []eos.PermissionLevel{{Actor: actor, Permission: "active"}},
+		},
+	}
+}
+
+func TestIndexedHistory(t *testing.T) {
+	ih := NewIndexedHistory()
+	for i := 1; i <= 5; i++ {
+		err := ih.AddTransaction(&eos.TransactionResp{
+			ID:       testTxid(i),
+			BlockNum: uint32(100 + i/2),
+			Traces:   []eos.ActionTrace{testTrace("fio.token", "alice", uint64(i))},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	// duplicates are ignored
+	if err := ih.AddTransaction(&eos.TransactionResp{ID: testTxid(1), Traces: []eos.ActionTrace{testTrace("fio.token", "alice", 1)}}); err != nil {
+		t.Error(err)
+	}
+
+	api := &API{API: eos.New("http://127.0.0.1:1")}
+	api.SetHistory(ih)
+	if !api.HasHistory() {
+		t.Error("expected history to be available")
+	}
+	high, err := api.GetMaxActions("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if high != 4 {
+		t.Errorf("expected highest sequence 4, got %d", high)
+	}
+	acts, err := ih.GetActions("alice", 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(acts.Actions) != 3 || acts.Actions[0].AccountSeq != 1 || acts.Actions[2].AccountSeq != 3 {
+		t.Errorf("wrong page of actions: %+v", acts.Actions)
+	}
+	acts, _ = ih.GetActions("alice", -1, -1)
+	if len(acts.Actions) != 2 || acts.Actions[1].AccountSeq != 4 {
+		t.Error("expected last two actions")
+	}
+	traces, err := api.GetActionsUniq("alice", 100, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(traces) != 5 {
+		t.Errorf("expected 5 unique traces, got %d", len(traces))
+	}
+	blocks, err := api.HistGetBlockTxids(101)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blocks.Ids) != 2 || blocks.LastIrreversibleBlock != 102 {
+		t.Errorf("unexpected block txids %+v", blocks)
+	}
+	tx, err := api.GetTransaction(testTxid(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tx.BlockNum != 101 {
+		t.Error("got wrong transaction")
+	}
+	if _, err = api.GetTransaction(testTxid(9)); err == nil {
+		t.Error("expected error for missing transaction")
+	}
+}
+
+func TestHyperionHistory(t *testing.T) {
+	const action = `{"@timestamp":"2021-03-01T12:00:00.500","block_num":%d,"trx_id":"%s","global_sequence":%d,
+		"act":{"account":"fio.token","name":"trnsfiopubky","authorization":[{"actor":"alice","permission":"active"}],"data":{"amount":1}},
+		"receipts":[{"receiver":"fio.token","global_sequence":"%d","recv_sequence":"1","auth_sequence":[{"account":"alice","sequence":"1"}]}]}`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		switch r.URL.Path {
+		case "/v2/history/get_actions":
+			if q.Get("block_num") == "10" {
+				_, _ = fmt.Fprintf(w, `{"lib":20,"actions":[`+action+`,`+action+`]}`, 10, testTxid(1), 7, 7, 10, testTxid(1), 8, 8)
+				return
+			}
+			if q.Get("block_num") == "12" {
+				// a full first page, the second transaction is only on the next page
+				id := testTxid(3)
+				if q.Get("skip") != "0" {
+					id = testTxid(4)
+				}
+				acts := make([]string, hyperionBlockLimit)
+				for i := range acts {
+					acts[i] = fmt.Sprintf(action, 12, id, i, i)
+				}
+				if q.Get("skip") != "0" {
+					acts = acts[:1]
+				}
+				_, _ = fmt.Fprintf(w, `{"lib":20,"actions":[%s]}`, strings.Join(acts, ","))
+				return
+			}
+			if q.Get("account") != "alice" || q.Get("sort") != "desc" || q.Get("limit") != "2" || q.Get("track") != "true" {
+				t.Errorf("unexpected query %s", r.URL.RawQuery)
+			}
+			_, _ = fmt.Fprintf(w, `{"lib":20,"total":{"value":9},"actions":[`+action+`,`+action+`]}`, 11, testTxid(2), 9, 9, 10, testTxid(1), 8, 8)
+		case "/v2/history/get_transaction":
+			_, _ = fmt.Fprintf(w, `{"executed":true,"trx_id":"%s","lib":20,"actions":[`+action+`]}`, testTxid(1), 10, testTxid(1), 7, 7)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	hh := NewHyperionHistory(srv.URL)
+	acts, err := hh.GetActions("alice", -1, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(acts.Actions) != 2 || acts.Actions[0].AccountSeq != 7 || acts.Actions[1].AccountSeq != 8 {
+		t.Errorf("actions should be ascending with sequence numbers: %+v", acts.Actions)
+	}
+	if acts.Actions[1].Trace.Receipt.ActionDigest != "9" || acts.Actions[1].BlockTime.Second() != 0 || acts.Actions[1].BlockTime.Hour() != 12 {
+		t.Error("did not convert trace")
+	}
+	tx, err := hh.GetTransaction(testTxid(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tx.BlockNum != 10 || len(tx.Traces) != 1 || tx.Traces[0].Action.Name != "trnsfiopubky" {
+		t.Errorf("unexpected transaction %+v", tx)
+	}
+	ids, err := hh.GetBlockTxids(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids.Ids) != 1 {
+		t.Error("expected transaction ids to be de-duplicated")
+	}
+	if ids, err = hh.GetBlockTxids(12); err != nil || len(ids.Ids) != 2 || ids.Ids[1].String() != testTxid(4).String() {
+		t.Error("expected all pages of the block to be read", ids, err)
+	}
+}
+
+func TestV1History(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/history/get_actions":
+			_, _ = fmt.Fprint(w, `{"actions":[{"global_action_seq":5,"account_action_seq":41,"block_num":3,"block_time":"2021-03-01T12:00:00","action_trace":{"receipt":{"receiver":"alice","act_digest":"abc","global_sequence":5}}}],"last_irreversible_block":3}`)
+		case "/v1/history/get_block_txids":
+			_, _ = fmt.Fprintf(w, `{"ids":["%s"],"last_irreversible_block":3}`, testTxid(1))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	api := &API{API: eos.New(srv.URL)}
+	high, err := api.GetMaxActions("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if high != 41 {
+		t.Errorf("expected 41 got %d", high)
+	}
+	ids, err := api.HistGetBlockTxids(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids.Ids) != 1 || ids.Ids[0].String() != testTxid(1).String() {
+		t.Error("did not get block txids")
+	}
+}
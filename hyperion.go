@@ -0,0 +1,255 @@
+package fio
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/fioprotocol/fio-go/eos"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HyperionHistory is a HistoryBackend for the Hyperion (https://github.com/eosrio/hyperion-history-api) v2 API.
+// Results are converted to the same structures returned by the v1 history plugin.
+type HyperionHistory struct {
+	BaseURL    string
+	HttpClient *http.Client
+}
+
+// NewHyperionHistory creates a HyperionHistory for the provided URL, for example "https://fio.eosrio.io"
+func NewHyperionHistory(baseUrl string) *HyperionHistory {
+	return &HyperionHistory{
+		BaseURL:    strings.TrimRight(baseUrl, "/"),
+		HttpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+type hyperionReceipt struct {
+	Receiver       eos.AccountName `json:"receiver"`
+	GlobalSequence eos.Uint64      `json:"global_sequence"`
+	RecvSequence   eos.Uint64      `json:"recv_sequence"`
+	AuthSequence   []struct {
+		Account  eos.AccountName `json:"account"`
+		Sequence eos.Uint64      `json:"sequence"`
+	} `json:"auth_sequence"`
+}
+
+type hyperionAction struct {
+	Timestamp      string            `json:"@timestamp"`
+	BlockNum       uint32            `json:"block_num"`
+	TrxId          eos.Checksum256   `json:"trx_id"`
+	Act            *eos.Action       `json:"act"`
+	Receipts       []hyperionReceipt `json:"receipts"`
+	GlobalSequence eos.Uint64        `json:"global_sequence"`
+	ActDigest      string            `json:"act_digest"`
+	CpuUsageUs     int               `json:"cpu_usage_us"`
+}
+
+type hyperionTotal struct {
+	Value int64 `json:"value"`
+}
+
+type hyperionActionsResp struct {
+	Lib     uint32           `json:"lib"`
+	Total   hyperionTotal    `json:"total"`
+	Actions []hyperionAction `json:"actions"`
+}
+
+type hyperionTransactionResp struct {
+	Executed bool             `json:"executed"`
+	TrxId    eos.Checksum256  `json:"trx_id"`
+	Lib      uint32           `json:"lib"`
+	Actions  []hyperionAction `json:"actions"`
+}
+
+// trace converts a hyperion action into an eos.ActionTrace, Hyperion combines the receipts for notifications into a
+// single action, so only the first receipt is used.
+func (ha hyperionAction) trace() eos.ActionTrace {
+	trace := eos.ActionTrace{
+		Action:        ha.Act,
+		TransactionID: ha.TrxId,
+		CPUUsage:      ha.CpuUsageUs,
+	}
+	trace.Receipt.GlobalSequence = ha.GlobalSequence
+	if len(ha.Receipts) > 0 {
+		r := ha.Receipts[0]
+		trace.Receipt.Receiver = r.Receiver
+		trace.Receipt.GlobalSequence = r.GlobalSequence
+		trace.Receipt.ReceiveSequence = r.RecvSequence
+		for _, auth := range r.AuthSequence {
+			trace.Receipt.AuthSequence = append(trace.Receipt.AuthSequence, eos.TransactionTraceAuthSequence{
+				Account:  auth.Account,
+				Sequence: auth.Sequence,
+			})
+		}
+	}
+	// act_digest is used for de-duplication by GetActionsUniq, fallback to global sequence if not provided.
+	trace.Receipt.ActionDigest = ha.ActDigest
+	if trace.Receipt.ActionDigest == "" {
+		trace.Receipt.ActionDigest = strconv.FormatUint(uint64(trace.Receipt.GlobalSequence), 10)
+	}
+	return trace
+}
+
+// blockTime parses the timestamp, which includes milliseconds in Hyperion's responses.
+func (ha hyperionAction) blockTime() eos.JSONTime {
+	for _, layout := range []string{"2006-01-02T15:04:05.000", eos.JSONTimeFormat, time.RFC3339} {
+		if t, err := time.Parse(layout, ha.Timestamp); err == nil {
+			return eos.JSONTime{Time: t}
+		}
+	}
+	return eos.JSONTime{}
+}
+
+func (hh *HyperionHistory) get(endpoint string, query url.Values, out interface{}) error {
+	resp, err := hh.HttpClient.Get(hh.BaseURL + endpoint + "?" + query.Encode())
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: status code=%d, body=%s", endpoint, resp.StatusCode, string(body))
+	}
+	if len(body) == 0 {
+		return errors.New("received empty response")
+	}
+	return json.Unmarshal(body, out)
+}
+
+// GetActions translates the v1 pos/offset paging into Hyperion's skip/limit/sort parameters.
+func (hh *HyperionHistory) GetActions(account eos.AccountName, pos int64, offset int64) (*eos.ActionsResp, error) {
+	q := url.Values{}
+	q.Set("account", string(account))
+	var descending bool
+	var skip, limit int64
+	switch {
+	case pos < 0:
+		// most recent actions, newest first, then reversed below so results are always ascending
+		descending = true
+		limit = abs64(offset) + 1
+	case offset < 0:
+		skip = pos + offset
+		limit = abs64(offset) + 1
+		if skip < 0 {
+			limit += skip
+			skip = 0
+		}
+	default:
+		skip = pos
+		limit = offset + 1
+	}
+	if descending {
+		q.Set("sort", "desc")
+	} else {
+		q.Set("sort", "asc")
+	}
+	q.Set("skip", strconv.FormatInt(skip, 10))
+	q.Set("limit", strconv.FormatInt(limit, 10))
+	// without track, total is capped at 10000 by Elasticsearch, and the sequence numbers would be wrong for busy
+	// accounts
+	q.Set("track", "true")
+
+	hr := &hyperionActionsResp{}
+	if err := hh.get("/v2/history/get_actions", q, hr); err != nil {
+		return nil, err
+	}
+	resp := &eos.ActionsResp{
+		Actions:               make([]eos.ActionResp, len(hr.Actions)),
+		LastIrreversibleBlock: hr.Lib,
+	}
+	for i, ha := range hr.Actions {
+		seq := skip + int64(i)
+		idx := i
+		if descending {
+			seq = hr.Total.Value - 1 - skip - int64(i)
+			idx = len(hr.Actions) - 1 - i
+		}
+		resp.Actions[idx] = eos.ActionResp{
+			GlobalSeq:  eos.JSONInt64(ha.GlobalSequence),
+			AccountSeq: eos.JSONInt64(seq),
+			BlockNum:   ha.BlockNum,
+			BlockTime:  ha.blockTime(),
+			Trace:      ha.trace(),
+		}
+	}
+	return resp, nil
+}
+
+// GetTransaction fetches a transaction using /v2/history/get_transaction
+func (hh *HyperionHistory) GetTransaction(id eos.Checksum256) (*eos.TransactionResp, error) {
+	q := url.Values{}
+	q.Set("id", id.String())
+	ht := &hyperionTransactionResp{}
+	if err := hh.get("/v2/history/get_transaction", q, ht); err != nil {
+		return nil, err
+	}
+	if len(ht.Actions) == 0 {
+		return nil, errors.New("transaction not found")
+	}
+	tx := &eos.TransactionResp{
+		ID:                    ht.TrxId,
+		BlockNum:              ht.Actions[0].BlockNum,
+		BlockTime:             ht.Actions[0].blockTime(),
+		LastIrreversibleBlock: ht.Lib,
+		Traces:                make([]eos.ActionTrace, len(ht.Actions)),
+	}
+	if len(tx.ID) == 0 {
+		tx.ID = id
+	}
+	if !ht.Executed {
+		tx.Receipt.Status = eos.TransactionStatusUnknown
+	}
+	for i := range ht.Actions {
+		tx.Traces[i] = ht.Actions[i].trace()
+	}
+	return tx, nil
+}
+
+// hyperionBlockLimit is the number of actions requested per page when listing the transactions in a block.
+const hyperionBlockLimit = 1000
+
+// GetBlockTxids lists the transactions in a block by filtering get_actions on the block_num field, paging until all
+// of the block's actions have been read.
+func (hh *HyperionHistory) GetBlockTxids(blockNum uint32) (*BlockTxidsResp, error) {
+	resp := &BlockTxidsResp{
+		Ids: make([]eos.Checksum256, 0),
+	}
+	seen := make(map[string]bool)
+	for skip := 0; ; skip += hyperionBlockLimit {
+		q := url.Values{}
+		q.Set("block_num", strconv.FormatUint(uint64(blockNum), 10))
+		q.Set("sort", "asc")
+		q.Set("skip", strconv.Itoa(skip))
+		q.Set("limit", strconv.Itoa(hyperionBlockLimit))
+		hr := &hyperionActionsResp{}
+		if err := hh.get("/v2/history/get_actions", q, hr); err != nil {
+			return nil, err
+		}
+		resp.LastIrreversibleBlock = hr.Lib
+		for _, ha := range hr.Actions {
+			if ha.BlockNum != blockNum || seen[ha.TrxId.String()] {
+				continue
+			}
+			seen[ha.TrxId.String()] = true
+			resp.Ids = append(resp.Ids, ha.TrxId)
+		}
+		if len(hr.Actions) < hyperionBlockLimit {
+			return resp, nil
+		}
+	}
+}
+
+func abs64(i int64) int64 {
+	if i < 0 {
+		return -i
+	}
+	return i
+}
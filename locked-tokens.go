@@ -85,7 +85,11 @@ func (api *API) GetGenesisLockedTokens(accountOrPubkey string) (hasLocked bool,
 // when an account spends or receives tokens. This should be subtracted from the current balance to calculate available
 // tokens.
 func (g *GenesisLockedTokens) ActualRemaining() (tokens uint64, err error) {
-	lockStart := time.Unix(int64(g.Timestamp), 0).UTC()
+	return g.remainingAt(time.Now())
+}
+
+// remainingAt calculates the locked tokens at a point in time
+func (g *GenesisLockedTokens) remainingAt(t time.Time) (tokens uint64, err error) {
 	switch g.GrantType {
 	case LockedGiveaway:
 		// every transaction will cause RemainingLockedAmount to get calculated, so it is always accurate.
@@ -102,22 +106,12 @@ func (g *GenesisLockedTokens) ActualRemaining() (tokens uint64, err error) {
 		fallthrough
 
 	case LockedFounder, LockedPresale:
-		minutes := int(time.Now().Sub(lockStart).Minutes())
-		// have not passed first period
-		if minutes < LockedInitial {
-			return g.RemainingLockedAmount, nil
+		// count the unlock periods that have passed
+		var periods int
+		for periods < LockedPeriods && !t.Before(g.unlockTime(periods)) {
+			periods += 1
 		}
-		// first unlock passed
-		pct := LockedInitialPct
-		// add percentage for each additional
-		for i := 1; i <= LockedPeriods; i++ {
-			if minutes >= (i*LockedIncrement)+LockedInitial {
-				pct += LockedIncrementPct
-			} else {
-				break
-			}
-		}
-		unlocked := uint64(math.Round(float64(g.TotalGrantAmount) * pct))
+		unlocked := g.unlockedAfter(periods)
 		if g.RemainingLockedAmount < (g.TotalGrantAmount - unlocked) {
 			return g.RemainingLockedAmount, nil
 		}
@@ -128,6 +122,29 @@ func (g *GenesisLockedTokens) ActualRemaining() (tokens uint64, err error) {
 	}
 }
 
+// unlockTime is when a genesis unlock period (starting at 0) occurs
+func (g *GenesisLockedTokens) unlockTime(period int) time.Time {
+	minutes := LockedInitial + period*LockedIncrement
+	return time.Unix(int64(g.Timestamp), 0).UTC().Add(time.Duration(minutes) * time.Minute)
+}
+
+// unlockedAfter is the total number of tokens unlocked once a number of periods have passed
+func (g *GenesisLockedTokens) unlockedAfter(periods int) uint64 {
+	switch {
+	case periods <= 0:
+		return 0
+	case periods >= LockedPeriods:
+		return g.TotalGrantAmount
+	}
+	// first unlock passed, add percentage for each additional
+	pct := LockedInitialPct + float64(periods-1)*LockedIncrementPct
+	unlocked := uint64(math.Round(float64(g.TotalGrantAmount) * pct))
+	if unlocked > g.TotalGrantAmount {
+		return g.TotalGrantAmount
+	}
+	return unlocked
+}
+
 // GetTotalGenesisLockTokens tallies the remaining locked tokens based upon the values in the lockedtokens table
 func (api *API) GetTotalGenesisLockTokens() (total uint64, founder uint64, member uint64, presale uint64, giveaway uint64, err error) {
 	nameQueries := splitNames(10)
@@ -173,6 +190,12 @@ func (api *API) GetTotalGenesisLockTokens() (total uint64, founder uint64, membe
 	return
 }
 
+// GetTotalGenesisLockTokensAmount is the same as GetTotalGenesisLockTokens, but returns an exact Amount
+func (api *API) GetTotalGenesisLockTokensAmount() (total Amount, founder Amount, member Amount, presale Amount, giveaway Amount, err error) {
+	t, f, m, p, g, err := api.GetTotalGenesisLockTokens()
+	return Amount(t), Amount(f), Amount(m), Amount(p), Amount(g), err
+}
+
 type nameRange struct {
 	LowerI64  uint64
 	LowerName string
@@ -257,6 +280,11 @@ func NewTransferLockedTokens(actor eos.AccountName, recipientPubKey string, canV
 	)
 }
 
+// NewTransferLockedTokensAmount is the same as NewTransferLockedTokens, but uses an exact Amount
+func NewTransferLockedTokensAmount(actor eos.AccountName, recipientPubKey string, canVote bool, periods []LockPeriods, amount Amount) *Action {
+	return NewTransferLockedTokens(actor, recipientPubKey, canVote, periods, amount.Suf())
+}
+
 // NewValidTransferLockedTokens is the same as NewTransferLockedTokens, but adds checks to ensure the account does not exist, and the periods are legit
 func (api *API) NewValidTransferLockedTokens(actor eos.AccountName, recipientPubKey string, canVote bool, periods []LockPeriods, amount uint64) (*Action, error) {
 	can := CanVoteNone
@@ -278,6 +306,11 @@ func (api *API) NewValidTransferLockedTokens(actor eos.AccountName, recipientPub
 	return NewAction("fio.token", "trnsloctoks", actor, tlt), nil
 }
 
+// NewValidTransferLockedTokensAmount is the same as NewValidTransferLockedTokens, but uses an exact Amount
+func (api *API) NewValidTransferLockedTokensAmount(actor eos.AccountName, recipientPubKey string, canVote bool, periods []LockPeriods, amount Amount) (*Action, error) {
+	return api.NewValidTransferLockedTokens(actor, recipientPubKey, canVote, periods, amount.Suf())
+}
+
 func (tlt *TransferLockedTokens) valid(api *API) error {
 	switch true {
 	case eos.CheckUnderOver(tlt.MaxFee) != nil:
@@ -366,6 +399,12 @@ func (api *API) GetTotalLockTokens() (uint64, error) {
 	return total, nil
 }
 
+// GetTotalLockTokensAmount is the same as GetTotalLockTokens, but returns an exact Amount
+func (api *API) GetTotalLockTokensAmount() (Amount, error) {
+	total, err := api.GetTotalLockTokens()
+	return Amount(total), err
+}
+
 /*
    Circulating Supply
 */
@@ -419,3 +458,9 @@ func (api *API) GetLockedBpRewards() (locked uint64, err error) {
 	}
 	return rows[0].Rewards, nil
 }
+
+// GetLockedBpRewardsAmount is the same as GetLockedBpRewards, but returns an exact Amount
+func (api *API) GetLockedBpRewardsAmount() (Amount, error) {
+	locked, err := api.GetLockedBpRewards()
+	return Amount(locked), err
+}
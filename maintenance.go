@@ -0,0 +1,280 @@
+package fio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/fioprotocol/fio-go/eos"
+	"sync"
+	"time"
+)
+
+// MaintenanceResult is the outcome of a single scheduled task, see MaintenanceHook
+type MaintenanceResult struct {
+	Task    string
+	Time    time.Time
+	Skipped bool   // the task was due, but there was no work to do
+	Reason  string // why the task was skipped
+	TxId    string
+	Err     error
+	NextRun time.Time // earliest time the task will be tried again
+}
+
+// MaintenanceHook receives every result from a MaintenanceScheduler, it is called synchronously so it should
+// not block for long.
+type MaintenanceHook interface {
+	OnResult(result MaintenanceResult)
+}
+
+// MaintenanceHookFunc allows using a function as a MaintenanceHook
+type MaintenanceHookFunc func(result MaintenanceResult)
+
+func (f MaintenanceHookFunc) OnResult(result MaintenanceResult) {
+	f(result)
+}
+
+// MaintenanceTask is an action that a block producer runs on a schedule
+type MaintenanceTask struct {
+	Name     string
+	Interval time.Duration // minimum time between successful runs
+	Daily    bool          // run once per UTC day instead of using Interval
+
+	// Ready checks whether there is work to do, a nil Ready is always ready. If it returns false the reason is
+	// reported in the result, and the task is checked again on the next interval.
+	Ready func(api *API) (ready bool, reason string, err error)
+	// Action builds the action to push
+	Action func(api *API) (*Action, error)
+
+	lastRun   time.Time
+	failures  int
+	nextRetry time.Time
+}
+
+// due checks if a task should run
+func (t *MaintenanceTask) due(now time.Time) bool {
+	if now.Before(t.nextRetry) {
+		return false
+	}
+	if t.lastRun.IsZero() {
+		return true
+	}
+	if t.Daily {
+		return now.UTC().Truncate(24 * time.Hour).After(t.lastRun.UTC())
+	}
+	return !now.Before(t.lastRun.Add(t.Interval))
+}
+
+// next is the earliest time the task will run again
+func (t *MaintenanceTask) next() time.Time {
+	if t.nextRetry.After(t.lastRun) {
+		return t.nextRetry
+	}
+	if t.Daily {
+		return t.lastRun.UTC().Truncate(24 * time.Hour).Add(24 * time.Hour)
+	}
+	return t.lastRun.Add(t.Interval)
+}
+
+// BpClaimTask claims producer rewards once per day
+func BpClaimTask(fioAddress string, actor eos.AccountName) *MaintenanceTask {
+	return &MaintenanceTask{
+		Name:  "bpclaim",
+		Daily: true,
+		Action: func(api *API) (*Action, error) {
+			return NewBpClaim(fioAddress, actor), nil
+		},
+	}
+}
+
+// TpidClaimTask pays out TPID rewards once per day
+func TpidClaimTask(actor eos.AccountName) *MaintenanceTask {
+	return &MaintenanceTask{
+		Name:  "tpidclaim",
+		Daily: true,
+		Action: func(api *API) (*Action, error) {
+			return NewPayTpidRewards(actor), nil
+		},
+	}
+}
+
+// BurnExpiredTask removes expired domains and addresses, it only runs when GetExpiredOffset finds an expired domain
+func BurnExpiredTask(actor eos.AccountName, interval time.Duration) *MaintenanceTask {
+	offset := int64(0)
+	return &MaintenanceTask{
+		Name:     "burnexpired",
+		Interval: interval,
+		Ready: func(api *API) (bool, string, error) {
+			var err error
+			offset, err = api.GetExpiredOffset(false)
+			if err != nil {
+				if errors.Is(err, ErrNoExpired) {
+					return false, "no expired domains", nil
+				}
+				return false, "", err
+			}
+			return true, "", nil
+		},
+		Action: func(api *API) (*Action, error) {
+			return NewBurnExpiredRange(offset, 15, actor), nil
+		},
+	}
+}
+
+// BurnNftsTask removes NFT mappings that have been marked for removal
+func BurnNftsTask(actor eos.AccountName, interval time.Duration) *MaintenanceTask {
+	return &MaintenanceTask{
+		Name:     "burnnfts",
+		Interval: interval,
+		Action: func(api *API) (*Action, error) {
+			return NewBurnNfts(actor), nil
+		},
+	}
+}
+
+// ComputeFeesTask updates fees after producers have voted, it only runs when a fee has votes pending
+func ComputeFeesTask(actor eos.AccountName, interval time.Duration) *MaintenanceTask {
+	return &MaintenanceTask{
+		Name:     "computefees",
+		Interval: interval,
+		Ready: func(api *API) (bool, string, error) {
+			fees := make([]FioFee, 0)
+			if err := NewTableQuery(api, "fio.fee", "fiofees").Limit(100).All(&fees); err != nil {
+				return false, "", err
+			}
+			for _, f := range fees {
+				if f.VotesPending {
+					return true, "", nil
+				}
+			}
+			return false, "no fee votes pending", nil
+		},
+		Action: func(api *API) (*Action, error) {
+			return NewComputeFees(actor), nil
+		},
+	}
+}
+
+// MaintenanceScheduler runs block producer maintenance actions on a schedule. A failed task is retried with an
+// exponential backoff between MinBackoff and MaxBackoff. Every outcome is reported to the Hook.
+//
+//	s := fio.NewMaintenanceScheduler(api, acc.Actor, "bp@dapixdev", fio.MaintenanceHookFunc(func(r fio.MaintenanceResult) {
+//	    log.Printf("%+v", r)
+//	}))
+//	err := s.Run(ctx)
+type MaintenanceScheduler struct {
+	Tasks      []*MaintenanceTask
+	Hook       MaintenanceHook
+	Tick       time.Duration // how often tasks are checked
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	api  *API
+	mux  sync.Mutex
+	now  func() time.Time
+	push func(actions ...*Action) (*eos.PushTransactionFullResp, error)
+}
+
+// NewMaintenanceScheduler creates a scheduler with the default tasks for a producer: bpclaim and tpidclaim daily,
+// burnexpired and burnnfts hourly, and computefees every ten minutes. Actions are signed by the API's signer.
+func NewMaintenanceScheduler(api *API, actor eos.AccountName, fioAddress string, hook MaintenanceHook) *MaintenanceScheduler {
+	return &MaintenanceScheduler{
+		Tasks: []*MaintenanceTask{
+			BpClaimTask(fioAddress, actor),
+			TpidClaimTask(actor),
+			BurnExpiredTask(actor, time.Hour),
+			BurnNftsTask(actor, time.Hour),
+			ComputeFeesTask(actor, 10*time.Minute),
+		},
+		Hook:       hook,
+		Tick:       time.Minute,
+		MinBackoff: time.Minute,
+		MaxBackoff: time.Hour,
+		api:        api,
+		now:        time.Now,
+		push:       api.SignPushActions,
+	}
+}
+
+// RunOnce runs every task that is due, returning the results
+func (s *MaintenanceScheduler) RunOnce() []MaintenanceResult {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	results := make([]MaintenanceResult, 0)
+	for _, task := range s.Tasks {
+		now := s.now()
+		if !task.due(now) {
+			continue
+		}
+		result := s.run(task, now)
+		results = append(results, result)
+		if s.Hook != nil {
+			s.Hook.OnResult(result)
+		}
+	}
+	return results
+}
+
+// run attempts a single task and updates its schedule
+func (s *MaintenanceScheduler) run(task *MaintenanceTask, now time.Time) (result MaintenanceResult) {
+	result = MaintenanceResult{Task: task.Name, Time: now}
+	defer func() {
+		if result.Err != nil {
+			task.failures += 1
+			// doubled for each failure, stopping at MaxBackoff so that it can't overflow
+			backoff := s.MinBackoff
+			for i := 1; i < task.failures && backoff < s.MaxBackoff; i++ {
+				backoff *= 2
+			}
+			if backoff > s.MaxBackoff || backoff <= 0 {
+				backoff = s.MaxBackoff
+			}
+			task.nextRetry = now.Add(backoff)
+		} else {
+			task.failures = 0
+			task.lastRun = now
+		}
+		result.NextRun = task.next()
+	}()
+
+	if task.Ready != nil {
+		ready, reason, err := task.Ready(s.api)
+		if err != nil {
+			result.Err = fmt.Errorf("checking %s: %s", task.Name, err)
+			return result
+		}
+		if !ready {
+			result.Skipped = true
+			result.Reason = reason
+			return result
+		}
+	}
+	act, err := task.Action(s.api)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	resp, err := s.push(act)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	result.TxId = resp.TransactionID
+	return result
+}
+
+// Run checks the tasks every Tick until the context is cancelled
+func (s *MaintenanceScheduler) Run(ctx context.Context) error {
+	if len(s.Tasks) == 0 {
+		return errors.New("no tasks to run")
+	}
+	ticker := time.NewTicker(s.Tick)
+	defer ticker.Stop()
+	for {
+		s.RunOnce()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package fio
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/fioprotocol/fio-go/eos"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMaintenanceScheduler(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := GetTableRowsOrderRequest{}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		switch req.Table {
+		case "domains":
+			_, _ = w.Write([]byte(`{"rows":[]}`))
+		case "fiofees":
+			_, _ = w.Write([]byte(`{"rows":[{"fee_id":1,"end_point":"add_nft","votes_pending":0},{"fee_id":2,"end_point":"add_pub_address","votes_pending":1}]}`))
+		}
+	}))
+	defer srv.Close()
+	api := &API{API: eos.New(srv.URL)}
+
+	results := make([]MaintenanceResult, 0)
+	s := NewMaintenanceScheduler(api, "producer", "bp@dapixdev", MaintenanceHookFunc(func(r MaintenanceResult) {
+		results = append(results, r)
+	}))
+	now := time.Date(2021, 1, 1, 12, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+	pushed := make([]string, 0)
+	s.push = func(actions ...*Action) (*eos.PushTransactionFullResp, error) {
+		if actions[0].Name == "burnnfts" {
+			return nil, errors.New("nothing to burn")
+		}
+		pushed = append(pushed, string(actions[0].Name))
+		return &eos.PushTransactionFullResp{TransactionID: "abc"}, nil
+	}
+	byTask := func(rs []MaintenanceResult) map[string]MaintenanceResult {
+		m := make(map[string]MaintenanceResult)
+		for _, r := range rs {
+			m[r.Task] = r
+		}
+		return m
+	}
+
+	first := byTask(s.RunOnce())
+	if len(first) != 5 || len(results) != 5 {
+		t.Fatal("expected every task to run", first)
+	}
+	if r := first["bpclaim"]; r.Err != nil || r.TxId != "abc" || !r.NextRun.Equal(time.Date(2021, 1, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected bpclaim result %+v", r)
+	}
+	if r := first["burnexpired"]; !r.Skipped || r.Reason != "no expired domains" || r.Err != nil {
+		t.Errorf("burnexpired should be skipped %+v", r)
+	}
+	if r := first["computefees"]; r.Skipped || r.Err != nil {
+		t.Errorf("computefees should run when votes are pending %+v", r)
+	}
+	if r := first["burnnfts"]; r.Err == nil || !r.NextRun.Equal(now.Add(time.Minute)) {
+		t.Errorf("burnnfts should back off %+v", r)
+	}
+
+	// failures back off exponentially, the interval and daily tasks are not due yet
+	now = now.Add(5 * time.Minute)
+	second := byTask(s.RunOnce())
+	if len(second) != 1 || second["burnnfts"].Err == nil || !second["burnnfts"].NextRun.Equal(now.Add(2*time.Minute)) {
+		t.Errorf("only burnnfts should retry %+v", second)
+	}
+
+	now = time.Date(2021, 1, 2, 0, 30, 0, 0, time.UTC)
+	third := byTask(s.RunOnce())
+	if _, ok := third["bpclaim"]; !ok {
+		t.Error("bpclaim should run on the next day")
+	}
+	if _, ok := third["burnexpired"]; !ok {
+		t.Error("burnexpired should be checked after the interval")
+	}
+	want := []string{"bpclaim", "tpidclaim", "computefees", "bpclaim", "tpidclaim", "computefees"}
+	if len(pushed) != len(want) {
+		t.Fatal("unexpected actions pushed", pushed)
+	}
+	for i := range want {
+		if pushed[i] != want[i] {
+			t.Fatal("unexpected actions pushed", pushed)
+		}
+	}
+
+	// the backoff stops at MaxBackoff however many times a task fails
+	task := &MaintenanceTask{Name: "failing", Interval: time.Minute, Action: func(api *API) (*Action, error) {
+		return nil, errors.New("failed")
+	}}
+	for i := 0; i < 100; i++ {
+		if r := s.run(task, now); !r.NextRun.Equal(now.Add(s.MaxBackoff)) && i > 10 {
+			t.Fatalf("backoff after %d failures was %s", task.failures, r.NextRun.Sub(now))
+		}
+	}
+}
+
+func TestGetExpiredOffset_None(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"rows":[]}`))
+	}))
+	defer srv.Close()
+	api := &API{API: eos.New(srv.URL)}
+	if _, err := api.GetExpiredOffset(false); !errors.Is(err, ErrNoExpired) {
+		t.Error("expected ErrNoExpired", err)
+	}
+}
@@ -196,6 +196,11 @@ func NewMsigExec(proposer eos.AccountName, proposal eos.Name, fee uint64, actor
 	)
 }
 
+// NewMsigExecAmount is the same as NewMsigExec, but uses an exact Amount for the fee
+func NewMsigExecAmount(proposer eos.AccountName, proposal eos.Name, fee Amount, actor eos.AccountName) *Action {
+	return NewMsigExec(proposer, proposal, fee.Suf(), actor)
+}
+
 // MsigInvalidate is used to remove all approvals and proposals for an account
 type MsigInvalidate struct {
 	Name   eos.Name `json:"name"`
@@ -117,6 +117,14 @@ func NewAddNft(fioAddress string, nfts []NftToAdd, actor eos.AccountName) (*Acti
 	return NewAction("fio.address", "addnft", actor, add), nil
 }
 
+// NewValidAddNft is the same as NewAddNft, but returns a *ValidationError describing an invalid FIO address
+func NewValidAddNft(fioAddress string, nfts []NftToAdd, actor eos.AccountName) (*Action, error) {
+	if _, err := ValidateAddress(fioAddress); err != nil {
+		return nil, err
+	}
+	return NewAddNft(fioAddress, nfts, actor)
+}
+
 // MustNewAddNft panics on error
 func MustNewAddNft(fioAddress string, nfts []NftToAdd, actor eos.AccountName) *Action {
 	a, e := NewAddNft(fioAddress, nfts, actor)
@@ -188,6 +196,14 @@ func NewRemNft(fioAddress string, nfts []NftToDelete, actor eos.AccountName) (*A
 	}), nil
 }
 
+// NewValidRemNft is the same as NewRemNft, but returns a *ValidationError describing an invalid FIO address
+func NewValidRemNft(fioAddress string, nfts []NftToDelete, actor eos.AccountName) (*Action, error) {
+	if _, err := ValidateAddress(fioAddress); err != nil {
+		return nil, err
+	}
+	return NewRemNft(fioAddress, nfts, actor)
+}
+
 // MustNewRemNft creates an action or panics
 func MustNewRemNft(fioAddress string, nfts []NftToDelete, actor eos.AccountName) *Action {
 	a, e := NewRemNft(fioAddress, nfts, actor)
@@ -215,6 +231,14 @@ func NewRemAllNft(fioAddress string, actor eos.AccountName) *Action {
 	})
 }
 
+// NewValidRemAllNft is the same as NewRemAllNft, but returns a *ValidationError for an invalid FIO address
+func NewValidRemAllNft(fioAddress string, actor eos.AccountName) (*Action, error) {
+	if _, err := ValidateAddress(fioAddress); err != nil {
+		return nil, err
+	}
+	return NewRemAllNft(fioAddress, actor), nil
+}
+
 type Nft struct {
 	ChainCode       string `json:"chain_code,omitempty"`
 	ContractAddress string `json:"contract_address,omitempty"`
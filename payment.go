@@ -0,0 +1,231 @@
+package fio
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// PaymentState is the status of a FIO Request
+type PaymentState string
+
+const (
+	PaymentPending   PaymentState = "pending"   // waiting for the payer
+	PaymentPaid      PaymentState = "paid"      // the payer recorded a payment with recordobt
+	PaymentRejected  PaymentState = "rejected"  // the payer rejected the request
+	PaymentCancelled PaymentState = "cancelled" // the payee cancelled the request
+)
+
+// paymentStateFromStatus converts the status string from the get_sent_fio_requests endpoint
+func paymentStateFromStatus(status string) (PaymentState, error) {
+	switch status {
+	case "requested", "":
+		return PaymentPending, nil
+	case "sent_to_blockchain":
+		return PaymentPaid, nil
+	case "rejected":
+		return PaymentRejected, nil
+	case "cancelled":
+		return PaymentCancelled, nil
+	}
+	return "", fmt.Errorf("unknown request status %q", status)
+}
+
+// paymentStateFromCode converts the status stored in the fioreqstss table
+func paymentStateFromCode(status uint64) (PaymentState, error) {
+	switch status {
+	case 0:
+		return PaymentPending, nil
+	case 1:
+		return PaymentRejected, nil
+	case 2:
+		return PaymentPaid, nil
+	case 3:
+		return PaymentCancelled, nil
+	}
+	return "", fmt.Errorf("unknown request status %d", status)
+}
+
+// PaymentRequest is a FIO Request with its content decrypted, see PaymentFlow
+type PaymentRequest struct {
+	Id              uint64
+	State           PaymentState
+	PayerFioAddress string
+	PayerKey        string
+	PayeeFioAddress string
+	PayeeKey        string
+	Time            time.Time
+	Content         *ObtRequestContent // nil if the content could not be decrypted
+	DecryptErr      error
+
+	flow *PaymentFlow
+}
+
+// PaymentFlow handles paying, rejecting, and cancelling FIO Requests for an account. The account may be the payer,
+// who received the request, or the payee, who sent it:
+//
+//	flow := api.NewPaymentFlow(account)
+//	pending, err := flow.Pending(100, 0)
+//	for _, req := range pending {
+//	    // send req.Content.Amount to req.Content.PayeePublicAddress on req.Content.ChainCode, then:
+//	    act, err := req.Paid(myPublicAddress, txid)
+//	    _, err = api.SignPushActions(act)
+//	}
+type PaymentFlow struct {
+	Account *Account
+	api     *API
+}
+
+// NewPaymentFlow creates a PaymentFlow for an account
+func (api *API) NewPaymentFlow(account *Account) *PaymentFlow {
+	return &PaymentFlow{Account: account, api: api}
+}
+
+// newRequest builds a PaymentRequest, decrypting the content using the key of the other party
+func (f *PaymentFlow) newRequest(id uint64, state PaymentState, payer, payerKey, payee, payeeKey, content string, t time.Time) *PaymentRequest {
+	r := &PaymentRequest{
+		Id:              id,
+		State:           state,
+		PayerFioAddress: payer,
+		PayerKey:        payerKey,
+		PayeeFioAddress: payee,
+		PayeeKey:        payeeKey,
+		Time:            t,
+		flow:            f,
+	}
+	other := payeeKey
+	if f.Account.PubKey == payeeKey {
+		other = payerKey
+	}
+	decrypted, err := DecryptContent(f.Account, other, content, ObtRequestType)
+	if err != nil {
+		r.DecryptErr = err
+		return r
+	}
+	r.Content = decrypted.Request
+	return r
+}
+
+// Pending gets requests waiting for the account to pay or reject them
+func (f *PaymentFlow) Pending(limit int, offset int) ([]*PaymentRequest, error) {
+	resp, _, err := f.api.GetPendingFioRequests(f.Account.PubKey, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	requests := make([]*PaymentRequest, 0, len(resp.Requests))
+	for _, r := range resp.Requests {
+		requests = append(requests, f.newRequest(r.FioRequestId, PaymentPending, r.PayerFioAddress, r.PayerFioPublicKey,
+			r.PayeeFioAddress, r.PayeeFioPublicKey, r.Content, r.TimeStamp.Time))
+	}
+	return requests, nil
+}
+
+// Sent gets requests sent by the account, and their current state
+func (f *PaymentFlow) Sent(limit int, offset int) ([]*PaymentRequest, error) {
+	resp, _, err := f.api.GetSentFioRequests(f.Account.PubKey, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	requests := make([]*PaymentRequest, 0, len(resp.Requests))
+	for _, r := range resp.Requests {
+		state, err := paymentStateFromStatus(r.Status)
+		if err != nil {
+			return nil, err
+		}
+		requests = append(requests, f.newRequest(r.FioRequestId, state, r.PayerFioAddress, r.PayerFioPublicKey,
+			r.PayeeFioAddress, r.PayeeFioPublicKey, r.Content, r.TimeStamp.Time))
+	}
+	return requests, nil
+}
+
+// Get looks up a single request by ID, the account must be either the payer or payee
+func (f *PaymentFlow) Get(requestId uint64) (*PaymentRequest, error) {
+	req, err := f.api.GetFioRequest(requestId)
+	if err != nil {
+		return nil, err
+	}
+	if f.Account.PubKey != req.PayerKey && f.Account.PubKey != req.PayeeKey {
+		return nil, fmt.Errorf("request %d is not for %s", requestId, f.Account.Actor)
+	}
+	state := PaymentPending
+	found, status, err := f.api.GetFioRequestStatus(requestId)
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		if state, err = paymentStateFromCode(status.Status); err != nil {
+			return nil, err
+		}
+	}
+	return f.newRequest(req.FioRequestId, state, req.PayerFioAddress, req.PayerKey, req.PayeeFioAddress, req.PayeeKey,
+		req.Content, req.Time), nil
+}
+
+// checkPayer ensures the request is pending and the flow's account is the payer
+func (r *PaymentRequest) checkPayer() error {
+	if r.State != PaymentPending {
+		return fmt.Errorf("request %d is %s", r.Id, r.State)
+	}
+	if r.flow.Account.PubKey != r.PayerKey {
+		return fmt.Errorf("only the payer can respond to request %d", r.Id)
+	}
+	return nil
+}
+
+// Reject builds the action for the payer to reject the request
+func (r *PaymentRequest) Reject() (*Action, error) {
+	if err := r.checkPayer(); err != nil {
+		return nil, err
+	}
+	return NewRejectFndReq(r.flow.Account.Actor, strconv.FormatUint(r.Id, 10)), nil
+}
+
+// Cancel builds the action for the payee to cancel the request
+func (r *PaymentRequest) Cancel() (*Action, error) {
+	if r.State != PaymentPending {
+		return nil, fmt.Errorf("request %d is %s", r.Id, r.State)
+	}
+	if r.flow.Account.PubKey != r.PayeeKey {
+		return nil, fmt.Errorf("only the payee can cancel request %d", r.Id)
+	}
+	return NewCancelFndReq(r.flow.Account.Actor, r.Id), nil
+}
+
+// Record builds the recordobt action for a payment made in response to the request, encrypted for the payee.
+// Fields left empty in rec are copied from the request: the payee's public address, amount, chain and token codes,
+// hash and offline url. Status defaults to sent_to_blockchain. The payer's public address and ObtId (the transaction
+// ID on the other chain) are required.
+func (r *PaymentRequest) Record(rec ObtRecordContent) (*Action, error) {
+	if err := r.checkPayer(); err != nil {
+		return nil, err
+	}
+	if r.Content == nil {
+		return nil, fmt.Errorf("could not decrypt request %d: %v", r.Id, r.DecryptErr)
+	}
+	if rec.PayerPublicAddress == "" || rec.ObtId == "" {
+		return nil, errors.New("payer public address and obt id are required")
+	}
+	fill := func(field *string, value string) {
+		if *field == "" {
+			*field = value
+		}
+	}
+	fill(&rec.PayeePublicAddress, r.Content.PayeePublicAddress)
+	fill(&rec.Amount, r.Content.Amount)
+	fill(&rec.ChainCode, r.Content.ChainCode)
+	fill(&rec.TokenCode, r.Content.TokenCode)
+	fill(&rec.Hash, r.Content.Hash)
+	fill(&rec.OfflineUrl, r.Content.OfflineUrl)
+	fill(&rec.Status, "sent_to_blockchain")
+	content, err := rec.Encrypt(r.flow.Account, r.PayeeKey)
+	if err != nil {
+		return nil, err
+	}
+	return NewRecordSend(r.flow.Account.Actor, strconv.FormatUint(r.Id, 10), r.PayerFioAddress, r.PayeeFioAddress, content), nil
+}
+
+// Paid is a shortcut for Record, used after sending the requested amount
+func (r *PaymentRequest) Paid(payerPublicAddress string, obtId string) (*Action, error) {
+	return r.Record(ObtRecordContent{PayerPublicAddress: payerPublicAddress, ObtId: obtId})
+}
@@ -0,0 +1,129 @@
+package fio
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/fioprotocol/fio-go/eos"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPaymentFlow(t *testing.T) {
+	alice, err := NewRandomAccount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	bob, err := NewRandomAccount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// bob requests 1.5 BTC from alice
+	content, err := ObtRequestContent{
+		PayeePublicAddress: "bc1qbob",
+		Amount:             "1.5",
+		ChainCode:          "BTC",
+		TokenCode:          "BTC",
+		Memo:               "invoice 7",
+	}.Encrypt(bob, alice.PubKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	status := "[]"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := fmt.Sprintf(`{"fio_request_id":9,"payer_fio_address":"alice@fiotestnet","payee_fio_address":"bob@fiotestnet",
+			"payer_fio_public_key":%q,"payee_fio_public_key":%q,"content":%q,"time_stamp":"2021-01-01T00:00:00","status":"%%s"}`,
+			alice.PubKey, bob.PubKey, content)
+		switch {
+		case strings.HasSuffix(r.URL.Path, "get_pending_fio_requests"):
+			_, _ = fmt.Fprintf(w, `{"requests":[`+request+`],"more":0}`, "requested")
+		case strings.HasSuffix(r.URL.Path, "get_sent_fio_requests"):
+			_, _ = fmt.Fprintf(w, `{"requests":[`+request+`],"more":0}`, "sent_to_blockchain")
+		case strings.HasSuffix(r.URL.Path, "get_pub_address"):
+			key := alice.PubKey
+			if req := (pubAddressRequest{}); json.NewDecoder(r.Body).Decode(&req) == nil && req.FioAddress == "bob@fiotestnet" {
+				key = bob.PubKey
+			}
+			_, _ = fmt.Fprintf(w, `{"public_address":%q}`, key)
+		default:
+			req := GetTableRowsOrderRequest{}
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			switch req.Table {
+			case "fioreqctxts":
+				_, _ = fmt.Fprintf(w, `{"rows":[{"fio_request_id":9,"content":%q,"time_stamp":1609459200,"payer_fio_addr":"alice@fiotestnet",
+					"payer_key":%q,"payee_fio_addr":"bob@fiotestnet","payee_key":%q}]}`, content, alice.PubKey, bob.PubKey)
+			case "fioreqstss":
+				_, _ = fmt.Fprintf(w, `{"rows":%s}`, status)
+			}
+		}
+	}))
+	defer srv.Close()
+	api := &API{API: eos.New(srv.URL)}
+
+	pending, err := api.NewPaymentFlow(alice).Pending(10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 1 || pending[0].Content == nil || pending[0].Content.Amount != "1.5" || pending[0].State != PaymentPending {
+		t.Fatalf("unexpected pending requests %+v", pending)
+	}
+	req := pending[0]
+	act, err := req.Paid("bc1qalice", "abc123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rs := act.Data.(RecordSend)
+	if rs.FioRequestId != "9" || rs.PayerFioAddress != "alice@fiotestnet" || rs.PayeeFioAddress != "bob@fiotestnet" || rs.Actor != string(alice.Actor) {
+		t.Errorf("unexpected recordobt %+v", rs)
+	}
+	// the payee can read the record
+	decrypted, err := DecryptContent(bob, alice.PubKey, rs.Content, ObtResponseType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec := decrypted.Record; rec.PayeePublicAddress != "bc1qbob" || rec.PayerPublicAddress != "bc1qalice" || rec.Amount != "1.5" ||
+		rec.ChainCode != "BTC" || rec.Status != "sent_to_blockchain" || rec.ObtId != "abc123" {
+		t.Errorf("unexpected record content %+v", rec)
+	}
+	if act, err = req.Reject(); err != nil || act.Data.(RejectFndReq).FioRequestId != "9" {
+		t.Error("payer should be able to reject", err)
+	}
+	if _, err = req.Cancel(); err == nil {
+		t.Error("payer should not be able to cancel")
+	}
+	if _, err = req.Paid("", "abc123"); err == nil {
+		t.Error("expected error without a payer address")
+	}
+
+	// bob sees the request as paid, and can no longer cancel it
+	sent, err := api.NewPaymentFlow(bob).Sent(10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != 1 || sent[0].State != PaymentPaid || sent[0].Content == nil || sent[0].Content.Memo != "invoice 7" {
+		t.Fatalf("unexpected sent requests %+v", sent)
+	}
+	if _, err = sent[0].Cancel(); err == nil {
+		t.Error("should not cancel a paid request")
+	}
+
+	got, err := api.NewPaymentFlow(bob).Get(9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if act, err = got.Cancel(); err != nil || got.State != PaymentPending || act.Data.(CancelFndReq).FioRequestId != "9" {
+		t.Error("payee should be able to cancel a pending request", err)
+	}
+	if _, err = got.Paid("bc1qalice", "abc123"); err == nil {
+		t.Error("payee should not record a payment")
+	}
+	status = `[{"id":1,"fio_request_id":9,"status":1}]`
+	if got, err = api.NewPaymentFlow(alice).Get(9); err != nil || got.State != PaymentRejected {
+		t.Error("expected rejected request", got, err)
+	}
+	stranger, _ := NewRandomAccount()
+	if _, err = api.NewPaymentFlow(stranger).Get(9); err == nil {
+		t.Error("expected error for an unrelated account")
+	}
+}
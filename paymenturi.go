@@ -0,0 +1,210 @@
+package fio
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// PaymentUriScheme is the URI scheme for FIO payment intents
+const PaymentUriScheme = "fio"
+
+// MaxPaymentMemoLength is the longest memo allowed in a PaymentUri, the same limit wallets use for request memos
+const MaxPaymentMemoLength = 256
+
+// PaymentUri is a payment intent that wallets exchange using a link or QR code. It identifies the payee by FIO
+// address, so the payer's wallet resolves the public address for the chain when the URI is opened:
+//
+//	fio:alice@wallet?amount=1.5&chain=BTC&memo=invoice+7&request=42&token=BTC
+//
+// Amount is in the token's own units, not SUF. The request is the ID of an existing FIO Request, if any.
+type PaymentUri struct {
+	Payee      Address
+	ChainCode  string
+	TokenCode  string
+	Amount     string
+	Memo       string
+	RequestId  string
+	Hash       string
+	OfflineUrl string
+}
+
+// ParsePaymentUri decodes and validates a fio: URI, both fio:alice@wallet and fio://alice@wallet are accepted
+func ParsePaymentUri(uri string) (*PaymentUri, error) {
+	u, err := url.Parse(strings.TrimSpace(uri))
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(u.Scheme, PaymentUriScheme) {
+		return nil, fmt.Errorf("not a %s: uri", PaymentUriScheme)
+	}
+	payee := u.Opaque
+	if payee == "" && u.User != nil {
+		payee = u.User.Username() + "@" + u.Host
+	}
+	if payee, err = url.PathUnescape(payee); err != nil {
+		return nil, err
+	}
+	q := u.Query()
+	p := &PaymentUri{
+		Payee:      Address(payee),
+		ChainCode:  q.Get("chain"),
+		TokenCode:  q.Get("token"),
+		Amount:     q.Get("amount"),
+		Memo:       q.Get("memo"),
+		RequestId:  q.Get("request"),
+		Hash:       q.Get("hash"),
+		OfflineUrl: q.Get("offline_url"),
+	}
+	if err = p.Validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Validate checks the payee, codes and amount, and normalizes the payee and codes. A missing token code is set to
+// the chain code.
+func (p *PaymentUri) Validate() error {
+	payee, err := ValidateAddress(string(p.Payee))
+	if err != nil {
+		return err
+	}
+	p.Payee = payee
+	if p.TokenCode == "" {
+		p.TokenCode = p.ChainCode
+	}
+	if err = ValidateChainCode(p.ChainCode); err != nil {
+		return err
+	}
+	if err = ValidateTokenCode(p.TokenCode); err != nil {
+		return err
+	}
+	if p.TokenCode == "*" {
+		return errors.New("a payment must be for a specific token")
+	}
+	p.ChainCode, p.TokenCode = strings.ToUpper(p.ChainCode), strings.ToUpper(p.TokenCode)
+	if p.Amount != "" && !validDecimal(p.Amount) {
+		return fmt.Errorf("invalid amount %q", p.Amount)
+	}
+	if len(p.Memo) > MaxPaymentMemoLength {
+		return fmt.Errorf("memo must be no more than %d characters", MaxPaymentMemoLength)
+	}
+	if p.RequestId != "" {
+		if _, err = strconv.ParseUint(p.RequestId, 10, 64); err != nil {
+			return fmt.Errorf("invalid request id %q", p.RequestId)
+		}
+	}
+	if p.OfflineUrl != "" {
+		if u, err := url.Parse(p.OfflineUrl); err != nil || u.Scheme != "https" {
+			return errors.New("offline url must use https")
+		}
+	}
+	return nil
+}
+
+// validDecimal checks for a positive decimal number without a sign or exponent
+func validDecimal(s string) bool {
+	digits, point := 0, false
+	nonZero := false
+	for _, c := range s {
+		switch {
+		case c == '.' && !point:
+			point = true
+		case c >= '0' && c <= '9':
+			digits += 1
+			nonZero = nonZero || c != '0'
+		default:
+			return false
+		}
+	}
+	return digits > 0 && nonZero
+}
+
+// String encodes the URI, this is also the payload for a QR code
+func (p PaymentUri) String() string {
+	q := url.Values{}
+	set := func(key string, value string) {
+		if value != "" {
+			q.Set(key, value)
+		}
+	}
+	set("chain", p.ChainCode)
+	if p.TokenCode != p.ChainCode {
+		set("token", p.TokenCode)
+	}
+	set("amount", p.Amount)
+	set("memo", p.Memo)
+	set("request", p.RequestId)
+	set("hash", p.Hash)
+	set("offline_url", p.OfflineUrl)
+	s := PaymentUriScheme + ":" + string(p.Payee)
+	if len(q) > 0 {
+		s += "?" + q.Encode()
+	}
+	return s
+}
+
+// ObtRequestContent converts the URI to FIO Request content, payeePublicAddress is the payee's address on the
+// chain, see API.ResolvePaymentUri.
+func (p PaymentUri) ObtRequestContent(payeePublicAddress string) ObtRequestContent {
+	return ObtRequestContent{
+		PayeePublicAddress: payeePublicAddress,
+		Amount:             p.Amount,
+		ChainCode:          p.ChainCode,
+		TokenCode:          p.TokenCode,
+		Memo:               p.Memo,
+		Hash:               p.Hash,
+		OfflineUrl:         p.OfflineUrl,
+	}
+}
+
+// NewPaymentUri creates a URI from FIO Request content, so that a request can be shared as a QR code. requestId
+// may be empty if the request has not been sent on chain.
+func NewPaymentUri(payee Address, content ObtRequestContent, requestId string) (*PaymentUri, error) {
+	p := &PaymentUri{
+		Payee:      payee,
+		ChainCode:  content.ChainCode,
+		TokenCode:  content.TokenCode,
+		Amount:     content.Amount,
+		Memo:       content.Memo,
+		RequestId:  requestId,
+		Hash:       content.Hash,
+		OfflineUrl: content.OfflineUrl,
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// ResolvePaymentUri looks up the payee's public address for the URI's chain and token, and returns the content for
+// paying it.
+func (api *API) ResolvePaymentUri(p *PaymentUri) (*ObtRequestContent, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	pub, found, err := api.PubAddressLookup(p.Payee, p.ChainCode, p.TokenCode)
+	if err != nil {
+		return nil, err
+	}
+	if !found || pub.PublicAddress == "" {
+		return nil, fmt.Errorf("%s does not have a public address for %s:%s", p.Payee, p.ChainCode, p.TokenCode)
+	}
+	content := p.ObtRequestContent(pub.PublicAddress)
+	return &content, nil
+}
+
+// OpenPaymentUri parses a URI and resolves the payee's public address
+func (api *API) OpenPaymentUri(uri string) (*PaymentUri, *ObtRequestContent, error) {
+	p, err := ParsePaymentUri(uri)
+	if err != nil {
+		return nil, nil, err
+	}
+	content, err := api.ResolvePaymentUri(p)
+	if err != nil {
+		return nil, nil, err
+	}
+	return p, content, nil
+}
@@ -0,0 +1,100 @@
+package fio
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/fioprotocol/fio-go/eos"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParsePaymentUri(t *testing.T) {
+	p, err := ParsePaymentUri("fio:Bob@FIOTestnet?chain=eth&token=usdt&amount=25.10&memo=invoice+7&request=42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Payee != "bob@fiotestnet" || p.ChainCode != "ETH" || p.TokenCode != "USDT" || p.Amount != "25.10" ||
+		p.Memo != "invoice 7" || p.RequestId != "42" {
+		t.Errorf("unexpected uri %+v", p)
+	}
+	if s := p.String(); s != "fio:bob@fiotestnet?amount=25.10&chain=ETH&memo=invoice+7&request=42&token=USDT" {
+		t.Error("unexpected encoding", s)
+	}
+	if p, err = ParsePaymentUri("fio://bob@fiotestnet?chain=BTC"); err != nil || p.Payee != "bob@fiotestnet" || p.TokenCode != "BTC" {
+		t.Error("expected token to default to the chain", p, err)
+	}
+	if s := p.String(); s != "fio:bob@fiotestnet?chain=BTC" {
+		t.Error("unexpected encoding", s)
+	}
+
+	for _, bad := range []string{
+		"bitcoin:bc1qbob?amount=1",
+		"fio:bob?chain=BTC",
+		"fio:bob@fiotestnet",
+		"fio:bob@fiotestnet?chain=ETH&token=*",
+		"fio:bob@fiotestnet?chain=BTC&amount=-1",
+		"fio:bob@fiotestnet?chain=BTC&amount=0.0",
+		"fio:bob@fiotestnet?chain=BTC&amount=1e5",
+		"fio:bob@fiotestnet?chain=BTC&amount=1.2.3",
+		"fio:bob@fiotestnet?chain=BTC&request=abc",
+		"fio:bob@fiotestnet?chain=BTC&offline_url=http://example.com",
+		"fio:bob@fiotestnet?chain=BTC&memo=" + strings.Repeat("a", MaxPaymentMemoLength+1),
+	} {
+		if _, err = ParsePaymentUri(bad); err == nil {
+			t.Error("expected error for", bad)
+		}
+	}
+}
+
+func TestPaymentUri_ObtRequestContent(t *testing.T) {
+	content := ObtRequestContent{
+		PayeePublicAddress: "bc1qbob",
+		Amount:             "0.5",
+		ChainCode:          "BTC",
+		TokenCode:          "BTC",
+		Memo:               "coffee & cake",
+		OfflineUrl:         "https://example.com/invoice/7",
+	}
+	p, err := NewPaymentUri("bob@fiotestnet", content, "9")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := ParsePaymentUri(p.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *parsed != *p {
+		t.Errorf("round trip changed the uri %+v %+v", parsed, p)
+	}
+	if got := parsed.ObtRequestContent("bc1qbob"); got != content {
+		t.Errorf("unexpected content %+v", got)
+	}
+}
+
+func TestOpenPaymentUri(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := pubAddressRequest{}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if req.ChainCode != "ETH" || req.TokenCode != "USDT" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"type":"invalid_input","message":"Public address not found"}`))
+			return
+		}
+		_, _ = fmt.Fprintf(w, `{"public_address":"0xb0b"}`)
+	}))
+	defer srv.Close()
+	api := &API{API: eos.New(srv.URL)}
+
+	p, content, err := api.OpenPaymentUri("fio:bob@fiotestnet?chain=ETH&token=USDT&amount=25")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.RequestId != "" || content.PayeePublicAddress != "0xb0b" || content.Amount != "25" || content.TokenCode != "USDT" {
+		t.Errorf("unexpected content %+v", content)
+	}
+	if _, _, err = api.OpenPaymentUri("fio:bob@fiotestnet?chain=BTC&amount=1"); err == nil {
+		t.Error("expected error when the payee has no address for the chain")
+	}
+}
@@ -0,0 +1,236 @@
+package fio
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/fioprotocol/fio-go/eos"
+	"github.com/fioprotocol/fio-go/eos/ecc"
+	"sort"
+)
+
+// NewKeyAuthority is the most common authority: a single key with a threshold of 1
+func NewKeyAuthority(pubKey string) (Authority, error) {
+	kw, err := NewKeyWeight(pubKey, 1)
+	if err != nil {
+		return Authority{}, err
+	}
+	return Authority{Threshold: 1, Keys: []KeyWeight{kw}}, nil
+}
+
+// NewKeyWeight parses a public key, which may use the FIO or EOS prefix
+func NewKeyWeight(pubKey string, weight uint16) (KeyWeight, error) {
+	pub, err := ecc.NewPublicKey(normalizeKey(pubKey))
+	if err != nil {
+		return KeyWeight{}, fmt.Errorf("invalid public key %q: %s", pubKey, err)
+	}
+	return KeyWeight{PublicKey: pub, Weight: weight}, nil
+}
+
+// Sort puts the keys, accounts and waits in the order required by the chain, an unsorted authority is rejected
+// with an invalid authority error.
+func (a *Authority) Sort() {
+	sort.SliceStable(a.Keys, func(i, j int) bool {
+		return keyLess(a.Keys[i].PublicKey, a.Keys[j].PublicKey)
+	})
+	sort.SliceStable(a.Accounts, func(i, j int) bool {
+		return levelLess(a.Accounts[i].Permission, a.Accounts[j].Permission)
+	})
+	sort.SliceStable(a.Waits, func(i, j int) bool {
+		return a.Waits[i].WaitSec < a.Waits[j].WaitSec
+	})
+}
+
+// Validate checks an authority using the same rules as the chain: entries must be sorted and unique, weights
+// can't be zero, and the threshold must be reachable.
+func (a Authority) Validate() error {
+	if a.Threshold == 0 {
+		return errors.New("threshold must be greater than zero")
+	}
+	var total uint64
+	for i, k := range a.Keys {
+		if k.Weight == 0 {
+			return fmt.Errorf("key %s has a weight of zero", k.PublicKey.String())
+		}
+		if i > 0 && !keyLess(a.Keys[i-1].PublicKey, k.PublicKey) {
+			return errors.New("keys must be sorted and unique, see Authority.Sort")
+		}
+		total += uint64(k.Weight)
+	}
+	for i, acc := range a.Accounts {
+		if acc.Weight == 0 {
+			return fmt.Errorf("account %s@%s has a weight of zero", acc.Permission.Actor, acc.Permission.Permission)
+		}
+		if !validName(string(acc.Permission.Actor)) || !validName(string(acc.Permission.Permission)) {
+			return fmt.Errorf("invalid permission %s@%s", acc.Permission.Actor, acc.Permission.Permission)
+		}
+		if i > 0 && !levelLess(a.Accounts[i-1].Permission, acc.Permission) {
+			return errors.New("accounts must be sorted and unique, see Authority.Sort")
+		}
+		total += uint64(acc.Weight)
+	}
+	for i, w := range a.Waits {
+		if w.Weight == 0 || w.WaitSec == 0 {
+			return errors.New("waits must have a non-zero weight and duration")
+		}
+		if i > 0 && a.Waits[i-1].WaitSec >= w.WaitSec {
+			return errors.New("waits must be sorted and unique, see Authority.Sort")
+		}
+		total += uint64(w.Weight)
+	}
+	if total < uint64(a.Threshold) {
+		return fmt.Errorf("threshold of %d can never be met, total weight is %d", a.Threshold, total)
+	}
+	return nil
+}
+
+// keyLess orders public keys by their binary encoding
+func keyLess(a ecc.PublicKey, b ecc.PublicKey) bool {
+	if a.Curve != b.Curve {
+		return a.Curve < b.Curve
+	}
+	return bytes.Compare(a.Content, b.Content) < 0
+}
+
+// levelLess orders permission levels by the numeric value of the names
+func levelLess(a eos.PermissionLevel, b eos.PermissionLevel) bool {
+	aActor, _ := eos.StringToName(string(a.Actor))
+	bActor, _ := eos.StringToName(string(b.Actor))
+	if aActor != bActor {
+		return aActor < bActor
+	}
+	aPerm, _ := eos.StringToName(string(a.Permission))
+	bPerm, _ := eos.StringToName(string(b.Permission))
+	return aPerm < bPerm
+}
+
+// NewUpdateAuth creates or replaces a permission. The authority is sorted, and the action is authorized with
+// actor@owner when changing owner, otherwise actor@active.
+func NewUpdateAuth(actor eos.AccountName, permission eos.PermissionName, parent eos.PermissionName, auth Authority) *Action {
+	auth.Keys = append([]KeyWeight{}, auth.Keys...)
+	auth.Accounts = append([]eos.PermissionLevelWeight{}, auth.Accounts...)
+	auth.Waits = append([]eos.WaitWeight{}, auth.Waits...)
+	auth.Sort()
+	signer := "active"
+	if permission == "owner" {
+		signer = "owner"
+	}
+	return NewActionWithPermission("eosio", "updateauth", actor, signer, UpdateAuth{
+		Account:    actor,
+		Permission: eos.Name(permission),
+		Parent:     eos.Name(parent),
+		Auth:       auth,
+		MaxFee:     Tokens(GetMaxFee(FeeAuthUpdate)),
+	})
+}
+
+// NewValidUpdateAuth is the same as NewUpdateAuth, but returns an error if the authority is invalid. The owner
+// permission must not have a parent, and every other permission must have one.
+func NewValidUpdateAuth(actor eos.AccountName, permission eos.PermissionName, parent eos.PermissionName, auth Authority) (*Action, error) {
+	if !validName(string(actor)) {
+		return nil, fmt.Errorf("invalid account %q", actor)
+	}
+	if !validName(string(permission)) {
+		return nil, fmt.Errorf("invalid permission %q", permission)
+	}
+	if (permission == "owner") != (parent == "") {
+		return nil, errors.New("only the owner permission has no parent")
+	}
+	act := NewUpdateAuth(actor, permission, parent, auth)
+	if err := act.Data.(UpdateAuth).Auth.Validate(); err != nil {
+		return nil, err
+	}
+	return act, nil
+}
+
+// DeleteAuth removes a permission, any links to it must be removed first with UnlinkAuth
+type DeleteAuth struct {
+	Account    eos.AccountName    `json:"account"`
+	Permission eos.PermissionName `json:"permission"`
+	MaxFee     uint64             `json:"max_fee"`
+}
+
+func NewDeleteAuth(actor eos.AccountName, permission eos.PermissionName) *Action {
+	return NewAction("eosio", "deleteauth", actor, DeleteAuth{
+		Account:    actor,
+		Permission: permission,
+		MaxFee:     Tokens(GetMaxFee(FeeAuthDelete)),
+	})
+}
+
+// LinkAuth allows a permission to authorize a contract action, so that active is not needed
+type LinkAuth struct {
+	Account     eos.AccountName    `json:"account"`
+	Code        eos.AccountName    `json:"code"`
+	Type        eos.ActionName     `json:"type"`
+	Requirement eos.PermissionName `json:"requirement"`
+	MaxFee      uint64             `json:"max_fee"`
+}
+
+func NewLinkAuth(actor eos.AccountName, code eos.AccountName, action eos.ActionName, requirement eos.PermissionName) *Action {
+	return NewAction("eosio", "linkauth", actor, LinkAuth{
+		Account:     actor,
+		Code:        code,
+		Type:        action,
+		Requirement: requirement,
+		MaxFee:      Tokens(GetMaxFee(FeeAuthLink)),
+	})
+}
+
+// UnlinkAuth removes a link created with LinkAuth, it does not have a fee
+type UnlinkAuth struct {
+	Account eos.AccountName `json:"account"`
+	Code    eos.AccountName `json:"code"`
+	Type    eos.ActionName  `json:"type"`
+}
+
+func NewUnlinkAuth(actor eos.AccountName, code eos.AccountName, action eos.ActionName) *Action {
+	return NewAction("eosio", "unlinkauth", actor, UnlinkAuth{
+		Account: actor,
+		Code:    code,
+		Type:    action,
+	})
+}
+
+// CheckRotation verifies that replacing a permission's authority will not lock the account: the new authority
+// must be valid, and heldKeys (public keys the caller controls) must satisfy it, including through any accounts it
+// delegates to. The returned action performs the change.
+func (api *API) CheckRotation(actor eos.AccountName, permission eos.PermissionName, auth Authority, heldKeys []string) (*Action, error) {
+	resolver := NewAuthorityResolver(api)
+	current, err := resolver.Permission(eos.PermissionLevel{Actor: actor, Permission: permission})
+	if err != nil {
+		return nil, err
+	}
+	act, err := NewValidUpdateAuth(actor, permission, eos.PermissionName(current.Parent), auth)
+	if err != nil {
+		return nil, err
+	}
+
+	// evaluate against the account's permissions with the change applied
+	perms := append([]Permission{}, resolver.accounts[actor]...)
+	for i := range perms {
+		if perms[i].PermName == string(permission) {
+			perms[i].RequiredAuth = act.Data.(UpdateAuth).Auth
+		}
+	}
+	resolver.AddPermissions(actor, perms)
+	result, err := resolver.Check(eos.PermissionLevel{Actor: actor, Permission: permission}, heldKeys, nil)
+	if err != nil {
+		return nil, err
+	}
+	if !result.Satisfied {
+		return nil, fmt.Errorf("the held keys only provide a weight of %d, the new %s@%s authority needs %d",
+			result.Weight, actor, permission, result.Threshold)
+	}
+	return act, nil
+}
+
+// RotateKeys replaces a permission's authority after checking it with CheckRotation, the transaction is signed
+// with the API's signer, which must satisfy the current permission.
+func (api *API) RotateKeys(actor eos.AccountName, permission eos.PermissionName, auth Authority, heldKeys []string) (*eos.PushTransactionFullResp, error) {
+	act, err := api.CheckRotation(actor, permission, auth, heldKeys)
+	if err != nil {
+		return nil, err
+	}
+	return api.SignPushActions(act)
+}
@@ -0,0 +1,144 @@
+package fio
+
+import (
+	"encoding/json"
+	"github.com/fioprotocol/fio-go/eos"
+	"github.com/fioprotocol/fio-go/eos/ecc"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewUpdateAuth(t *testing.T) {
+	keys := make([]ecc.PublicKey, 3)
+	for i := range keys {
+		priv, err := ecc.NewRandomPrivateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys[i] = priv.PublicKey()
+	}
+	level := func(actor string) eos.PermissionLevel {
+		return eos.PermissionLevel{Actor: eos.AccountName(actor), Permission: "active"}
+	}
+	auth := Authority{
+		Threshold: 3,
+		Keys:      []KeyWeight{{PublicKey: keys[0], Weight: 1}, {PublicKey: keys[1], Weight: 1}, {PublicKey: keys[2], Weight: 1}},
+		Accounts:  []eos.PermissionLevelWeight{{Permission: level("bob"), Weight: 1}, {Permission: level("alice"), Weight: 1}},
+		Waits:     []eos.WaitWeight{{WaitSec: 7200, Weight: 1}, {WaitSec: 3600, Weight: 1}},
+	}
+	act, err := NewValidUpdateAuth("multisig", "claim", "active", auth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ua := act.Data.(UpdateAuth)
+	if ua.Auth.Accounts[0].Permission.Actor != "alice" || ua.Auth.Waits[0].WaitSec != 3600 {
+		t.Error("authority was not sorted", ua.Auth)
+	}
+	for i := 1; i < len(ua.Auth.Keys); i++ {
+		if !keyLess(ua.Auth.Keys[i-1].PublicKey, ua.Auth.Keys[i].PublicKey) {
+			t.Error("keys were not sorted")
+		}
+	}
+	if auth.Accounts[0].Permission.Actor != "bob" {
+		t.Error("caller's authority should not be modified")
+	}
+	if ua.MaxFee != Tokens(GetMaxFee(FeeAuthUpdate)) || act.Authorization[0].Permission != "active" || ua.Parent != "active" {
+		t.Errorf("unexpected action %+v", ua)
+	}
+
+	owner, err := NewKeyAuthority(keys[0].String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	act, err = NewValidUpdateAuth("multisig", "owner", "", owner)
+	if err != nil || act.Authorization[0].Permission != "owner" {
+		t.Error("owner should authorize a change to owner", err)
+	}
+
+	bad := []struct {
+		name       string
+		permission eos.PermissionName
+		parent     eos.PermissionName
+		auth       Authority
+	}{
+		{"zero threshold", "active", "owner", Authority{Threshold: 0, Keys: owner.Keys}},
+		{"unreachable threshold", "active", "owner", Authority{Threshold: 2, Keys: owner.Keys}},
+		{"zero weight", "active", "owner", Authority{Threshold: 1, Keys: []KeyWeight{{PublicKey: keys[0]}}}},
+		{"duplicate key", "active", "owner", Authority{Threshold: 1, Keys: append(owner.Keys, owner.Keys...)}},
+		{"duplicate account", "active", "owner", Authority{Threshold: 1, Accounts: []eos.PermissionLevelWeight{
+			{Permission: level("bob"), Weight: 1}, {Permission: level("bob"), Weight: 1}}}},
+		{"invalid account", "active", "owner", Authority{Threshold: 1, Accounts: []eos.PermissionLevelWeight{
+			{Permission: level("Bob"), Weight: 1}}}},
+		{"owner with parent", "owner", "active", owner},
+		{"missing parent", "active", "", owner},
+		{"invalid permission", "Active", "owner", owner},
+	}
+	for _, b := range bad {
+		if _, err = NewValidUpdateAuth("multisig", b.permission, b.parent, b.auth); err == nil {
+			t.Error("expected error for", b.name)
+		}
+	}
+
+	if _, err = NewKeyAuthority("FIOnotakey"); err == nil {
+		t.Error("expected error for invalid key")
+	}
+	if da := NewDeleteAuth("multisig", "claim").Data.(DeleteAuth); da.MaxFee != Tokens(GetMaxFee(FeeAuthDelete)) {
+		t.Error("unexpected deleteauth fee", da.MaxFee)
+	}
+	if la := NewLinkAuth("multisig", "eosio", "bpclaim", "claim").Data.(LinkAuth); la.MaxFee != Tokens(GetMaxFee(FeeAuthLink)) || la.Requirement != "claim" {
+		t.Errorf("unexpected linkauth %+v", la)
+	}
+	if ul := NewUnlinkAuth("multisig", "eosio", "bpclaim"); ul.Name != "unlinkauth" {
+		t.Error("unexpected unlinkauth", ul.Name)
+	}
+}
+
+func TestCheckRotation(t *testing.T) {
+	keys := make(map[string]string)
+	for _, name := range []string{"owner", "active", "new"} {
+		priv, err := ecc.NewRandomPrivateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys[name] = priv.PublicKey().String()
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"account_name": "alice",
+			"permissions": []map[string]interface{}{
+				{"perm_name": "owner", "parent": "", "required_auth": map[string]interface{}{
+					"threshold": 1, "keys": []map[string]interface{}{{"key": keys["owner"], "weight": 1}}}},
+				{"perm_name": "active", "parent": "owner", "required_auth": map[string]interface{}{
+					"threshold": 1, "keys": []map[string]interface{}{{"key": keys["active"], "weight": 1}}}},
+			},
+		})
+	}))
+	defer srv.Close()
+	api := &API{API: eos.New(srv.URL)}
+
+	auth, err := NewKeyAuthority(keys["new"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	act, err := api.CheckRotation("alice", "active", auth, []string{keys["new"]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ua := act.Data.(UpdateAuth); ua.Parent != "owner" || ua.Auth.Keys[0].PublicKey.String() != keys["new"] {
+		t.Errorf("unexpected updateauth %+v", ua)
+	}
+	if _, err = api.CheckRotation("alice", "active", auth, []string{keys["active"]}); err == nil {
+		t.Error("should not allow rotating to a key that is not held")
+	}
+
+	// delegating active to owner is satisfied through the owner key
+	delegated := Authority{Threshold: 1, Accounts: []eos.PermissionLevelWeight{
+		{Permission: eos.PermissionLevel{Actor: "alice", Permission: "owner"}, Weight: 1}}}
+	if _, err = api.CheckRotation("alice", "active", delegated, []string{keys["owner"]}); err != nil {
+		t.Error(err)
+	}
+	if _, err = api.CheckRotation("alice", "claim", auth, []string{keys["new"]}); err == nil {
+		t.Error("expected error for a missing permission")
+	}
+}
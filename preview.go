@@ -0,0 +1,317 @@
+package fio
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// previewBundleField maps actions that can use a bundled transaction to the field holding the FIO address that
+// the bundle is deducted from.
+var previewBundleField = map[string]string{
+	"addaddress":  "fio_address",
+	"remaddress":  "fio_address",
+	"remalladdr":  "fio_address",
+	"addnft":      "fio_address",
+	"remnft":      "fio_address",
+	"remallnfts":  "fio_address",
+	"newfundsreq": "payee_fio_address",
+	"recordobt":   "payer_fio_address",
+}
+
+// PreviewItem is the expected outcome of a single action
+type PreviewItem struct {
+	Action        *Action
+	Endpoint      string   // fee endpoint for the action, empty if unknown
+	Fee           uint64   // expected fee in SUFs, zero if a bundled transaction is used
+	MaxFee        uint64   // max_fee supplied in the action
+	Bundled       bool     // a bundled transaction will be used instead of paying a fee
+	BundleAddress string   // the FIO address that the bundled transaction is deducted from
+	Amount        uint64   // tokens transferred by the action, in SUFs
+	Effects       []string // human-readable description of what the action does
+	Problems      []string // reasons the action is expected to fail
+}
+
+// Preview summarizes the cost and effects of a set of actions, see API.Preview
+type Preview struct {
+	Items       []*PreviewItem
+	TotalFees   uint64         // total of all fees, in SUFs
+	TotalAmount uint64         // total of all token transfers, in SUFs
+	BundlesUsed map[string]int // bundled transactions used, by FIO address
+}
+
+// Ok is true if no problems were found
+func (p *Preview) Ok() bool {
+	for _, item := range p.Items {
+		if len(item.Problems) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// String provides a human-readable summary of the preview
+func (p *Preview) String() string {
+	buf := bytes.NewBufferString("")
+	for i, item := range p.Items {
+		buf.WriteString(fmt.Sprintf("%d. %s::%s", i+1, item.Action.Account, item.Action.Name))
+		switch {
+		case item.Bundled:
+			buf.WriteString(fmt.Sprintf(" (1 bundled transaction from %s)\n", item.BundleAddress))
+		case item.Endpoint == "":
+			buf.WriteString(" (fee unknown)\n")
+		default:
+			buf.WriteString(fmt.Sprintf(" (fee %s, max %s)\n", simFio(item.Fee), simFio(item.MaxFee)))
+		}
+		for _, e := range item.Effects {
+			buf.WriteString("   - " + e + "\n")
+		}
+		for _, problem := range item.Problems {
+			buf.WriteString("   ! " + problem + "\n")
+		}
+	}
+	buf.WriteString(fmt.Sprintf("Total fees: %s\n", simFio(p.TotalFees)))
+	if p.TotalAmount > 0 {
+		buf.WriteString(fmt.Sprintf("Total transferred: %s\n", simFio(p.TotalAmount)))
+	}
+	addresses := make([]string, 0, len(p.BundlesUsed))
+	for address := range p.BundlesUsed {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+	for _, address := range addresses {
+		buf.WriteString(fmt.Sprintf("Bundled transactions used by %s: %d\n", address, p.BundlesUsed[address]))
+	}
+	if p.Ok() {
+		buf.WriteString("No problems found\n")
+	} else {
+		buf.WriteString("PROBLEMS FOUND, transaction is expected to fail\n")
+	}
+	return buf.String()
+}
+
+// Preview validates each action locally, resolves the fees and bundled transaction usage, and checks availability
+// for registrations, without signing or pushing anything. Validation failures are reported as Problems in the
+// result, the returned error is only set when a query to the API fails.
+func (api *API) Preview(actions ...*Action) (*Preview, error) {
+	p := &Preview{
+		Items:       make([]*PreviewItem, 0, len(actions)),
+		BundlesUsed: make(map[string]int),
+	}
+	bundles := make(map[string]int)
+	fees := make(map[string]uint64)
+	getFee := func(address string, endpoint string) (uint64, error) {
+		key := address + "/" + endpoint
+		if fee, seen := fees[key]; seen {
+			return fee, nil
+		}
+		fee, err := api.GetFee(address, endpoint)
+		if err != nil {
+			return 0, fmt.Errorf("getting fee for %s: %s", endpoint, err)
+		}
+		fees[key] = fee
+		return fee, nil
+	}
+	for _, act := range actions {
+		if act == nil {
+			continue
+		}
+		maxFeeActionMutex.RLock()
+		endpoint := maxFeesByAction[string(act.Name)]
+		maxFeeActionMutex.RUnlock()
+		item := &PreviewItem{
+			Action:   act,
+			Endpoint: endpoint,
+			Effects:  make([]string, 0),
+			Problems: make([]string, 0),
+		}
+		p.Items = append(p.Items, item)
+
+		fields := make(map[string]interface{})
+		if act.Data == nil {
+			item.Problems = append(item.Problems, "action data is not available, it cannot be previewed")
+			continue
+		}
+		if j, err := json.Marshal(act.Data); err == nil {
+			dec := json.NewDecoder(bytes.NewReader(j))
+			dec.UseNumber()
+			_ = dec.Decode(&fields)
+		}
+		item.MaxFee = previewUint(fields["max_fee"])
+		item.Amount = previewUint(fields["amount"])
+		for _, name := range []string{"fio_address", "payer_fio_address", "payee_fio_address"} {
+			if s, ok := fields[name].(string); ok && s != "" && !Address(s).Valid() {
+				item.Problems = append(item.Problems, fmt.Sprintf("%s %q is not a valid FIO address", name, s))
+			}
+		}
+
+		if err := api.previewAction(act, item); err != nil {
+			return nil, err
+		}
+
+		// bundled transactions, falling back to the fee if none remain
+		feeAddress := ""
+		if field, ok := previewBundleField[string(act.Name)]; ok {
+			if address, _ := fields[field].(string); Address(address).Valid() {
+				feeAddress = address
+				remaining, seen := bundles[address]
+				if !seen {
+					r, err := api.GetBundleRemaining(Address(address))
+					if err != nil {
+						return nil, err
+					}
+					remaining = r
+				}
+				if remaining > 0 {
+					item.Bundled = true
+					item.BundleAddress = address
+					remaining -= 1
+					p.BundlesUsed[address] += 1
+				}
+				bundles[address] = remaining
+			}
+		}
+		if item.Endpoint == "" {
+			item.Problems = append(item.Problems, fmt.Sprintf("no fee is known for action %s", act.Name))
+		} else if !item.Bundled {
+			// the fee schedule is queried rather than using GetMaxFee, which may be stale or the default values. Bundle
+			// eligible fees are queried for the address, which is zero if the chain would still use a bundle, but
+			// earlier actions in the preview have used them.
+			fee, err := getFee(feeAddress, item.Endpoint)
+			if err == nil && fee == 0 && feeAddress != "" {
+				fee, err = getFee("", item.Endpoint)
+			}
+			if err != nil {
+				return nil, err
+			}
+			item.Fee = fee
+			if item.Fee > item.MaxFee && fields["max_fee"] != nil {
+				item.Problems = append(item.Problems, fmt.Sprintf("max_fee of %s is less than the fee of %s",
+					simFio(item.MaxFee), simFio(item.Fee)))
+			}
+		}
+		p.TotalFees += item.Fee
+		p.TotalAmount += item.Amount
+	}
+	return p, nil
+}
+
+// previewAction performs action-specific validation and describes the effects
+func (api *API) previewAction(act *Action, item *PreviewItem) error {
+	problem := func(err error) {
+		item.Problems = append(item.Problems, err.Error())
+	}
+	switch string(act.Account) + "::" + string(act.Name) {
+	case "fio.address::regaddress":
+		ra := RegAddress{}
+		if err := decodeActionData(act, &ra); err != nil {
+			problem(err)
+			return nil
+		}
+		if !Address(ra.FioAddress).Valid() {
+			return nil
+		}
+		if err := api.previewAvailable(ra.FioAddress, item); err != nil {
+			return err
+		}
+		item.Effects = append(item.Effects, fmt.Sprintf("registers %s to %s", ra.FioAddress, ra.OwnerFioPublicKey))
+	case "fio.address::regdomain":
+		rd := RegDomain{}
+		if err := decodeActionData(act, &rd); err != nil {
+			problem(err)
+			return nil
+		}
+		if _, err := ValidateDomain(rd.FioDomain); err != nil {
+			problem(err)
+			return nil
+		}
+		if err := api.previewAvailable(rd.FioDomain, item); err != nil {
+			return err
+		}
+		item.Effects = append(item.Effects, fmt.Sprintf("registers domain %s to %s", rd.FioDomain, rd.OwnerFioPublicKey))
+	case "fio.address::addaddress":
+		aa := AddAddress{}
+		if err := decodeActionData(act, &aa); err != nil {
+			problem(err)
+			return nil
+		}
+		if len(aa.PublicAddresses) == 0 || len(aa.PublicAddresses) > 5 {
+			item.Problems = append(item.Problems, "between 1 and 5 public addresses may be added at a time")
+		}
+		for _, pa := range aa.PublicAddresses {
+			item.Effects = append(item.Effects, fmt.Sprintf("maps %s %s:%s to %s", aa.FioAddress, pa.ChainCode, pa.TokenCode, pa.PublicAddress))
+		}
+	case "fio.address::addnft":
+		an := addNft{}
+		if err := decodeActionData(act, &an); err != nil {
+			problem(err)
+			return nil
+		}
+		if err := an.valid(); err != nil {
+			problem(err)
+		}
+		item.Effects = append(item.Effects, fmt.Sprintf("maps %d NFTs to %s", len(an.Nfts), an.FioAddress))
+	case "fio.address::remnft":
+		rn := RemNft{}
+		if err := decodeActionData(act, &rn); err != nil {
+			problem(err)
+			return nil
+		}
+		if _, err := NewRemNft(rn.FioAddress, rn.Nfts, rn.Actor); err != nil {
+			problem(err)
+		}
+		item.Effects = append(item.Effects, fmt.Sprintf("removes %d NFTs from %s", len(rn.Nfts), rn.FioAddress))
+	case "fio.token::trnsfiopubky":
+		tt := TransferTokensPubKey{}
+		if err := decodeActionData(act, &tt); err != nil {
+			problem(err)
+			return nil
+		}
+		if tt.Amount == 0 {
+			item.Problems = append(item.Problems, "must transfer a positive amount")
+		}
+		if _, err := ActorFromPub(tt.PayeePublicKey); err != nil {
+			problem(fmt.Errorf("invalid payee public key: %s", err.Error()))
+		}
+		item.Effects = append(item.Effects, fmt.Sprintf("transfers %s from %s to %s", simFio(tt.Amount), tt.Actor, tt.PayeePublicKey))
+	case "fio.token::trnsloctoks":
+		tlt := TransferLockedTokens{}
+		if err := decodeActionData(act, &tlt); err != nil {
+			problem(err)
+			return nil
+		}
+		if err := tlt.valid(api); err != nil {
+			problem(err)
+		}
+		item.Effects = append(item.Effects, fmt.Sprintf("transfers %s in %d lock periods from %s to %s",
+			simFio(tlt.Amount), len(tlt.Periods), tlt.Actor, tlt.PayeePublicKey))
+	}
+	return nil
+}
+
+// previewAvailable checks that a name is available for registration
+func (api *API) previewAvailable(name string, item *PreviewItem) error {
+	available, err := api.AvailCheck(name)
+	if err != nil {
+		return err
+	}
+	if !available {
+		item.Problems = append(item.Problems, fmt.Sprintf("%s is already registered", name))
+	}
+	return nil
+}
+
+// previewUint converts a numeric JSON field, which may be encoded as a number or a string
+func previewUint(v interface{}) uint64 {
+	var s string
+	switch n := v.(type) {
+	case json.Number:
+		s = n.String()
+	case string:
+		s = n
+	}
+	u, _ := strconv.ParseUint(s, 10, 64)
+	return u
+}
@@ -0,0 +1,113 @@
+package fio
+
+import (
+	"encoding/json"
+	"github.com/fioprotocol/fio-go/eos"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAPI_Preview(t *testing.T) {
+	feeAddresses := make(map[string]bool)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/chain/avail_check":
+			req := AvailCheckReq{}
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			if req.FioName == "taken@preview" {
+				_, _ = w.Write([]byte(`{"is_registered":1}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"is_registered":0}`))
+		case "/v1/chain/get_table_rows":
+			_, _ = w.Write([]byte(`{"rows":[{"bundleeligiblecountdown":1}],"more":false}`))
+		case "/v1/chain/get_fee":
+			req := GetFeeRequest{}
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			feeAddresses[req.FioAddress] = true
+			switch {
+			case req.EndPoint == FeeRegisterFioAddress:
+				_, _ = w.Write([]byte(`{"fee":20000000000}`))
+			case req.FioAddress == "new@preview":
+				// the chain still has a bundle for the address
+				_, _ = w.Write([]byte(`{"fee":0}`))
+			default:
+				_, _ = w.Write([]byte(`{"fee":100000000}`))
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	api := &API{API: eos.New(srv.URL)}
+
+	acc, err := NewRandomAccount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	reg, _ := NewRegAddress(acc.Actor, "new@preview", acc.PubKey)
+	taken, _ := NewRegAddress(acc.Actor, "taken@preview", acc.PubKey)
+	add1, _ := NewAddAddress(acc.Actor, "new@preview", "BTC", "BTC", "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4")
+	add2, _ := NewAddAddress(acc.Actor, "new@preview", "ETH", "ETH", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed")
+	lowFee := NewTransferTokensPubKey(acc.Actor, acc.PubKey, Tokens(5))
+	lowFee.Data = TransferTokensPubKey{PayeePublicKey: acc.PubKey, Amount: Tokens(5), MaxFee: 1, Actor: acc.Actor}
+	nft := &Action{Account: "fio.address", Name: "addnft", ActionData: eos.NewActionData(&addNft{
+		FioAddress: "new@preview",
+		Nfts:       []nftEncoded{{ChainCode: "ETH-", ContractAddress: "0x1"}},
+		MaxFee:     Tokens(GetMaxFee(FeeAddNft)),
+		Actor:      acc.Actor,
+	})}
+
+	p, err := api.Preview(reg, add1, add2, taken, lowFee, nft)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Ok() {
+		t.Error("expected problems")
+	}
+	if len(p.Items[0].Problems) != 0 || p.Items[0].Fee != Tokens(20) {
+		t.Error("registration should be valid, using the fee from get_fee", p.Items[0])
+	}
+	if !p.Items[1].Bundled || p.Items[2].Bundled || p.Items[2].Fee != 100000000 || p.BundlesUsed["new@preview"] != 1 {
+		t.Error("only one bundled transaction should be available", p.Items[2].Fee)
+	}
+	if !feeAddresses["new@preview"] {
+		t.Error("the fee for a bundle eligible action should be queried with the address")
+	}
+	if len(p.Items[3].Problems) != 1 || !strings.Contains(p.Items[3].Problems[0], "already registered") {
+		t.Error("expected registered address to be a problem", p.Items[3].Problems)
+	}
+	if len(p.Items[4].Problems) != 1 || p.Items[4].Amount != Tokens(5) {
+		t.Error("expected max_fee problem", p.Items[4].Problems)
+	}
+	if len(p.Items[5].Problems) != 1 {
+		t.Error("expected nft chain code problem", p.Items[5].Problems)
+	}
+	if !strings.Contains(p.String(), "PROBLEMS FOUND") {
+		t.Error("summary should report problems")
+	}
+}
+
+func TestAPI_Preview_FeeError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/chain/get_fee":
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"type":"invalid_input","message":"An invalid request was sent in."}`))
+		default:
+			_, _ = w.Write([]byte(`{"is_registered":0}`))
+		}
+	}))
+	defer srv.Close()
+	api := &API{API: eos.New(srv.URL)}
+
+	acc, err := NewRandomAccount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = api.Preview(NewTransferTokensPubKey(acc.Actor, acc.PubKey, Tokens(5))); err == nil || !strings.Contains(err.Error(), "400") {
+		t.Error("expected the get_fee error to be returned", err)
+	}
+}
@@ -38,6 +38,25 @@ func NewVoteProducer(producers []string, actor eos.AccountName, fioAddress strin
 	)
 }
 
+// NewValidVoteProducer is the same as NewVoteProducer, but returns a *ValidationError for an invalid producer or
+// voter FIO address. fioAddress may be empty.
+func NewValidVoteProducer(producers []string, actor eos.AccountName, fioAddress string) (*Action, error) {
+	if len(producers) > 30 {
+		return nil, errors.New("no more than 30 producers may be voted for")
+	}
+	for _, p := range producers {
+		if err := validateAddressField("producers", p); err != nil {
+			return nil, err
+		}
+	}
+	if fioAddress != "" {
+		if _, err := ValidateAddress(fioAddress); err != nil {
+			return nil, err
+		}
+	}
+	return NewVoteProducer(producers, actor, fioAddress), nil
+}
+
 // BpClaim requests payout for a block producer
 type BpClaim struct {
 	FioAddress string          `json:"fio_address"`
@@ -54,6 +73,14 @@ func NewBpClaim(fioAddress string, actor eos.AccountName) *Action {
 	)
 }
 
+// NewValidBpClaim is the same as NewBpClaim, but returns a *ValidationError for an invalid FIO address
+func NewValidBpClaim(fioAddress string, actor eos.AccountName) (*Action, error) {
+	if _, err := ValidateAddress(fioAddress); err != nil {
+		return nil, err
+	}
+	return NewBpClaim(fioAddress, actor), nil
+}
+
 // ProducerLocation valid values are 10-80 in increments of 10
 type ProducerLocation uint16
 
@@ -95,6 +122,18 @@ func NewRegProducer(fioAddress string, fioPubKey string, url string, location Pr
 		}), nil
 }
 
+// NewValidRegProducer is the same as NewRegProducer, but returns a *ValidationError for an invalid FIO address or
+// public key
+func NewValidRegProducer(fioAddress string, fioPubKey string, url string, location ProducerLocation, actor eos.AccountName) (*Action, error) {
+	if _, err := ValidateAddress(fioAddress); err != nil {
+		return nil, err
+	}
+	if err := validatePubKey("fio_pub_key", fioPubKey); err != nil {
+		return nil, err
+	}
+	return NewRegProducer(fioAddress, fioPubKey, url, location, actor)
+}
+
 func MustNewRegProducer(fioAddress string, fioPubKey string, url string, location ProducerLocation, actor eos.AccountName) *Action {
 	p, err := NewRegProducer(fioAddress, fioPubKey, url, location, actor)
 	if err != nil {
@@ -118,6 +157,14 @@ func NewUnRegProducer(fioAddress string, actor eos.AccountName) *Action {
 	})
 }
 
+// NewValidUnRegProducer is the same as NewUnRegProducer, but returns a *ValidationError for an invalid FIO address
+func NewValidUnRegProducer(fioAddress string, actor eos.AccountName) (*Action, error) {
+	if _, err := ValidateAddress(fioAddress); err != nil {
+		return nil, err
+	}
+	return NewUnRegProducer(fioAddress, actor), nil
+}
+
 type VoteProxy struct {
 	Proxy      string          `json:"proxy"`
 	FioAddress string          `json:"fio_address,omitempty"`
@@ -137,6 +184,20 @@ func NewVoteProxy(proxy string, fioAddress string, actor eos.AccountName) *Actio
 	)
 }
 
+// NewValidVoteProxy is the same as NewVoteProxy, but returns a *ValidationError for an invalid proxy or voter FIO
+// address. fioAddress may be empty.
+func NewValidVoteProxy(proxy string, fioAddress string, actor eos.AccountName) (*Action, error) {
+	if err := validateAddressField("proxy", proxy); err != nil {
+		return nil, err
+	}
+	if fioAddress != "" {
+		if _, err := ValidateAddress(fioAddress); err != nil {
+			return nil, err
+		}
+	}
+	return NewVoteProxy(proxy, fioAddress, actor), nil
+}
+
 type RegProxy struct {
 	FioAddress string          `json:"fio_address"`
 	Actor      eos.AccountName `json:"actor"`
@@ -153,6 +214,14 @@ func NewRegProxy(fioAddress string, actor eos.AccountName) *Action {
 	)
 }
 
+// NewValidRegProxy is the same as NewRegProxy, but returns a *ValidationError for an invalid FIO address
+func NewValidRegProxy(fioAddress string, actor eos.AccountName) (*Action, error) {
+	if _, err := ValidateAddress(fioAddress); err != nil {
+		return nil, err
+	}
+	return NewRegProxy(fioAddress, actor), nil
+}
+
 type ProducerKey struct {
 	AccountName     eos.AccountName `json:"producer_name"`
 	BlockSigningKey ecc.PublicKey   `json:"block_signing_key"`
@@ -295,28 +364,18 @@ func (api *API) GetBpJson(producer eos.AccountName) (*BpJson, error) {
 
 // allows override of private ip check for tests
 func (api *API) getBpJson(producer eos.AccountName, allowIp bool) (*BpJson, error) {
-	gtr, err := api.GetTableRows(eos.GetTableRowsRequest{
-		Code:       "eosio",
-		Scope:      "eosio",
-		Table:      "producers",
-		LowerBound: string(producer),
-		UpperBound: string(producer),
-		KeyType:    "name",
-		Index:      "4",
-		JSON:       true,
-	})
+	bp := &Producer{}
+	found, err := NewTableQuery(api, "eosio", "producers").Index("owner").Equal(producer).First(bp)
 	if err != nil {
 		return nil, err
 	}
-	producerRows := make([]Producer, 0)
-	err = json.Unmarshal(gtr.Rows, &producerRows)
-	if len(producerRows) != 1 {
+	if !found {
 		return nil, errors.New("account not found in producers table")
 	}
-	if !strings.HasPrefix(producerRows[0].Url, "http") {
-		producerRows[0].Url = "https://" + producerRows[0].Url
+	if !strings.HasPrefix(bp.Url, "http") {
+		bp.Url = "https://" + bp.Url
 	}
-	u, err := url.Parse(producerRows[0].Url)
+	u, err := url.Parse(bp.Url)
 	if err != nil {
 		return nil, err
 	}
@@ -461,54 +520,23 @@ type prodRow struct {
 
 // GetVotes returns a slice of an account's current votes
 func (api *API) GetVotes(account string) (votedFor []string, err error) {
-	getVote, err := api.GetTableRows(eos.GetTableRowsRequest{
-		Code:  "eosio",
-		Scope: "eosio",
-		Table: "voters",
-
-		Index:      "3",
-		LowerBound: account,
-		UpperBound: account,
-		Limit:      1,
-		KeyType:    "name",
-		JSON:       true,
-	})
-	if err != nil {
-		return
-	}
-	v := make([]*existVotes, 0)
-	err = json.Unmarshal(getVote.Rows, &v)
-	if err != nil {
-		return
-	}
-	if len(v) == 0 {
+	v := &existVotes{}
+	found, err := NewTableQuery(api, "eosio", "voters").Index("owner").Equal(account).First(v)
+	if err != nil || !found {
 		return
 	}
 	votedFor = make([]string, 0)
-	for _, row := range v[0].Producers {
+	for _, row := range v.Producers {
 		if row == "" {
 			continue
 		}
-		gtr, err := api.GetTableRows(eos.GetTableRowsRequest{
-			Code:       "eosio",
-			Scope:      "eosio",
-			Table:      "producers",
-			LowerBound: row,
-			UpperBound: row,
-			KeyType:    "name",
-			Index:      "4",
-			JSON:       true,
-		})
-		if err != nil {
-			continue
-		}
-		p := make([]*prodRow, 0)
-		err = json.Unmarshal(gtr.Rows, &p)
-		if err != nil {
+		p := &prodRow{}
+		found, err := NewTableQuery(api, "eosio", "producers").Index("owner").Equal(row).First(p)
+		if err != nil || !found {
 			continue
 		}
-		if len(p) == 1 && p[0].FioAddress != "" {
-			votedFor = append(votedFor, p[0].FioAddress)
+		if p.FioAddress != "" {
+			votedFor = append(votedFor, p.FioAddress)
 		}
 	}
 	return
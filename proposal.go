@@ -0,0 +1,231 @@
+package fio
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/fioprotocol/fio-go/eos"
+	"time"
+)
+
+// ProposalAction is an action from a proposed transaction, with the data decoded using the contract's ABI
+type ProposalAction struct {
+	Account       eos.AccountName       `json:"account"`
+	Name          eos.ActionName        `json:"name"`
+	Authorization []eos.PermissionLevel `json:"authorization"`
+	HexData       eos.HexBytes          `json:"hex_data"`
+	Data          json.RawMessage       `json:"data,omitempty"`
+	DecodeError   string                `json:"decode_error,omitempty"` // set if the ABI could not be used to decode the data
+}
+
+// ProposalAuth is the approval status of a permission that the proposed transaction requires
+type ProposalAuth struct {
+	Level     eos.PermissionLevel
+	Threshold uint32
+	Weight    uint32                // weight counted towards the threshold from the approvals provided
+	Satisfied bool                  // the threshold has been met, or the permission itself approved the proposal
+	Missing   []eos.PermissionLevel // accounts in the authority that have not approved
+}
+
+// Proposal ties together a multisig proposal's transaction and approvals, and builds the actions used to approve,
+// unapprove, execute or cancel it. Use API.GetProposal to load one.
+type Proposal struct {
+	Proposer    eos.AccountName
+	Name        eos.Name
+	Hash        eos.Checksum256
+	Transaction *eos.Transaction
+	Actions     []*ProposalAction
+	Requested   []MsigApproval // approvals that have been requested, but not yet provided
+	Provided    []MsigApproval
+
+	api      *API
+	resolver *AuthorityResolver
+}
+
+// approvals2Row is used to read a single proposal from the approvals2 table
+type approvals2Row struct {
+	ProposalName       eos.Name       `json:"proposal_name"`
+	RequestedApprovals []MsigApproval `json:"requested_approvals"`
+	ProvidedApprovals  []MsigApproval `json:"provided_approvals"`
+}
+
+// GetProposal loads a multisig proposal, its approvals, and decodes the actions in the proposed transaction.
+// An action that can't be decoded does not cause an error, the reason is recorded in ProposalAction.DecodeError.
+func (api *API) GetProposal(proposer eos.AccountName, name eos.Name) (*Proposal, error) {
+	row := &msigProposalRow{}
+	found, err := NewTableQuery(api, "eosio.msig", "proposal").Scope(string(proposer)).Equal(name).First(row)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("proposal %s by %s was not found", name, proposer)
+	}
+	txBytes, err := hex.DecodeString(row.PackedTransaction)
+	if err != nil {
+		return nil, err
+	}
+	tx := &eos.Transaction{}
+	if err = eos.NewDecoder(txBytes).Decode(tx); err != nil {
+		return nil, err
+	}
+
+	approvals := &approvals2Row{}
+	found, err = NewTableQuery(api, "eosio.msig", "approvals2").Scope(string(proposer)).Equal(name).First(approvals)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("approvals for proposal %s by %s were not found", name, proposer)
+	}
+
+	sum := sha256.Sum256(txBytes)
+	p := &Proposal{
+		Proposer:    proposer,
+		Name:        name,
+		Hash:        sum[:],
+		Transaction: tx,
+		Actions:     make([]*ProposalAction, 0, len(tx.Actions)),
+		Requested:   approvals.RequestedApprovals,
+		Provided:    approvals.ProvidedApprovals,
+		api:         api,
+		resolver:    NewAuthorityResolver(api),
+	}
+	abis := make(map[eos.AccountName]*eos.ABI)
+	for _, act := range tx.Actions {
+		pa := &ProposalAction{
+			Account:       act.Account,
+			Name:          act.Name,
+			Authorization: act.Authorization,
+			HexData:       act.HexData,
+		}
+		p.Actions = append(p.Actions, pa)
+		abi, ok := abis[act.Account]
+		if !ok {
+			resp, err := api.GetABI(act.Account)
+			if err != nil {
+				pa.DecodeError = fmt.Sprintf("getting abi for %s: %s", act.Account, err)
+				continue
+			}
+			abi = &resp.ABI
+			abis[act.Account] = abi
+		}
+		if pa.Data, err = abi.DecodeAction(act.HexData, act.Name); err != nil {
+			pa.DecodeError = err.Error()
+		}
+	}
+	return p, nil
+}
+
+// Missing lists the requested approvals that have not been provided
+func (p *Proposal) Missing() []PermissionLevel {
+	missing := make([]PermissionLevel, 0, len(p.Requested))
+	for _, r := range p.Requested {
+		missing = append(missing, r.Level)
+	}
+	return missing
+}
+
+// RequiredAuths lists each distinct permission used to authorize the proposed actions
+func (p *Proposal) RequiredAuths() []eos.PermissionLevel {
+	return transactionAuths(p.Transaction)
+}
+
+// Thresholds checks the provided approvals against the current authority of each permission required by the
+// proposed transaction, see AuthorityResolver.Check. Keys do not count towards the weight since they can't approve
+// a proposal.
+func (p *Proposal) Thresholds() ([]*ProposalAuth, error) {
+	if p.resolver == nil {
+		return nil, errors.New("proposal was not loaded with GetProposal")
+	}
+	signers := make([]eos.PermissionLevel, 0, len(p.Provided))
+	for _, a := range p.Provided {
+		signers = append(signers, eos.PermissionLevel(a.Level))
+	}
+	auths := make([]*ProposalAuth, 0)
+	for _, level := range p.RequiredAuths() {
+		result, err := p.resolver.Check(level, nil, signers)
+		if err != nil {
+			return nil, err
+		}
+		auths = append(auths, &ProposalAuth{
+			Level:     level,
+			Threshold: result.Threshold,
+			Weight:    result.Weight,
+			Satisfied: result.Satisfied,
+			Missing:   result.Missing,
+		})
+	}
+	return auths, nil
+}
+
+// Executable is true when the threshold has been met for every permission required by the proposed transaction
+func (p *Proposal) Executable() (bool, error) {
+	auths, err := p.Thresholds()
+	if err != nil {
+		return false, err
+	}
+	for _, a := range auths {
+		if !a.Satisfied {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// findApproval finds the permission an account was requested to approve with, or has approved with
+func findApproval(approvals []MsigApproval, actor eos.AccountName) (PermissionLevel, bool) {
+	for _, a := range approvals {
+		if a.Level.Actor == actor {
+			return a.Level, true
+		}
+	}
+	return PermissionLevel{}, false
+}
+
+// Approve builds an approval for the proposal, using the hash of the proposed transaction so that the approval
+// fails if the proposal is replaced. The approval uses the permission that was requested, which may not be active.
+func (p *Proposal) Approve(actor eos.AccountName) (*Action, error) {
+	level, ok := findApproval(p.Requested, actor)
+	if !ok {
+		if _, provided := findApproval(p.Provided, actor); provided {
+			return nil, fmt.Errorf("%s has already approved %s", actor, p.Name)
+		}
+		return nil, fmt.Errorf("%s was not requested to approve %s", actor, p.Name)
+	}
+	return NewActionWithPermission("eosio.msig", "approve", actor, string(level.Permission), &MsigApprove{
+		Proposer:     p.Proposer,
+		ProposalName: p.Name,
+		Level:        level,
+		MaxFee:       Tokens(GetMaxFee(FeeMsigApprove)),
+		ProposalHash: p.Hash,
+	}), nil
+}
+
+// Unapprove builds an action withdrawing an existing approval, using the permission the approval was provided with
+func (p *Proposal) Unapprove(actor eos.AccountName) (*Action, error) {
+	level, ok := findApproval(p.Provided, actor)
+	if !ok {
+		return nil, fmt.Errorf("%s has not approved %s", actor, p.Name)
+	}
+	return NewActionWithPermission("eosio.msig", "unapprove", actor, string(level.Permission), &MsigUnapprove{
+		Proposer:     p.Proposer,
+		ProposalName: p.Name,
+		Level:        level,
+		MaxFee:       Tokens(GetMaxFee(FeeMsigUnapprove)),
+	}), nil
+}
+
+// Exec builds an action to execute the proposal, any account may execute it once the thresholds are met
+func (p *Proposal) Exec(actor eos.AccountName) *Action {
+	return NewMsigExec(p.Proposer, p.Name, Tokens(GetMaxFee(FeeMsigExec)), actor)
+}
+
+// Cancel builds an action to withdraw the proposal, only the proposer can cancel before it expires
+func (p *Proposal) Cancel(actor eos.AccountName) (*Action, error) {
+	if actor != p.Proposer && p.Transaction.Expiration.After(time.Now()) {
+		return nil, fmt.Errorf("only %s can cancel %s before it expires", p.Proposer, p.Name)
+	}
+	return NewMsigCancel(p.Proposer, p.Name, actor), nil
+}
@@ -0,0 +1,165 @@
+package fio
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/fioprotocol/fio-go/eos"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const proposalTestAbi = `{
+	"version": "eosio::abi/1.1",
+	"structs": [{"name": "transfer", "base": "", "fields": [
+		{"name": "to", "type": "name"},
+		{"name": "amount", "type": "uint64"}
+	]}],
+	"actions": [{"name": "transfer", "type": "transfer", "ricardian_contract": ""}]
+}`
+
+// proposalTestServer serves a proposal by "alice" requiring multisig@active, a 2 of 3 authority of alice, bob and
+// carol. Alice has approved.
+func proposalTestServer(t *testing.T) *httptest.Server {
+	abi, err := eos.NewABI(strings.NewReader(proposalTestAbi))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := abi.EncodeAction("transfer", []byte(`{"to":"bob","amount":5}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := &eos.Transaction{
+		TransactionHeader: eos.TransactionHeader{Expiration: eos.JSONTime{Time: time.Now().Add(time.Hour).UTC()}},
+		Actions: []*eos.Action{{
+			Account:       "fio.token",
+			Name:          "transfer",
+			Authorization: []eos.PermissionLevel{{Actor: "multisig", Permission: "active"}},
+			ActionData:    eos.NewActionDataFromHexData(data),
+		}},
+	}
+	packed, err := eos.MarshalBinary(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice, _ := eos.StringToName("alice")
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := make(map[string]interface{})
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		switch r.URL.Path {
+		case "/v1/chain/get_table_rows":
+			switch req["table"] {
+			case "proposal":
+				_, _ = w.Write([]byte(`{"rows":[{"proposal_name":"prop1","packed_transaction":"` + hex.EncodeToString(packed) + `"}]}`))
+			case "approvals2":
+				_, _ = w.Write([]byte(`{"rows":[{"version":1,"proposal_name":"prop1",
+					"requested_approvals":[{"level":{"actor":"bob","permission":"active"},"time":"1970-01-01T00:00:00"},
+						{"level":{"actor":"carol","permission":"active"},"time":"1970-01-01T00:00:00"}],
+					"provided_approvals":[{"level":{"actor":"alice","permission":"active"},"time":"2020-01-01T00:00:00"}]}]}`))
+			case "accountmap":
+				if req["lower_bound"] == fmt.Sprint(alice) {
+					_, _ = w.Write([]byte(`{"rows":[{"clientkey":"FIO6m1fMdTpRkRBnedvYshXCxLFiC5suRU8KDfx8xxtXp2hntxpnf"}]}`))
+					return
+				}
+				_, _ = w.Write([]byte(`{"rows":[]}`))
+			}
+		case "/v1/chain/get_fio_names":
+			_, _ = w.Write([]byte(`{"fio_addresses":[{"fio_address":"alice@fiotestnet"}]}`))
+		case "/v1/chain/get_abi":
+			_, _ = w.Write([]byte(`{"account_name":"fio.token","abi":` + proposalTestAbi + `}`))
+		case "/v1/chain/get_account":
+			auth := `{"threshold":1,"keys":[]}`
+			if req["account_name"] == "multisig" {
+				auth = `{"threshold":2,"accounts":[
+					{"permission":{"actor":"alice","permission":"active"},"weight":1},
+					{"permission":{"actor":"bob","permission":"active"},"weight":1},
+					{"permission":{"actor":"carol","permission":"active"},"weight":1}]}`
+			}
+			_, _ = w.Write([]byte(`{"account_name":"` + req["account_name"].(string) + `","permissions":[
+				{"perm_name":"active","parent":"owner","required_auth":` + auth + `}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestProposal(t *testing.T) {
+	srv := proposalTestServer(t)
+	defer srv.Close()
+	api := &API{API: eos.New(srv.URL)}
+
+	p, err := api.GetProposal("alice", "prop1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Actions) != 1 || p.Actions[0].DecodeError != "" {
+		t.Fatal("action was not decoded", p.Actions[0].DecodeError)
+	}
+	if string(p.Actions[0].Data) != `{"to":"bob","amount":5}` {
+		t.Error("unexpected action data", string(p.Actions[0].Data))
+	}
+	if missing := p.Missing(); len(missing) != 2 || missing[0].Actor != "bob" || missing[1].Actor != "carol" {
+		t.Error("unexpected missing approvals", missing)
+	}
+
+	auths, err := p.Thresholds()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(auths) != 1 || auths[0].Satisfied || auths[0].Weight != 1 || auths[0].Threshold != 2 || len(auths[0].Missing) != 2 {
+		t.Errorf("unexpected threshold status %+v", auths[0])
+	}
+	if ok, err := p.Executable(); ok || err != nil {
+		t.Error("proposal should not be executable", err)
+	}
+
+	// an approval by owner satisfies active
+	p.Provided = append(p.Provided, MsigApproval{Level: PermissionLevel{Actor: "bob", Permission: "owner"}})
+	if ok, err := p.Executable(); !ok || err != nil {
+		t.Error("proposal should be executable", err)
+	}
+
+	// approvals use the permission that was requested or provided, rather than active
+	p.Requested[1].Level.Permission = "owner"
+	approve, err := p.Approve("carol")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if approve.Authorization[0].Permission != "owner" || approve.Data.(*MsigApprove).Level.Permission != "owner" {
+		t.Error("approval should use the requested permission", approve.Authorization, approve.Data)
+	}
+	if _, err = p.Approve("alice"); err == nil {
+		t.Error("alice has already approved")
+	}
+	if _, err = p.Approve("dave"); err == nil {
+		t.Error("dave was not requested")
+	}
+	if _, err = p.Unapprove("alice"); err != nil {
+		t.Error(err)
+	}
+	unapprove, err := p.Unapprove("bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unapprove.Authorization[0].Permission != "owner" || unapprove.Data.(*MsigUnapprove).Level.Permission != "owner" {
+		t.Error("unapproval should use the provided permission", unapprove.Authorization, unapprove.Data)
+	}
+	if _, err = p.Unapprove("carol"); err == nil {
+		t.Error("carol has not approved")
+	}
+	if _, err = p.Cancel("bob"); err == nil {
+		t.Error("only the proposer can cancel before expiration")
+	}
+	cancel, err := p.Cancel("alice")
+	if err != nil || cancel.Name != "cancel" {
+		t.Error("could not cancel", err)
+	}
+	exec := p.Exec("bob")
+	if e, ok := exec.Data.(*MsigExec); !ok || e.MaxFee != Tokens(GetMaxFee(FeeMsigExec)) || e.Executer != "bob" {
+		t.Errorf("unexpected exec %+v", exec.Data)
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"github.com/fioprotocol/fio-go/eos/ecc"
 	"io/ioutil"
 	"net/http"
+	"strconv"
 	"time"
 )
 
@@ -230,6 +231,26 @@ func NewRecordSend(actor eos.AccountName, reqId string, payer string, payee stri
 	)
 }
 
+// NewValidRecordSend is the same as NewRecordSend, but returns a *ValidationError for an invalid payer or payee
+// address. reqId may be empty when the record is not in response to a FIO Request.
+func NewValidRecordSend(actor eos.AccountName, reqId string, payer string, payee string, content string) (*Action, error) {
+	if reqId != "" {
+		if _, err := strconv.ParseUint(reqId, 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid fio_request_id %q", reqId)
+		}
+	}
+	if err := validateAddressField("payer_fio_address", payer); err != nil {
+		return nil, err
+	}
+	if err := validateAddressField("payee_fio_address", payee); err != nil {
+		return nil, err
+	}
+	if content == "" {
+		return nil, errors.New("content is empty")
+	}
+	return NewRecordSend(actor, reqId, payer, payee, content), nil
+}
+
 // FundsReq is a request sent from one user to another requesting funds
 type FundsReq struct {
 	PayerFioAddress string `json:"payer_fio_address"`
@@ -267,6 +288,20 @@ func NewFundsReq(actor eos.AccountName, payerFio string, payeeFio string, conten
 	)
 }
 
+// NewValidFundsReq is the same as NewFundsReq, but returns a *ValidationError for an invalid payer or payee address
+func NewValidFundsReq(actor eos.AccountName, payerFio string, payeeFio string, content string) (*Action, error) {
+	if err := validateAddressField("payer_fio_address", payerFio); err != nil {
+		return nil, err
+	}
+	if err := validateAddressField("payee_fio_address", payeeFio); err != nil {
+		return nil, err
+	}
+	if content == "" {
+		return nil, errors.New("content is empty")
+	}
+	return NewFundsReq(actor, payerFio, payeeFio, content), nil
+}
+
 // CancelFndReq allows cancelling a previously sent request
 type CancelFndReq struct {
 	FioRequestId string `json:"fio_request_id"`
@@ -655,35 +690,17 @@ type FundsReqTableResp struct {
 // endpoint because that requires knowing the offset of the request and the id. The downside is that this
 // returns a slightly different struct.
 func (api *API) GetFioRequest(requestId uint64) (request *FundsReqTableResp, err error) {
-	resp, err := api.GetTableRows(eos.GetTableRowsRequest{
-		Code:       "fio.reqobt",
-		Scope:      "fio.reqobt",
-		Table:      "fioreqctxts",
-		LowerBound: fmt.Sprintf("%d", requestId),
-		UpperBound: fmt.Sprintf("%d", requestId),
-		Limit:      1,
-		KeyType:    "i64",
-		Index:      "1",
-		JSON:       true,
-		EncodeType: "dec",
-	})
+	r := []*FundsReqTableResp{{}}
+	found, err := NewTableQuery(api, "fio.reqobt", "fioreqctxts").Index("fio_request_id").Equal(requestId).First(r[0])
 	if err != nil {
 		return
 	}
-	if len(resp.Rows) < 3 {
+	if !found {
 		return nil, errors.New("no requests found")
 	}
-	r := make([]*FundsReqTableResp, 0)
-	err = json.Unmarshal(resp.Rows, &r)
-	if err != nil {
-		return
-	}
-	if len(r) > 0 && r[0] != nil {
-		r[0].Time = time.Unix(r[0].TimeStamp, 0)
-		r, _, err = api.checkFRTRMismatch(r)
-		return r[0], err
-	}
-	return
+	r[0].Time = time.Unix(r[0].TimeStamp, 0)
+	r, _, err = api.checkFRTRMismatch(r)
+	return r[0], err
 }
 
 // checkFRTRMismatch updates a FundsReqTableResp to include a bool if there is a public key mismatch, which
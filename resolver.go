@@ -0,0 +1,354 @@
+package fio
+
+import (
+	"container/list"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"github.com/fioprotocol/fio-go/eos"
+	"github.com/fioprotocol/fio-go/eos/p2p"
+	"strings"
+	"sync"
+	"time"
+)
+
+// resolverActions are the fio.address actions that change the result of a lookup, mapped to the field holding the
+// FIO address or domain. In each of these actions it is the first field.
+var resolverActions = map[eos.ActionName]string{
+	"addaddress":  "fio_address",
+	"remaddress":  "fio_address",
+	"remalladdr":  "fio_address",
+	"xferaddress": "fio_address",
+	"regaddress":  "fio_address",
+	"burnaddress": "fio_address",
+	"regdomain":   "fio_domain",
+}
+
+// ResolverStats counts how lookups were answered by a Resolver
+type ResolverStats struct {
+	Hits      uint64 // answered from the cache
+	Misses    uint64 // sent to the API
+	Coalesced uint64 // waited for a matching request that was already in progress
+	Evictions uint64 // entries dropped because the cache was full
+}
+
+// resolverEntry is a cached lookup result
+type resolverEntry struct {
+	key     string
+	name    string
+	value   interface{}
+	found   bool
+	expires time.Time
+}
+
+// resolverCall is a lookup in progress, callers asking for the same key wait for it instead of making another request
+type resolverCall struct {
+	name  string
+	done  chan struct{}
+	value interface{}
+	found bool
+	err   error
+}
+
+// Resolver caches PubAddressLookup, GetAllPublic, and AvailCheck results. Entries expire after a TTL, and the least
+// recently used entry is dropped when the cache is full. Lookups that find nothing are cached for NegativeTTL, errors
+// are not cached. Concurrent lookups for the same value share a single request.
+//
+// Cached values can be kept current by passing blocks to the resolver, any action that changes a FIO address's
+// public addresses, owner, or registration invalidates the entries for that address. Registering or burning an
+// address also invalidates its domain, and burnexpired clears the whole cache since the names it removes are not in
+// the action:
+//
+//	r := api.NewResolver(10000, 5*time.Minute)
+//	go api.ResolveBlocks(ctx, r, 0)
+//	pub, found, err := r.PubAddressLookup("alice@fiotestnet", "BTC", "BTC")
+//
+// Only the actions in a block's transactions are seen. Actions run by eosio.msig::exec or eosio.wrap::execute, and
+// inline actions, are not, so entries they change stay cached until they expire or are removed with Invalidate.
+type Resolver struct {
+	TTL         time.Duration
+	NegativeTTL time.Duration
+
+	api   *API
+	size  int
+	mux   sync.Mutex
+	lru   *list.List
+	items map[string]*list.Element
+	calls map[string]*resolverCall
+	stats ResolverStats
+}
+
+// NewResolver creates a Resolver holding at most size entries, NegativeTTL defaults to the same value as ttl
+func (api *API) NewResolver(size int, ttl time.Duration) *Resolver {
+	if size < 1 {
+		size = 1
+	}
+	return &Resolver{
+		TTL:         ttl,
+		NegativeTTL: ttl,
+		api:         api,
+		size:        size,
+		lru:         list.New(),
+		items:       make(map[string]*list.Element),
+		calls:       make(map[string]*resolverCall),
+	}
+}
+
+// Stats returns the resolver's counters
+func (r *Resolver) Stats() ResolverStats {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	return r.stats
+}
+
+// Len is the number of cached entries, including expired entries that have not been removed yet
+func (r *Resolver) Len() int {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	return r.lru.Len()
+}
+
+// Clear removes all cached entries, and ensures lookups already in progress are not cached
+func (r *Resolver) Clear() {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.lru.Init()
+	r.items = make(map[string]*list.Element)
+	r.calls = make(map[string]*resolverCall)
+}
+
+// get returns a cached value, or calls fetch. Only one fetch runs at a time for a key, other callers wait for its
+// result.
+func (r *Resolver) get(key string, name string, fetch func() (interface{}, bool, error)) (interface{}, bool, error) {
+	r.mux.Lock()
+	if el := r.items[key]; el != nil {
+		entry := el.Value.(*resolverEntry)
+		if time.Now().Before(entry.expires) {
+			r.lru.MoveToFront(el)
+			r.stats.Hits += 1
+			r.mux.Unlock()
+			return entry.value, entry.found, nil
+		}
+		r.remove(el)
+	}
+	if call := r.calls[key]; call != nil {
+		r.stats.Coalesced += 1
+		r.mux.Unlock()
+		<-call.done
+		return call.value, call.found, call.err
+	}
+	call := &resolverCall{name: name, done: make(chan struct{})}
+	r.calls[key] = call
+	r.stats.Misses += 1
+	r.mux.Unlock()
+
+	call.value, call.found, call.err = fetch()
+
+	r.mux.Lock()
+	// if the name was invalidated during the request the call was removed, and the result may be stale
+	if r.calls[key] == call {
+		delete(r.calls, key)
+		if call.err == nil {
+			r.store(key, name, call.value, call.found)
+		}
+	}
+	r.mux.Unlock()
+	close(call.done)
+	return call.value, call.found, call.err
+}
+
+// store adds an entry, evicting the least recently used entries if the cache is full. Caller must hold the lock.
+func (r *Resolver) store(key string, name string, value interface{}, found bool) {
+	ttl := r.TTL
+	if !found {
+		ttl = r.NegativeTTL
+	}
+	if ttl <= 0 {
+		return
+	}
+	if el := r.items[key]; el != nil {
+		r.remove(el)
+	}
+	r.items[key] = r.lru.PushFront(&resolverEntry{
+		key:     key,
+		name:    name,
+		value:   value,
+		found:   found,
+		expires: time.Now().Add(ttl),
+	})
+	for r.lru.Len() > r.size {
+		r.remove(r.lru.Back())
+		r.stats.Evictions += 1
+	}
+}
+
+// remove drops an entry. Caller must hold the lock.
+func (r *Resolver) remove(el *list.Element) {
+	r.lru.Remove(el)
+	delete(r.items, el.Value.(*resolverEntry).key)
+}
+
+// PubAddressLookup is a cached API.PubAddressLookup
+func (r *Resolver) PubAddressLookup(fioAddress Address, chain string, token string) (PubAddress, bool, error) {
+	if token == "" {
+		token = chain
+	}
+	name := strings.ToLower(string(fioAddress))
+	key := "pub:" + name + ":" + strings.ToUpper(chain) + ":" + strings.ToUpper(token)
+	v, found, err := r.get(key, name, func() (interface{}, bool, error) {
+		return r.api.PubAddressLookup(Address(name), chain, token)
+	})
+	if err != nil {
+		return PubAddress{}, false, err
+	}
+	return v.(PubAddress), found, nil
+}
+
+// GetAllPublic is a cached API.GetAllPublic
+func (r *Resolver) GetAllPublic(fioAddress Address) ([]TokenPubAddr, error) {
+	name := strings.ToLower(string(fioAddress))
+	v, found, err := r.get("all:"+name, name, func() (interface{}, bool, error) {
+		return r.api.getAllPublic(Address(name))
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, errors.New("empty result")
+	}
+	// copy so that callers can't modify the cached value
+	addrs := v.([]TokenPubAddr)
+	return append(make([]TokenPubAddr, 0, len(addrs)), addrs...), nil
+}
+
+// AvailCheck is a cached API.AvailCheck, an available name is treated as a negative result and uses NegativeTTL
+func (r *Resolver) AvailCheck(addressOrDomain string) (bool, error) {
+	name := strings.ToLower(addressOrDomain)
+	v, _, err := r.get("avail:"+name, name, func() (interface{}, bool, error) {
+		available, err := r.api.AvailCheck(name)
+		return available, !available, err
+	})
+	if err != nil {
+		return false, err
+	}
+	return v.(bool), nil
+}
+
+// Invalidate removes all cached entries for a FIO address or domain, and ensures lookups already in progress are not
+// cached
+func (r *Resolver) Invalidate(addressOrDomain string) {
+	name := strings.ToLower(addressOrDomain)
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	for el := r.lru.Front(); el != nil; {
+		next := el.Next()
+		if el.Value.(*resolverEntry).name == name {
+			r.remove(el)
+		}
+		el = next
+	}
+	for key, call := range r.calls {
+		if call.name == name {
+			delete(r.calls, key)
+		}
+	}
+}
+
+// InvalidateAction invalidates the entries affected by an action, other actions are ignored
+func (r *Resolver) InvalidateAction(act *eos.Action) {
+	if act == nil || act.Account != "fio.address" {
+		return
+	}
+	if act.Name == "burnexpired" {
+		r.Clear()
+		return
+	}
+	field := resolverActions[act.Name]
+	if field == "" {
+		return
+	}
+	name := actionName(act, field)
+	if name == "" {
+		return
+	}
+	r.Invalidate(name)
+	switch act.Name {
+	case "regaddress", "burnaddress":
+		// availability of the domain is cached separately
+		if at := strings.Index(name, "@"); at >= 0 {
+			r.Invalidate(name[at+1:])
+		}
+	}
+}
+
+// actionName gets the FIO address or domain from an action. Data may be decoded (a struct or map), a hex string, or
+// only available as binary in HexData.
+func actionName(act *eos.Action, field string) string {
+	hexData := act.HexData
+	switch data := act.Data.(type) {
+	case nil:
+	case string:
+		hexData, _ = hex.DecodeString(data)
+	default:
+		j, err := json.Marshal(data)
+		if err != nil {
+			return ""
+		}
+		m := make(map[string]interface{})
+		if json.Unmarshal(j, &m) == nil {
+			if name, ok := m[field].(string); ok {
+				return name
+			}
+		}
+	}
+	if len(hexData) == 0 {
+		return ""
+	}
+	name, err := eos.NewDecoder(hexData).ReadString()
+	if err != nil {
+		return ""
+	}
+	return name
+}
+
+// AddBlock invalidates entries affected by the actions in a block. Only the top-level actions of each transaction are
+// checked, actions executed through eosio.msig::exec or eosio.wrap::execute are not seen.
+func (r *Resolver) AddBlock(block *eos.SignedBlock) {
+	if block == nil {
+		return
+	}
+	for _, receipt := range block.Transactions {
+		if receipt.Transaction.Packed == nil {
+			continue
+		}
+		tx, err := receipt.Transaction.Packed.UnpackBare()
+		if err != nil {
+			continue
+		}
+		for _, act := range tx.Actions {
+			r.InvalidateAction(act)
+		}
+	}
+}
+
+// P2PHandler returns a handler for an eos/p2p client that passes each signed block to the resolver
+func (r *Resolver) P2PHandler() p2p.Handler {
+	return p2p.HandlerFunc(func(envelope *p2p.Envelope) {
+		if envelope == nil || envelope.Packet == nil {
+			return
+		}
+		if block, ok := envelope.Packet.P2PMessage.(*eos.SignedBlock); ok {
+			r.AddBlock(block)
+		}
+	})
+}
+
+// ResolveBlocks polls get_block starting at a block number, passing each block to the resolver until the context is
+// cancelled. A start of zero begins at the current head block.
+func (api *API) ResolveBlocks(ctx context.Context, r *Resolver, start uint32) error {
+	return api.pollBlocks(ctx, start, func(block *eos.BlockResp) error {
+		r.AddBlock(&block.SignedBlock)
+		return nil
+	})
+}
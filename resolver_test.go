@@ -0,0 +1,224 @@
+package fio
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/fioprotocol/fio-go/eos"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestResolver(t *testing.T) {
+	var requests int32
+	gate := make(chan struct{})
+	close(gate)
+	mux := sync.Mutex{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		mux.Lock()
+		g := gate
+		mux.Unlock()
+		<-g
+		switch r.URL.Path {
+		case "/v1/chain/get_pub_address":
+			req := pubAddressRequest{}
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			if req.FioAddress != "alice@fiotestnet" {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"message":"Public address not found"}`))
+				return
+			}
+			_, _ = fmt.Fprintf(w, `{"public_address":"%s-%d"}`, req.ChainCode, atomic.LoadInt32(&requests))
+		case "/v1/chain/avail_check":
+			_, _ = w.Write([]byte(`{"is_registered":1}`))
+		case "/v1/chain/get_table_rows":
+			_, _ = w.Write([]byte(`{"rows":[]}`))
+		}
+	}))
+	defer srv.Close()
+	r := (&API{API: eos.New(srv.URL)}).NewResolver(2, time.Minute)
+
+	pub, found, err := r.PubAddressLookup("Alice@fiotestnet", "BTC", "")
+	if err != nil || !found || pub.PublicAddress != "BTC-1" {
+		t.Fatal("unexpected lookup", pub, found, err)
+	}
+	if pub, _, _ = r.PubAddressLookup("alice@fiotestnet", "btc", "btc"); pub.PublicAddress != "BTC-1" {
+		t.Error("expected cached result", pub)
+	}
+	// not found results are cached too
+	for i := 0; i < 2; i++ {
+		if _, found, err = r.PubAddressLookup("bob@fiotestnet", "BTC", "BTC"); err != nil || found {
+			t.Error("expected not found", err)
+		}
+	}
+	if _, err = r.GetAllPublic("bob@fiotestnet"); err == nil {
+		t.Error("expected error for an address without mappings")
+	}
+	if n := atomic.LoadInt32(&requests); n != 3 {
+		t.Error("expected 3 requests, got", n)
+	}
+	// the cache holds two entries, alice's lookup was the least recently used
+	if s := r.Stats(); r.Len() != 2 || s.Evictions != 1 || s.Hits != 2 || s.Misses != 3 {
+		t.Errorf("unexpected stats %+v", s)
+	}
+	if pub, _, _ = r.PubAddressLookup("alice@fiotestnet", "BTC", "BTC"); pub.PublicAddress != "BTC-4" {
+		t.Error("expected evicted entry to be fetched again", pub)
+	}
+
+	// entries expire
+	r.TTL = time.Millisecond
+	if _, err = r.AvailCheck("alice@fiotestnet"); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if available, _ := r.AvailCheck("alice@fiotestnet"); available || atomic.LoadInt32(&requests) != 6 {
+		t.Error("expected expired entry to be fetched again", atomic.LoadInt32(&requests))
+	}
+	r.TTL = time.Minute
+
+	// concurrent lookups share a request
+	mux.Lock()
+	gate = make(chan struct{})
+	mux.Unlock()
+	before := atomic.LoadInt32(&requests)
+	wg := sync.WaitGroup{}
+	results := make([]string, 10)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			p, _, _ := r.PubAddressLookup("alice@fiotestnet", "ETH", "ETH")
+			results[i] = p.PublicAddress
+		}(i)
+	}
+	for r.Stats().Coalesced < 9 {
+		time.Sleep(time.Millisecond)
+	}
+	mux.Lock()
+	close(gate)
+	mux.Unlock()
+	wg.Wait()
+	if n := atomic.LoadInt32(&requests); n != before+1 {
+		t.Error("expected one request for concurrent lookups, got", n-before)
+	}
+	for _, res := range results {
+		if res != results[0] || res == "" {
+			t.Error("concurrent lookups returned different results", results)
+			break
+		}
+	}
+}
+
+func TestResolver_Invalidate(t *testing.T) {
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&requests, 1)
+		_, _ = fmt.Fprintf(w, `{"public_address":"addr-%d"}`, n)
+	}))
+	defer srv.Close()
+	r := (&API{API: eos.New(srv.URL)}).NewResolver(100, time.Minute)
+	lookup := func() string {
+		pub, _, err := r.PubAddressLookup("alice@fiotestnet", "BTC", "BTC")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return pub.PublicAddress
+	}
+	lookup()
+	acc, err := NewRandomAccount()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// decoded action data
+	r.InvalidateAction(NewRenewAddress(acc.Actor, "alice@fiotestnet").ToEos())
+	if lookup() != "addr-1" {
+		t.Error("renewaddress should not invalidate")
+	}
+	r.InvalidateAction(MustNewRegAddress(acc.Actor, "bob@fiotestnet", acc.PubKey).ToEos())
+	if lookup() != "addr-1" {
+		t.Error("other addresses should not invalidate")
+	}
+	add, err := NewValidAddAddress(acc.Actor, "alice@fiotestnet", "BTC", "BTC", "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.InvalidateAction(add.ToEos())
+	if lookup() != "addr-2" {
+		t.Error("addaddress should invalidate")
+	}
+
+	// binary action data from a block
+	xfer := NewTransferAddress(acc.Actor, "alice@fiotestnet", acc.PubKey).ToEos()
+	packed, err := eos.NewSignedTransaction(eos.NewTransaction([]*eos.Action{xfer}, nil)).Pack(eos.CompressionZlib)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.AddBlock(&eos.SignedBlock{Transactions: []eos.TransactionReceipt{{Transaction: eos.TransactionWithID{Packed: packed}}}})
+	if lookup() != "addr-3" {
+		t.Error("xferaddress in a block should invalidate")
+	}
+}
+
+func TestResolver_InvalidateDomain(t *testing.T) {
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		_, _ = w.Write([]byte(`{"is_registered":1}`))
+	}))
+	defer srv.Close()
+	r := (&API{API: eos.New(srv.URL)}).NewResolver(100, time.Minute)
+	avail := func(name string) {
+		if _, err := r.AvailCheck(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	acc, err := NewRandomAccount()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	avail("fiotestnet")
+	avail("alice@fiotestnet")
+	r.InvalidateAction(MustNewRegAddress(acc.Actor, "bob@fiotestnet", acc.PubKey).ToEos())
+	avail("fiotestnet")
+	avail("alice@fiotestnet")
+	if n := atomic.LoadInt32(&requests); n != 3 {
+		t.Error("regaddress should invalidate only the domain, requests:", n)
+	}
+
+	r.InvalidateAction(NewBurnExpiredRange(0, 15, acc.Actor).ToEos())
+	if r.Len() != 0 {
+		t.Error("burnexpired should clear the cache")
+	}
+	avail("alice@fiotestnet")
+	if n := atomic.LoadInt32(&requests); n != 4 {
+		t.Error("expected a new request after burnexpired, requests:", n)
+	}
+}
+
+func TestResolver_ErrorsNotCached(t *testing.T) {
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte(`{"message":"unavailable"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"public_address":"bc1qalice"}`))
+	}))
+	defer srv.Close()
+	r := (&API{API: eos.New(srv.URL)}).NewResolver(100, time.Minute)
+
+	if _, found, err := r.PubAddressLookup("alice@fiotestnet", "BTC", "BTC"); err == nil || found {
+		t.Error("expected an error for a 503 response", err)
+	}
+	pub, found, err := r.PubAddressLookup("alice@fiotestnet", "BTC", "BTC")
+	if err != nil || !found || pub.PublicAddress != "bc1qalice" {
+		t.Error("the error should not have been cached", pub, found, err)
+	}
+}
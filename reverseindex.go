@@ -0,0 +1,278 @@
+package fio
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/fioprotocol/fio-go/eos"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ReverseMapping is a FIO address that maps a public address
+type ReverseMapping struct {
+	FioAddress    string `json:"fio_address"`
+	ChainCode     string `json:"chain_code"`
+	TokenCode     string `json:"token_code"`
+	PublicAddress string `json:"public_address"`
+}
+
+// ReverseStore holds the data for a ReverseIndex. MemoryReverseStore is provided, other implementations can persist
+// the index in a database. Implementations must be safe for concurrent use.
+type ReverseStore interface {
+	// Set replaces all of the public addresses for a FIO address, an empty list removes the FIO address
+	Set(fioAddress string, addrs []TokenPubAddr) error
+
+	// Lookup finds the FIO addresses mapping a public address, an empty chain code matches any chain. The public
+	// address has already been normalized with ReverseKey.
+	Lookup(chain string, publicAddress string) ([]ReverseMapping, error)
+
+	// FioAddresses lists every FIO address in the store
+	FioAddresses() ([]string, error)
+}
+
+// ReverseKey normalizes a public address for the reverse index. Hex (0x) addresses, and addresses that are entirely
+// upper-case such as bech32 in a QR code, are case-insensitive and are folded to lower-case.
+func ReverseKey(publicAddress string) string {
+	if strings.HasPrefix(publicAddress, "0x") || strings.HasPrefix(publicAddress, "0X") ||
+		strings.ToUpper(publicAddress) == publicAddress {
+		return strings.ToLower(publicAddress)
+	}
+	return publicAddress
+}
+
+// MemoryReverseStore is an in-memory ReverseStore
+type MemoryReverseStore struct {
+	mux    sync.RWMutex
+	byName map[string][]TokenPubAddr
+	byKey  map[string]map[string]bool // public address -> set of FIO addresses
+}
+
+// NewMemoryReverseStore creates an empty MemoryReverseStore
+func NewMemoryReverseStore() *MemoryReverseStore {
+	return &MemoryReverseStore{
+		byName: make(map[string][]TokenPubAddr),
+		byKey:  make(map[string]map[string]bool),
+	}
+}
+
+// Set replaces the public addresses for a FIO address
+func (s *MemoryReverseStore) Set(fioAddress string, addrs []TokenPubAddr) error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	for _, a := range s.byName[fioAddress] {
+		key := ReverseKey(a.PublicAddress)
+		delete(s.byKey[key], fioAddress)
+		if len(s.byKey[key]) == 0 {
+			delete(s.byKey, key)
+		}
+	}
+	if len(addrs) == 0 {
+		delete(s.byName, fioAddress)
+		return nil
+	}
+	s.byName[fioAddress] = append(make([]TokenPubAddr, 0, len(addrs)), addrs...)
+	for _, a := range addrs {
+		key := ReverseKey(a.PublicAddress)
+		if s.byKey[key] == nil {
+			s.byKey[key] = make(map[string]bool)
+		}
+		s.byKey[key][fioAddress] = true
+	}
+	return nil
+}
+
+// Lookup finds the FIO addresses mapping a public address, sorted by FIO address
+func (s *MemoryReverseStore) Lookup(chain string, publicAddress string) ([]ReverseMapping, error) {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+	mappings := make([]ReverseMapping, 0)
+	for name := range s.byKey[publicAddress] {
+		for _, a := range s.byName[name] {
+			if ReverseKey(a.PublicAddress) != publicAddress || (chain != "" && !strings.EqualFold(a.ChainCode, chain)) {
+				continue
+			}
+			mappings = append(mappings, ReverseMapping{
+				FioAddress:    name,
+				ChainCode:     a.ChainCode,
+				TokenCode:     a.TokenCode,
+				PublicAddress: a.PublicAddress,
+			})
+		}
+	}
+	sort.Slice(mappings, func(i, j int) bool {
+		if mappings[i].FioAddress == mappings[j].FioAddress {
+			return mappings[i].TokenCode < mappings[j].TokenCode
+		}
+		return mappings[i].FioAddress < mappings[j].FioAddress
+	})
+	return mappings, nil
+}
+
+// FioAddresses lists the FIO addresses in the store
+func (s *MemoryReverseStore) FioAddresses() ([]string, error) {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+	names := make([]string, 0, len(s.byName))
+	for name := range s.byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// reverseIndexRow is the part of a fio.address fionames row used by ReverseIndex
+type reverseIndexRow struct {
+	Id        uint64         `json:"id"`
+	Name      string         `json:"name"`
+	Addresses []TokenPubAddr `json:"addresses"`
+}
+
+// ReverseIndex answers which FIO addresses map a public address, which the chain can't do directly. It is built by
+// scanning the fio.address fionames table and kept current by applying action traces:
+//
+//	idx := api.NewReverseIndex(nil)
+//	err := idx.Scan()
+//	// for each new transaction, for example from a history backend:
+//	err = idx.AddTransaction(tx)
+//	mappings, err := idx.Lookup("ETH", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed")
+type ReverseIndex struct {
+	Store    ReverseStore
+	PageSize int // rows requested from the table at a time, default 1000
+
+	api *API
+}
+
+// NewReverseIndex creates a ReverseIndex, if store is nil a MemoryReverseStore is used
+func (api *API) NewReverseIndex(store ReverseStore) *ReverseIndex {
+	if store == nil {
+		store = NewMemoryReverseStore()
+	}
+	return &ReverseIndex{Store: store, PageSize: 1000, api: api}
+}
+
+// Scan reads every row of the fionames table into the store, and removes FIO addresses that no longer exist. It
+// returns the number of FIO addresses read.
+func (ri *ReverseIndex) Scan() (int, error) {
+	pageSize := ri.PageSize
+	if pageSize < 1 {
+		pageSize = 1000
+	}
+	seen := make(map[string]bool)
+	var next uint64
+	for {
+		rows := make([]reverseIndexRow, 0)
+		err := NewTableQuery(ri.api, "fio.address", "fionames").Index("id").Lower(next).
+			Limit(uint32(pageSize)).Max(pageSize).All(&rows)
+		if err != nil {
+			return len(seen), err
+		}
+		for _, row := range rows {
+			seen[row.Name] = true
+			if err = ri.Store.Set(row.Name, row.Addresses); err != nil {
+				return len(seen), err
+			}
+		}
+		if len(rows) < pageSize {
+			break
+		}
+		next = rows[len(rows)-1].Id + 1
+	}
+	names, err := ri.Store.FioAddresses()
+	if err != nil {
+		return len(seen), err
+	}
+	for _, name := range names {
+		if !seen[name] {
+			if err = ri.Store.Set(name, nil); err != nil {
+				return len(seen), err
+			}
+		}
+	}
+	return len(seen), nil
+}
+
+// Refresh reads a single FIO address from the fionames table and updates the store
+func (ri *ReverseIndex) Refresh(fioAddress string) error {
+	name := strings.ToLower(fioAddress)
+	row := reverseIndexRow{}
+	found, err := NewTableQuery(ri.api, "fio.address", "fionames").Index("name").Equal(Address(name)).First(&row)
+	if err != nil {
+		return err
+	}
+	if !found || row.Name != name {
+		return ri.Store.Set(name, nil)
+	}
+	return ri.Store.Set(name, row.Addresses)
+}
+
+// ApplyTrace refreshes the FIO address changed by an action trace, and any inline traces. Actions that don't change
+// public addresses are ignored, as are the notification traces received by other accounts. A burnexpired action
+// doesn't say which addresses were removed, so it causes a full Scan.
+func (ri *ReverseIndex) ApplyTrace(trace *eos.ActionTrace) error {
+	if trace == nil {
+		return nil
+	}
+	if act := trace.Action; act != nil && act.Account == "fio.address" && trace.Receipt.Receiver == "fio.address" {
+		switch {
+		case act.Name == "burnexpired":
+			if _, err := ri.Scan(); err != nil {
+				return err
+			}
+		case resolverActions[act.Name] == "fio_address":
+			if name := actionName(act, "fio_address"); name != "" {
+				if err := ri.Refresh(name); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	for _, inline := range trace.InlineTraces {
+		if err := ri.ApplyTrace(inline); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddTransaction applies each action trace in a transaction
+func (ri *ReverseIndex) AddTransaction(tx *eos.TransactionResp) error {
+	if tx == nil {
+		return errors.New("transaction is nil")
+	}
+	for i := range tx.Traces {
+		if err := ri.ApplyTrace(&tx.Traces[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Lookup finds the FIO addresses mapping a public address, an empty chain code matches any chain
+func (ri *ReverseIndex) Lookup(chain string, publicAddress string) ([]ReverseMapping, error) {
+	return ri.Store.Lookup(chain, ReverseKey(publicAddress))
+}
+
+// ServeHTTP answers lookups using the public_address and optional chain_code query parameters:
+//
+//	GET /?chain_code=ETH&public_address=0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed
+//	{"mappings":[{"fio_address":"alice@fiotestnet","chain_code":"ETH","token_code":"USDT","public_address":"0x5aAe..."}]}
+func (ri *ReverseIndex) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	pub := r.URL.Query().Get("public_address")
+	if pub == "" {
+		http.Error(w, "public_address is required", http.StatusBadRequest)
+		return
+	}
+	mappings, err := ri.Lookup(r.URL.Query().Get("chain_code"), pub)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string][]ReverseMapping{"mappings": mappings})
+}
@@ -0,0 +1,130 @@
+package fio
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/fioprotocol/fio-go/eos"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestReverseIndex(t *testing.T) {
+	const eth = "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"
+	mux := sync.Mutex{}
+	requests := 0
+	rows := []reverseIndexRow{
+		{Id: 0, Name: "alice@fiotestnet", Addresses: []TokenPubAddr{
+			{ChainCode: "ETH", TokenCode: "ETH", PublicAddress: eth},
+			{ChainCode: "ETH", TokenCode: "USDT", PublicAddress: eth},
+		}},
+		{Id: 1, Name: "bob@fiotestnet", Addresses: []TokenPubAddr{{ChainCode: "BTC", TokenCode: "BTC", PublicAddress: "bc1qbob"}}},
+		{Id: 2, Name: "carol@fiotestnet", Addresses: []TokenPubAddr{{ChainCode: "BSC", TokenCode: "BNB", PublicAddress: eth}}},
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := GetTableRowsOrderRequest{}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		mux.Lock()
+		defer mux.Unlock()
+		requests += 1
+		page := make([]reverseIndexRow, 0)
+		for _, row := range rows {
+			switch req.Index {
+			case "1":
+				lower, _ := strconv.ParseUint(req.LowerBound, 10, 64)
+				if row.Id >= lower && len(page) < int(req.Limit) {
+					page = append(page, row)
+				}
+			case "5":
+				if req.LowerBound == I128Hash(row.Name) {
+					page = append(page, row)
+				}
+			}
+		}
+		j, _ := json.Marshal(page)
+		_, _ = fmt.Fprintf(w, `{"rows":%s,"more":false}`, j)
+	}))
+	defer srv.Close()
+	count := func() int {
+		mux.Lock()
+		defer mux.Unlock()
+		n := requests
+		requests = 0
+		return n
+	}
+	idx := (&API{API: eos.New(srv.URL)}).NewReverseIndex(nil)
+	idx.PageSize = 2
+	_ = idx.Store.Set("burned@fiotestnet", []TokenPubAddr{{ChainCode: "BTC", TokenCode: "BTC", PublicAddress: "bc1qold"}})
+
+	n, err := idx.Scan()
+	if err != nil || n != 3 {
+		t.Fatal("unexpected scan", n, err)
+	}
+	m, err := idx.Lookup("", "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed")
+	if err != nil || len(m) != 3 || m[0].FioAddress != "alice@fiotestnet" || m[2].FioAddress != "carol@fiotestnet" || m[2].PublicAddress != eth {
+		t.Errorf("unexpected mappings %+v %v", m, err)
+	}
+	if m, _ = idx.Lookup("eth", eth); len(m) != 2 || m[1].TokenCode != "USDT" {
+		t.Errorf("expected chain filter %+v", m)
+	}
+	if m, _ = idx.Lookup("BTC", "bc1qold"); len(m) != 0 {
+		t.Error("scan should remove addresses that no longer exist", m)
+	}
+
+	// bob adds an ETH address, alice transfers her handle
+	mux.Lock()
+	rows[1].Addresses = append(rows[1].Addresses, TokenPubAddr{ChainCode: "ETH", TokenCode: "ETH", PublicAddress: eth})
+	rows[0].Addresses = nil
+	mux.Unlock()
+	acc, _ := NewRandomAccount()
+	add, _ := NewValidAddAddress(acc.Actor, "bob@fiotestnet", "ETH", "ETH", eth)
+	receipt := eos.ActionTraceReceipt{Receiver: "fio.address"}
+	tx := &eos.TransactionResp{Traces: []eos.ActionTrace{
+		{Receipt: eos.ActionTraceReceipt{Receiver: "fio.token"}, Action: NewTransferTokensPubKey(acc.Actor, acc.PubKey, 1).ToEos()},
+		{Receipt: receipt, Action: add.ToEos(), InlineTraces: []*eos.ActionTrace{
+			{Receipt: receipt, Action: NewTransferAddress(acc.Actor, "alice@fiotestnet", acc.PubKey).ToEos()},
+		}},
+	}}
+	count()
+	if err = idx.AddTransaction(tx); err != nil {
+		t.Fatal(err)
+	}
+	if m, _ = idx.Lookup("ETH", eth); len(m) != 1 || m[0].FioAddress != "bob@fiotestnet" {
+		t.Errorf("unexpected mappings after update %+v", m)
+	}
+	if n := count(); n != 2 {
+		t.Error("expected one refresh for each changed address, got", n)
+	}
+
+	// notifications to other accounts are ignored
+	notify := eos.ActionTrace{Receipt: eos.ActionTraceReceipt{Receiver: "fio.treasury"}, Action: add.ToEos()}
+	if err = idx.ApplyTrace(&notify); err != nil || count() != 0 {
+		t.Error("notification trace should not refresh", err)
+	}
+
+	w := httptest.NewRecorder()
+	idx.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/?chain_code=BSC&public_address="+eth, nil))
+	resp := make(map[string][]ReverseMapping)
+	if err = json.Unmarshal(w.Body.Bytes(), &resp); err != nil || len(resp["mappings"]) != 1 || resp["mappings"][0].FioAddress != "carol@fiotestnet" {
+		t.Error("unexpected http response", w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	idx.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Error("expected bad request without a public address", w.Code)
+	}
+
+	// carol's address expires and is burned
+	mux.Lock()
+	rows = rows[:2]
+	mux.Unlock()
+	burn := eos.ActionTrace{Receipt: receipt, Action: NewBurnExpiredRange(0, 15, acc.Actor).ToEos()}
+	if err = idx.ApplyTrace(&burn); err != nil {
+		t.Fatal(err)
+	}
+	if m, _ = idx.Lookup("BSC", eth); len(m) != 0 {
+		t.Error("burnexpired should remove expired addresses", m)
+	}
+}
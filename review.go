@@ -0,0 +1,434 @@
+package fio
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/fioprotocol/fio-go/eos"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// reviewSufFields are fields holding an amount in SUFs, they are shown as FIO in a review
+var reviewSufFields = map[string]bool{
+	"amount":         true,
+	"max_fee":        true,
+	"max_oracle_fee": true,
+	"fee":            true,
+}
+
+// reviewNameFields are fields holding an account name, they are shown with the account's FIO addresses in a review
+var reviewNameFields = map[string]bool{
+	"account":  true,
+	"actor":    true,
+	"executer": true,
+	"from":     true,
+	"owner":    true,
+	"producer": true,
+	"proposer": true,
+	"proxy":    true,
+	"to":       true,
+	"voter":    true,
+}
+
+// ReviewField is a single value from an action, nested fields are named with their path, ie "periods.0.duration"
+type ReviewField struct {
+	Name  string
+	Value string
+}
+
+// ReviewAction is the human-readable form of a proposed action
+type ReviewAction struct {
+	Action      *ProposalAction
+	Fields      []ReviewField
+	AuthChanges []string // for updateauth and deleteauth, differences from the current permission prefixed with + or -
+}
+
+// ProposalReview summarizes a multisig proposal for the approvers, see API.ReviewProposal
+type ProposalReview struct {
+	Proposal       *Proposal
+	Actions        []*ReviewAction
+	Auths          []*ProposalAuth
+	ThresholdError error                        // set if the thresholds could not be checked, Auths is then empty
+	Names          map[eos.AccountName][]string // FIO addresses owned by the accounts involved
+}
+
+// ReviewProposal loads a proposal and builds a review of it
+func (api *API) ReviewProposal(proposer eos.AccountName, name eos.Name) (*ProposalReview, error) {
+	p, err := api.GetProposal(proposer, name)
+	if err != nil {
+		return nil, err
+	}
+	return p.Review()
+}
+
+// Review decodes each proposed action, converting SUF amounts to FIO, finds the FIO addresses for the accounts
+// involved, and compares any permission changes with the current permissions. If the thresholds can't be checked the
+// review is still built, with the reason in ThresholdError.
+func (p *Proposal) Review() (*ProposalReview, error) {
+	if p.api == nil {
+		return nil, errors.New("proposal was not loaded with GetProposal")
+	}
+	r := &ProposalReview{
+		Proposal: p,
+		Actions:  make([]*ReviewAction, 0, len(p.Actions)),
+		Auths:    make([]*ProposalAuth, 0),
+		Names:    make(map[eos.AccountName][]string),
+	}
+	var err error
+	if auths, err := p.Thresholds(); err != nil {
+		r.ThresholdError = err
+	} else {
+		r.Auths = auths
+	}
+	r.lookupNames(p.Proposer)
+	for _, a := range p.Requested {
+		r.lookupNames(a.Level.Actor)
+	}
+	for _, a := range p.Provided {
+		r.lookupNames(a.Level.Actor)
+	}
+	for _, act := range p.Actions {
+		ra := &ReviewAction{Action: act, Fields: make([]ReviewField, 0)}
+		r.Actions = append(r.Actions, ra)
+		for _, level := range act.Authorization {
+			r.lookupNames(level.Actor)
+		}
+		if act.DecodeError != "" {
+			continue
+		}
+		if ra.Fields, err = r.fields(act.Data); err != nil {
+			return nil, fmt.Errorf("%s::%s: %s", act.Account, act.Name, err)
+		}
+		if act.Account == "eosio" && (act.Name == "updateauth" || act.Name == "deleteauth") {
+			if ra.AuthChanges, err = p.authChanges(act); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return r, nil
+}
+
+// lookupNames finds the FIO addresses for an account, accounts without any are ignored
+func (r *ProposalReview) lookupNames(actor eos.AccountName) {
+	if _, ok := r.Names[actor]; ok {
+		return
+	}
+	r.Names[actor] = make([]string, 0)
+	names, found, err := r.Proposal.api.GetFioNamesForActor(string(actor))
+	if err != nil || !found {
+		return
+	}
+	for _, n := range names.FioAddresses {
+		r.Names[actor] = append(r.Names[actor], n.FioAddress)
+	}
+}
+
+// fields flattens action data into named values, keeping the order from the ABI
+func (r *ProposalReview) fields(data json.RawMessage) ([]ReviewField, error) {
+	fields := make([]ReviewField, 0)
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var walk func(path string) error
+	walk = func(path string) error {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		join := func(name string) string {
+			if path == "" {
+				return name
+			}
+			return path + "." + name
+		}
+		switch v := tok.(type) {
+		case json.Delim:
+			switch v {
+			case '{':
+				for dec.More() {
+					key, err := dec.Token()
+					if err != nil {
+						return err
+					}
+					if err = walk(join(fmt.Sprint(key))); err != nil {
+						return err
+					}
+				}
+			case '[':
+				for i := 0; dec.More(); i++ {
+					if err = walk(join(strconv.Itoa(i))); err != nil {
+						return err
+					}
+				}
+			}
+			// closing delimiter
+			_, err = dec.Token()
+			return err
+		case nil:
+			fields = append(fields, ReviewField{Name: path, Value: "null"})
+		default:
+			fields = append(fields, ReviewField{Name: path, Value: r.value(path, fmt.Sprint(v))})
+		}
+		return nil
+	}
+	if err := walk(""); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// value formats SUF amounts as FIO, and adds FIO addresses to account names
+func (r *ProposalReview) value(path string, s string) string {
+	field := path[strings.LastIndex(path, ".")+1:]
+	if reviewSufFields[field] {
+		if suf, err := strconv.ParseUint(s, 10, 64); err == nil {
+			return fmt.Sprintf("%s (%d SUF)", Amount(suf), suf)
+		}
+	}
+	if reviewNameFields[field] {
+		r.lookupNames(eos.AccountName(s))
+		return r.actor(eos.AccountName(s))
+	}
+	return s
+}
+
+// actor formats an account name, including its FIO addresses if it has any
+func (r *ProposalReview) actor(actor eos.AccountName) string {
+	if names := r.Names[actor]; len(names) > 0 {
+		return fmt.Sprintf("%s (%s)", actor, strings.Join(names, ", "))
+	}
+	return string(actor)
+}
+
+// level formats a permission level, including the account's FIO addresses
+func (r *ProposalReview) level(level PermissionLevel) string {
+	return fmt.Sprintf("%s@%s", r.actor(level.Actor), level.Permission)
+}
+
+// reviewAuthority is an Authority with the keys left as strings, since proposals may use either key prefix
+type reviewAuthority struct {
+	Threshold uint32 `json:"threshold"`
+	Keys      []struct {
+		Key    string `json:"key"`
+		Weight uint16 `json:"weight"`
+	} `json:"keys"`
+	Accounts []eos.PermissionLevelWeight `json:"accounts"`
+	Waits    []eos.WaitWeight            `json:"waits"`
+}
+
+// entries describes each part of an authority as a line that can be compared
+func (ra reviewAuthority) entries(parent string) []string {
+	e := []string{fmt.Sprintf("parent %s", parent), fmt.Sprintf("threshold %d", ra.Threshold)}
+	for _, k := range ra.Keys {
+		e = append(e, fmt.Sprintf("key %s weight %d", normalizeKey(k.Key), k.Weight))
+	}
+	for _, a := range ra.Accounts {
+		e = append(e, fmt.Sprintf("account %s@%s weight %d", a.Permission.Actor, a.Permission.Permission, a.Weight))
+	}
+	for _, w := range ra.Waits {
+		e = append(e, fmt.Sprintf("wait %ds weight %d", w.WaitSec, w.Weight))
+	}
+	return e
+}
+
+// authChanges compares an updateauth or deleteauth with the account's current permission
+func (p *Proposal) authChanges(act *ProposalAction) ([]string, error) {
+	update := struct {
+		Account    eos.AccountName `json:"account"`
+		Permission string          `json:"permission"`
+		Parent     string          `json:"parent"`
+		Auth       reviewAuthority `json:"auth"`
+	}{}
+	if err := json.Unmarshal(act.Data, &update); err != nil {
+		return nil, fmt.Errorf("%s::%s: %s", act.Account, act.Name, err)
+	}
+	acc, err := p.api.GetFioAccount(string(update.Account))
+	if err != nil {
+		return nil, fmt.Errorf("getting account %s: %s", update.Account, err)
+	}
+	current := make([]string, 0)
+	for _, perm := range acc.Permissions {
+		if perm.PermName != update.Permission {
+			continue
+		}
+		// round trip through json to convert the keys to strings
+		ra := reviewAuthority{}
+		j, err := json.Marshal(perm.RequiredAuth)
+		if err != nil {
+			return nil, err
+		}
+		if err = json.Unmarshal(j, &ra); err != nil {
+			return nil, err
+		}
+		current = ra.entries(perm.Parent)
+	}
+	proposed := make([]string, 0)
+	if act.Name == "updateauth" {
+		proposed = update.Auth.entries(update.Parent)
+	}
+
+	changes := make([]string, 0)
+	if len(current) == 0 && act.Name == "updateauth" {
+		changes = append(changes, fmt.Sprintf("+ new permission %s@%s", update.Account, update.Permission))
+	}
+	if act.Name == "deleteauth" {
+		changes = append(changes, fmt.Sprintf("- delete permission %s@%s", update.Account, update.Permission))
+	}
+	inProposed := make(map[string]bool)
+	for _, e := range proposed {
+		inProposed[e] = true
+	}
+	inCurrent := make(map[string]bool)
+	for _, e := range current {
+		inCurrent[e] = true
+		if !inProposed[e] {
+			changes = append(changes, "- "+e)
+		}
+	}
+	for _, e := range proposed {
+		if !inCurrent[e] {
+			changes = append(changes, "+ "+e)
+		}
+	}
+	return changes, nil
+}
+
+// Text renders the review as plain text
+func (r *ProposalReview) Text() string {
+	return r.render(false)
+}
+
+// Markdown renders the review as Markdown
+func (r *ProposalReview) Markdown() string {
+	return r.render(true)
+}
+
+// WriteTo writes the review as Markdown
+func (r *ProposalReview) WriteTo(w io.Writer) (int64, error) {
+	n, err := io.WriteString(w, r.Markdown())
+	return int64(n), err
+}
+
+// reviewMarkdownEscaped are characters that could change the formatting of a Markdown table cell
+const reviewMarkdownEscaped = "\\`*_[]<>|~#&!"
+
+// reviewEscapeText makes control characters, and bidi overrides that reorder text, visible so that a value (such as a memo)
+// can't add lines or disguise itself in a review
+func reviewEscapeText(s string) string {
+	b := strings.Builder{}
+	for _, c := range s {
+		if unicode.IsControl(c) || unicode.Is(unicode.Bidi_Control, c) || c == '\u2028' || c == '\u2029' {
+			q := strconv.QuoteRune(c)
+			b.WriteString(q[1 : len(q)-1])
+			continue
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// reviewEscapeMarkdown escapes Markdown syntax, and control characters, for a table cell
+func reviewEscapeMarkdown(s string) string {
+	b := strings.Builder{}
+	for _, c := range s {
+		if strings.ContainsRune(reviewMarkdownEscaped, c) {
+			b.WriteRune('\\')
+		}
+		b.WriteRune(c)
+	}
+	return reviewEscapeText(b.String())
+}
+
+func (r *ProposalReview) render(markdown bool) string {
+	p := r.Proposal
+	buf := bytes.NewBufferString("")
+	heading := func(level int, s string) {
+		if markdown {
+			buf.WriteString(strings.Repeat("#", level) + " " + s + "\n\n")
+			return
+		}
+		buf.WriteString(s + "\n" + strings.Repeat("=-~"[level-1:level], len(s)) + "\n")
+	}
+	item := func(s string) {
+		if markdown {
+			buf.WriteString("- " + s + "\n")
+			return
+		}
+		buf.WriteString("  " + s + "\n")
+	}
+
+	heading(1, fmt.Sprintf("Proposal %s by %s", p.Name, r.actor(p.Proposer)))
+	item(fmt.Sprintf("Proposal hash: %s", p.Hash.String()))
+	item(fmt.Sprintf("Expires: %s", p.Transaction.Expiration.Format("2006-01-02 15:04:05 MST")))
+	buf.WriteString("\n")
+
+	heading(2, "Approvals")
+	for _, a := range p.Provided {
+		item(fmt.Sprintf("approved: %s", r.level(a.Level)))
+	}
+	for _, a := range p.Requested {
+		item(fmt.Sprintf("pending: %s", r.level(a.Level)))
+	}
+	for _, a := range r.Auths {
+		status := "threshold NOT met"
+		if a.Satisfied {
+			status = "threshold met"
+		}
+		s := fmt.Sprintf("%s@%s: %s, weight %d of %d", a.Level.Actor, a.Level.Permission, status, a.Weight, a.Threshold)
+		if !a.Satisfied && len(a.Missing) > 0 {
+			missing := make([]string, 0, len(a.Missing))
+			for _, m := range a.Missing {
+				missing = append(missing, fmt.Sprintf("%s@%s", m.Actor, m.Permission))
+			}
+			sort.Strings(missing)
+			s += ", not approved by " + strings.Join(missing, ", ")
+		}
+		item(s)
+	}
+	if r.ThresholdError != nil {
+		item(fmt.Sprintf("thresholds could not be checked: %s", r.ThresholdError))
+	}
+	buf.WriteString("\n")
+
+	heading(2, "Actions")
+	for i, ra := range r.Actions {
+		act := ra.Action
+		heading(3, fmt.Sprintf("%d. %s::%s", i+1, act.Account, act.Name))
+		for _, level := range act.Authorization {
+			item(fmt.Sprintf("authorized by %s", r.level(PermissionLevel(level))))
+		}
+		buf.WriteString("\n")
+		if act.DecodeError != "" {
+			item(fmt.Sprintf("could not decode data (%s): %s", reviewEscapeText(act.DecodeError), act.HexData.String()))
+			buf.WriteString("\n")
+			continue
+		}
+		if markdown && len(ra.Fields) > 0 {
+			buf.WriteString("| Field | Value |\n|---|---|\n")
+		}
+		for _, f := range ra.Fields {
+			if markdown {
+				buf.WriteString(fmt.Sprintf("| %s | %s |\n", reviewEscapeMarkdown(f.Name), reviewEscapeMarkdown(f.Value)))
+			} else {
+				item(fmt.Sprintf("%s: %s", reviewEscapeText(f.Name), reviewEscapeText(f.Value)))
+			}
+		}
+		buf.WriteString("\n")
+		if len(ra.AuthChanges) > 0 {
+			if markdown {
+				buf.WriteString("Permission changes:\n\n```diff\n" + strings.Join(ra.AuthChanges, "\n") + "\n```\n\n")
+			} else {
+				buf.WriteString("  Permission changes:\n")
+				for _, c := range ra.AuthChanges {
+					buf.WriteString("    " + c + "\n")
+				}
+				buf.WriteString("\n")
+			}
+		}
+	}
+	return buf.String()
+}
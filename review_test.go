@@ -0,0 +1,147 @@
+package fio
+
+import (
+	"encoding/json"
+	"github.com/fioprotocol/fio-go/eos"
+	"strings"
+	"testing"
+)
+
+func TestProposalReview(t *testing.T) {
+	srv := proposalTestServer(t)
+	defer srv.Close()
+	api := &API{API: eos.New(srv.URL)}
+
+	r, err := api.ReviewProposal("alice", "prop1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Actions) != 1 || len(r.Actions[0].Fields) != 2 {
+		t.Fatal("unexpected actions", r.Actions)
+	}
+	want := []ReviewField{{"to", "bob"}, {"amount", "0.000000005 FIO (5 SUF)"}}
+	for i, f := range r.Actions[0].Fields {
+		if f != want[i] {
+			t.Errorf("got field %+v, want %+v", f, want[i])
+		}
+	}
+	if names := r.Names["alice"]; len(names) != 1 || names[0] != "alice@fiotestnet" {
+		t.Error("did not find fio address for alice", names)
+	}
+
+	md := r.Markdown()
+	for _, s := range []string{
+		"# Proposal prop1 by alice (alice@fiotestnet)",
+		"- approved: alice (alice@fiotestnet)@active",
+		"- pending: carol@active",
+		"multisig@active: threshold NOT met, weight 1 of 2, not approved by bob@active, carol@active",
+		"### 1. fio.token::transfer",
+		"| amount | 0.000000005 FIO (5 SUF) |",
+	} {
+		if !strings.Contains(md, s) {
+			t.Errorf("markdown is missing %q:\n%s", s, md)
+		}
+	}
+	if text := r.Text(); !strings.Contains(text, "  amount: 0.000000005 FIO (5 SUF)") {
+		t.Errorf("unexpected text:\n%s", text)
+	}
+
+	fields, err := r.fields(json.RawMessage(`{"periods":[{"duration":10,"amount":"1500000000"}],"memo":null}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []ReviewField{{"periods.0.duration", "10"}, {"periods.0.amount", "1.500000000 FIO (1500000000 SUF)"}, {"memo", "null"}}
+	if len(fields) != len(want) {
+		t.Fatal("unexpected fields", fields)
+	}
+	for i := range fields {
+		if fields[i] != want[i] {
+			t.Errorf("got field %+v, want %+v", fields[i], want[i])
+		}
+	}
+}
+
+func TestProposalReview_Escaping(t *testing.T) {
+	srv := proposalTestServer(t)
+	defer srv.Close()
+	api := &API{API: eos.New(srv.URL)}
+
+	p, err := api.GetProposal("alice", "prop1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := p.Review()
+	if err != nil {
+		t.Fatal(err)
+	}
+	memo := "pay |\r\n| amount | 1000.000000000 FIO |\n\n# Approved\n`code` <b>x</b> [link](https://example.com) \u202edesrever"
+	r.Actions[0].Fields = append(r.Actions[0].Fields, ReviewField{Name: "memo", Value: memo})
+
+	md := r.Markdown()
+	for _, line := range strings.Split(md, "\n") {
+		if strings.HasPrefix(line, "| amount | 1000") || strings.HasPrefix(line, "# Approved") {
+			t.Errorf("memo injected a line into the markdown: %q", line)
+		}
+	}
+	want := "| memo | pay \\|\\r\\n\\| amount \\| 1000.000000000 FIO \\|\\n\\n\\# Approved\\n\\`code\\` \\<b\\>x\\</b\\> " +
+		"\\[link\\](https://example.com) \\u202edesrever |"
+	if !strings.Contains(md, want) {
+		t.Errorf("memo was not escaped, want %s in:\n%s", want, md)
+	}
+	text := r.Text()
+	for _, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(line, "| amount") || strings.HasPrefix(line, "# Approved") {
+			t.Errorf("memo injected a line into the text: %q", line)
+		}
+	}
+	if !strings.Contains(text, `  memo: pay |\r\n| amount | 1000.000000000 FIO |\n\n# Approved\n`) {
+		t.Errorf("control characters were not escaped:\n%s", text)
+	}
+
+	// the actions are still reviewed when the thresholds can't be checked
+	p.resolver = nil
+	if r, err = p.Review(); err != nil {
+		t.Fatal(err)
+	}
+	if r.ThresholdError == nil || len(r.Actions) != 1 || len(r.Actions[0].Fields) != 2 {
+		t.Error("expected a threshold error and the decoded action", r.ThresholdError, r.Actions)
+	}
+	if md = r.Markdown(); !strings.Contains(md, "- thresholds could not be checked: ") || !strings.Contains(md, "| amount |") {
+		t.Errorf("threshold error was not reported inline:\n%s", md)
+	}
+}
+
+func TestProposalAuthChanges(t *testing.T) {
+	srv := proposalTestServer(t)
+	defer srv.Close()
+	api := &API{API: eos.New(srv.URL)}
+	p, err := api.GetProposal("alice", "prop1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changes, err := p.authChanges(&ProposalAction{Account: "eosio", Name: "updateauth", Data: json.RawMessage(`{
+		"account":"multisig","permission":"active","parent":"owner","auth":{"threshold":2,
+		"keys":[{"key":"EOS6m1fMdTpRkRBnedvYshXCxLFiC5suRU8KDfx8xxtXp2hntxpnf","weight":1}],
+		"accounts":[{"permission":{"actor":"alice","permission":"active"},"weight":1},
+			{"permission":{"actor":"bob","permission":"active"},"weight":1}]}}`)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"- account carol@active weight 1",
+		"+ key FIO6m1fMdTpRkRBnedvYshXCxLFiC5suRU8KDfx8xxtXp2hntxpnf weight 1",
+	}
+	if strings.Join(changes, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got changes %q, want %q", changes, want)
+	}
+
+	changes, err = p.authChanges(&ProposalAction{Account: "eosio", Name: "deleteauth", Data: json.RawMessage(`{
+		"account":"multisig","permission":"active"}`)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 6 || changes[0] != "- delete permission multisig@active" {
+		t.Errorf("unexpected changes %q", changes)
+	}
+}
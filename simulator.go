@@ -0,0 +1,605 @@
+package fio
+
+import (
+	"errors"
+	"fmt"
+	"github.com/fioprotocol/fio-go/eos"
+	"strings"
+	"time"
+)
+
+const (
+	// SimBundleCount is the number of bundled transactions granted when an address is registered or renewed
+	SimBundleCount = 100
+	// SimRegistrationPeriod is how long an address or domain registration (or renewal) lasts
+	SimRegistrationPeriod = 365 * 24 * time.Hour
+	// simMaxPubAddresses is the limit of public addresses mapped to a single FIO address
+	simMaxPubAddresses = 100
+	// simMaxPubAddressesPerTx is the limit of public addresses in a single addaddress or remaddress action
+	simMaxPubAddressesPerTx = 5
+)
+
+// simBundledActions maps the actions that consume a bundled transaction to their fee endpoint
+var simBundledActions = map[string]string{
+	"addaddress": FeeAddPubAddress,
+	"remaddress": FeeRemovePubAddress,
+	"remalladdr": FeeRemoveAllAddresses,
+}
+
+// SimAccount is an account in the simulator's state
+type SimAccount struct {
+	Actor   eos.AccountName
+	PubKey  string
+	Balance uint64
+}
+
+// SimAddress is a FIO address in the simulator's state
+type SimAddress struct {
+	Address         string
+	Owner           eos.AccountName
+	Expiration      time.Time
+	Bundle          int
+	PublicAddresses []TokenPubAddr
+}
+
+// SimDomain is a FIO domain in the simulator's state
+type SimDomain struct {
+	Domain     string
+	Owner      eos.AccountName
+	Public     bool
+	Expiration time.Time
+}
+
+// SimResult describes what happened when an action was applied by the Simulator
+type SimResult struct {
+	Action  *Action
+	Actor   eos.AccountName
+	Fee     uint64 // fee charged in SUFs, zero when a bundled transaction was used
+	Bundled bool   // true if a bundled transaction was consumed instead of charging a fee
+	Effects []string
+}
+
+// Simulator is an in-process approximation of the core fio.address and fio.token contract logic. It applies
+// Actions built by this library to an in-memory state, allowing a batch of actions to be dry-run to see the
+// resulting balances and names before anything is signed or broadcast. Only the actions listed in Supported are
+// handled; locked tokens, staking, and voting are not simulated.
+//
+// A Simulator is not safe for concurrent use.
+type Simulator struct {
+	// Time is used for expiration checks and new registrations, it defaults to the current time
+	Time time.Time
+	// Fees is the fee in SUFs for each endpoint, see the Fee* constants
+	Fees map[string]uint64
+
+	accounts  map[eos.AccountName]*SimAccount
+	addresses map[string]*SimAddress
+	domains   map[string]*SimDomain
+}
+
+// NewSimulator creates an empty Simulator, fees are copied from the current max fees, see RefreshFees.
+func NewSimulator() *Simulator {
+	sim := &Simulator{
+		Time:      time.Now().UTC(),
+		Fees:      make(map[string]uint64),
+		accounts:  make(map[eos.AccountName]*SimAccount),
+		addresses: make(map[string]*SimAddress),
+		domains:   make(map[string]*SimDomain),
+	}
+	for _, f := range GetMaxFees() {
+		sim.Fees[f.EndPoint] = uint64(f.Value)
+	}
+	return sim
+}
+
+// Clone creates a deep copy of the simulator
+func (sim *Simulator) Clone() *Simulator {
+	c := &Simulator{
+		Time:      sim.Time,
+		Fees:      make(map[string]uint64),
+		accounts:  make(map[eos.AccountName]*SimAccount),
+		addresses: make(map[string]*SimAddress),
+		domains:   make(map[string]*SimDomain),
+	}
+	for k, v := range sim.Fees {
+		c.Fees[k] = v
+	}
+	for k, v := range sim.accounts {
+		a := *v
+		c.accounts[k] = &a
+	}
+	for k, v := range sim.addresses {
+		a := *v
+		a.PublicAddresses = append([]TokenPubAddr{}, v.PublicAddresses...)
+		c.addresses[k] = &a
+	}
+	for k, v := range sim.domains {
+		d := *v
+		c.domains[k] = &d
+	}
+	return c
+}
+
+// AddAccount adds an account with a balance in SUFs, or updates the balance if it already exists.
+func (sim *Simulator) AddAccount(pubKey string, balance uint64) (eos.AccountName, error) {
+	acc, err := sim.account(pubKey)
+	if err != nil {
+		return "", err
+	}
+	acc.Balance = balance
+	return acc.Actor, nil
+}
+
+// AddDomain adds an existing domain to the state
+func (sim *Simulator) AddDomain(domain string, ownerPubKey string, public bool, expiration time.Time) error {
+	owner, err := sim.account(ownerPubKey)
+	if err != nil {
+		return err
+	}
+	domain = strings.ToLower(domain)
+	sim.domains[domain] = &SimDomain{
+		Domain:     domain,
+		Owner:      owner.Actor,
+		Public:     public,
+		Expiration: expiration,
+	}
+	return nil
+}
+
+// AddAddress adds an existing FIO address to the state, the domain must already exist.
+func (sim *Simulator) AddAddress(address Address, ownerPubKey string, bundle int, expiration time.Time) error {
+	if !address.Valid() {
+		return errors.New("invalid fio address")
+	}
+	if sim.domains[address.domain()] == nil {
+		return fmt.Errorf("domain %s does not exist", address.domain())
+	}
+	owner, err := sim.account(ownerPubKey)
+	if err != nil {
+		return err
+	}
+	sim.addresses[strings.ToLower(string(address))] = &SimAddress{
+		Address:         strings.ToLower(string(address)),
+		Owner:           owner.Actor,
+		Expiration:      expiration,
+		Bundle:          bundle,
+		PublicAddresses: []TokenPubAddr{{TokenCode: "FIO", ChainCode: "FIO", PublicAddress: ownerPubKey}},
+	}
+	return nil
+}
+
+// Balance returns an account's balance in SUFs
+func (sim *Simulator) Balance(actor eos.AccountName) uint64 {
+	if acc := sim.accounts[actor]; acc != nil {
+		return acc.Balance
+	}
+	return 0
+}
+
+// Account returns a copy of an account's state
+func (sim *Simulator) Account(actor eos.AccountName) (account SimAccount, ok bool) {
+	if acc := sim.accounts[actor]; acc != nil {
+		return *acc, true
+	}
+	return SimAccount{}, false
+}
+
+// Address returns a copy of a FIO address' state
+func (sim *Simulator) Address(address Address) (fioAddress SimAddress, ok bool) {
+	a := sim.addresses[strings.ToLower(string(address))]
+	if a == nil {
+		return SimAddress{}, false
+	}
+	fioAddress = *a
+	fioAddress.PublicAddresses = append([]TokenPubAddr{}, a.PublicAddresses...)
+	return fioAddress, true
+}
+
+// Domain returns a copy of a domain's state
+func (sim *Simulator) Domain(domain string) (fioDomain SimDomain, ok bool) {
+	d := sim.domains[strings.ToLower(domain)]
+	if d == nil {
+		return SimDomain{}, false
+	}
+	return *d, true
+}
+
+// Supported lists the actions (as contract::action) that the simulator is able to apply
+func (sim *Simulator) Supported() []string {
+	return []string{
+		"fio.address::addaddress",
+		"fio.address::regaddress",
+		"fio.address::regdomain",
+		"fio.address::remaddress",
+		"fio.address::remalladdr",
+		"fio.address::renewaddress",
+		"fio.address::renewdomain",
+		"fio.token::trnsfiopubky",
+	}
+}
+
+// Apply runs a batch of actions as a single transaction: if any action fails the state is left unchanged and the
+// error identifies the failing action.
+func (sim *Simulator) Apply(actions ...*Action) ([]*SimResult, error) {
+	tx := sim.Clone()
+	results := make([]*SimResult, 0, len(actions))
+	for i, act := range actions {
+		if act == nil {
+			return nil, fmt.Errorf("action %d is nil", i)
+		}
+		result, err := tx.apply(act)
+		if err != nil {
+			return nil, fmt.Errorf("action %d (%s::%s): %s", i, act.Account, act.Name, err.Error())
+		}
+		results = append(results, result)
+	}
+	*sim = *tx
+	return results, nil
+}
+
+// DryRun applies the actions to a copy of the state, returning the results and the resulting state without
+// modifying the Simulator.
+func (sim *Simulator) DryRun(actions ...*Action) ([]*SimResult, *Simulator, error) {
+	c := sim.Clone()
+	results, err := c.Apply(actions...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return results, c, nil
+}
+
+// account finds or creates the account for a public key
+func (sim *Simulator) account(pubKey string) (*SimAccount, error) {
+	actor, err := ActorFromPub(pubKey)
+	if err != nil {
+		return nil, err
+	}
+	if sim.accounts[actor] == nil {
+		sim.accounts[actor] = &SimAccount{Actor: actor, PubKey: pubKey}
+	}
+	return sim.accounts[actor], nil
+}
+
+// decodeActionData gets the action's data as the concrete struct, regardless of whether it was built with a value,
+// a pointer, or is only available as serialized hex data.
+func decodeActionData(act *Action, v interface{}) error {
+	data := []byte(act.HexData)
+	if len(data) == 0 {
+		if act.Data == nil {
+			return errors.New("action has no data")
+		}
+		var err error
+		data, err = eos.MarshalBinary(act.Data)
+		if err != nil {
+			return err
+		}
+	}
+	return eos.UnmarshalBinary(data, v)
+}
+
+// domain returns the lower-case domain portion of an address
+func (a Address) domain() string {
+	parts := strings.Split(strings.ToLower(string(a)), "@")
+	return parts[len(parts)-1]
+}
+
+func simFio(suf uint64) string {
+	return Amount(suf).String()
+}
+
+// charge deducts a fee from the actor, or a bundled transaction from the address if one is provided and available.
+func (sim *Simulator) charge(result *SimResult, endpoint string, maxFee uint64, bundleAddress *SimAddress) error {
+	if bundleAddress != nil && bundleAddress.Bundle > 0 {
+		bundleAddress.Bundle -= 1
+		result.Bundled = true
+		result.Effects = append(result.Effects, fmt.Sprintf("uses 1 bundled transaction from %s, %d remaining", bundleAddress.Address, bundleAddress.Bundle))
+		return nil
+	}
+	fee, ok := sim.Fees[endpoint]
+	if !ok {
+		return fmt.Errorf("no fee found for endpoint %s", endpoint)
+	}
+	if fee > maxFee {
+		return fmt.Errorf("fee exceeds supplied maximum: fee %s > max_fee %s", simFio(fee), simFio(maxFee))
+	}
+	acc := sim.accounts[result.Actor]
+	if acc == nil || acc.Balance < fee {
+		return fmt.Errorf("insufficient funds to cover fee of %s", simFio(fee))
+	}
+	acc.Balance -= fee
+	result.Fee = fee
+	if fee > 0 {
+		result.Effects = append(result.Effects, fmt.Sprintf("%s pays fee of %s", result.Actor, simFio(fee)))
+	}
+	return nil
+}
+
+// ownedAddress gets an address that must exist, be unexpired, and belong to the actor
+func (sim *Simulator) ownedAddress(address string, actor eos.AccountName) (*SimAddress, error) {
+	a := sim.addresses[strings.ToLower(address)]
+	if a == nil {
+		return nil, fmt.Errorf("fio address %s is not registered", address)
+	}
+	if a.Expiration.Before(sim.Time) {
+		return nil, fmt.Errorf("fio address %s is expired", address)
+	}
+	if a.Owner != actor {
+		return nil, fmt.Errorf("%s does not own fio address %s", actor, address)
+	}
+	return a, nil
+}
+
+func (sim *Simulator) apply(act *Action) (*SimResult, error) {
+	result := &SimResult{Action: act, Effects: make([]string, 0)}
+	if len(act.Authorization) > 0 {
+		result.Actor = act.Authorization[0].Actor
+	}
+	switch string(act.Account) + "::" + string(act.Name) {
+	case "fio.address::regaddress":
+		return result, sim.regAddress(act, result)
+	case "fio.address::regdomain":
+		return result, sim.regDomain(act, result)
+	case "fio.address::renewaddress":
+		return result, sim.renewAddress(act, result)
+	case "fio.address::renewdomain":
+		return result, sim.renewDomain(act, result)
+	case "fio.address::addaddress":
+		return result, sim.addAddress(act, result)
+	case "fio.address::remaddress":
+		return result, sim.remAddress(act, result)
+	case "fio.address::remalladdr":
+		return result, sim.remAllAddr(act, result)
+	case "fio.token::trnsfiopubky":
+		return result, sim.transfer(act, result)
+	}
+	return nil, fmt.Errorf("simulator does not support %s::%s", act.Account, act.Name)
+}
+
+func (sim *Simulator) checkActor(result *SimResult, actor eos.AccountName) error {
+	if actor != result.Actor {
+		return fmt.Errorf("actor %s does not match authorization %s", actor, result.Actor)
+	}
+	if sim.accounts[actor] == nil {
+		return fmt.Errorf("account %s does not exist", actor)
+	}
+	return nil
+}
+
+func (sim *Simulator) regAddress(act *Action, result *SimResult) error {
+	ra := RegAddress{}
+	if err := decodeActionData(act, &ra); err != nil {
+		return err
+	}
+	if err := sim.checkActor(result, ra.Actor); err != nil {
+		return err
+	}
+	address := Address(strings.ToLower(ra.FioAddress))
+	if !address.Valid() {
+		return fmt.Errorf("invalid fio address %s", ra.FioAddress)
+	}
+	if sim.addresses[string(address)] != nil {
+		return fmt.Errorf("fio address %s is already registered", address)
+	}
+	d := sim.domains[address.domain()]
+	if d == nil {
+		return fmt.Errorf("domain %s is not registered", address.domain())
+	}
+	if d.Expiration.Before(sim.Time) {
+		return fmt.Errorf("domain %s is expired", d.Domain)
+	}
+	if !d.Public && d.Owner != ra.Actor {
+		return fmt.Errorf("domain %s is not public, only the owner may register addresses", d.Domain)
+	}
+	if err := sim.charge(result, FeeRegisterFioAddress, ra.MaxFee, nil); err != nil {
+		return err
+	}
+	owner, err := sim.account(ra.OwnerFioPublicKey)
+	if err != nil {
+		return err
+	}
+	sim.addresses[string(address)] = &SimAddress{
+		Address:         string(address),
+		Owner:           owner.Actor,
+		Expiration:      sim.Time.Add(SimRegistrationPeriod),
+		Bundle:          SimBundleCount,
+		PublicAddresses: []TokenPubAddr{{TokenCode: "FIO", ChainCode: "FIO", PublicAddress: ra.OwnerFioPublicKey}},
+	}
+	result.Effects = append(result.Effects, fmt.Sprintf("registers %s to %s with %d bundled transactions", address, owner.Actor, SimBundleCount))
+	return nil
+}
+
+func (sim *Simulator) regDomain(act *Action, result *SimResult) error {
+	rd := RegDomain{}
+	if err := decodeActionData(act, &rd); err != nil {
+		return err
+	}
+	if err := sim.checkActor(result, rd.Actor); err != nil {
+		return err
+	}
+	domain, err := ValidateDomain(rd.FioDomain)
+	if err != nil {
+		return err
+	}
+	if sim.domains[domain] != nil {
+		return fmt.Errorf("domain %s is already registered", domain)
+	}
+	if err = sim.charge(result, FeeRegisterFioDomain, rd.MaxFee, nil); err != nil {
+		return err
+	}
+	owner, err := sim.account(rd.OwnerFioPublicKey)
+	if err != nil {
+		return err
+	}
+	sim.domains[domain] = &SimDomain{
+		Domain:     domain,
+		Owner:      owner.Actor,
+		Expiration: sim.Time.Add(SimRegistrationPeriod),
+	}
+	result.Effects = append(result.Effects, fmt.Sprintf("registers domain %s to %s", domain, owner.Actor))
+	return nil
+}
+
+func (sim *Simulator) renewAddress(act *Action, result *SimResult) error {
+	ra := RenewAddress{}
+	if err := decodeActionData(act, &ra); err != nil {
+		return err
+	}
+	if err := sim.checkActor(result, ra.Actor); err != nil {
+		return err
+	}
+	a := sim.addresses[strings.ToLower(ra.FioAddress)]
+	if a == nil {
+		return fmt.Errorf("fio address %s is not registered", ra.FioAddress)
+	}
+	if err := sim.charge(result, FeeRenewFioAddress, ra.MaxFee, nil); err != nil {
+		return err
+	}
+	a.Expiration = a.Expiration.Add(SimRegistrationPeriod)
+	a.Bundle += SimBundleCount
+	result.Effects = append(result.Effects, fmt.Sprintf("renews %s until %s, adds %d bundled transactions", a.Address,
+		a.Expiration.Format(eos.JSONTimeFormat), SimBundleCount))
+	return nil
+}
+
+func (sim *Simulator) renewDomain(act *Action, result *SimResult) error {
+	rd := RenewDomain{}
+	if err := decodeActionData(act, &rd); err != nil {
+		return err
+	}
+	if err := sim.checkActor(result, rd.Actor); err != nil {
+		return err
+	}
+	d := sim.domains[strings.ToLower(rd.FioDomain)]
+	if d == nil {
+		return fmt.Errorf("domain %s is not registered", rd.FioDomain)
+	}
+	if err := sim.charge(result, FeeRenewFioDomain, rd.MaxFee, nil); err != nil {
+		return err
+	}
+	d.Expiration = d.Expiration.Add(SimRegistrationPeriod)
+	result.Effects = append(result.Effects, fmt.Sprintf("renews domain %s until %s", d.Domain, d.Expiration.Format(eos.JSONTimeFormat)))
+	return nil
+}
+
+func (sim *Simulator) addAddress(act *Action, result *SimResult) error {
+	aa := AddAddress{}
+	if err := decodeActionData(act, &aa); err != nil {
+		return err
+	}
+	if err := sim.checkActor(result, aa.Actor); err != nil {
+		return err
+	}
+	a, err := sim.ownedAddress(aa.FioAddress, aa.Actor)
+	if err != nil {
+		return err
+	}
+	if len(aa.PublicAddresses) == 0 || len(aa.PublicAddresses) > simMaxPubAddressesPerTx {
+		return fmt.Errorf("between 1 and %d public addresses may be added at a time", simMaxPubAddressesPerTx)
+	}
+	for _, pa := range aa.PublicAddresses {
+		if pa.ChainCode == "" || pa.TokenCode == "" || pa.PublicAddress == "" {
+			return errors.New("chain code, token code, and public address are required")
+		}
+		replaced := false
+		for i := range a.PublicAddresses {
+			if strings.EqualFold(a.PublicAddresses[i].ChainCode, pa.ChainCode) && strings.EqualFold(a.PublicAddresses[i].TokenCode, pa.TokenCode) {
+				a.PublicAddresses[i].PublicAddress = pa.PublicAddress
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			a.PublicAddresses = append(a.PublicAddresses, pa)
+		}
+		result.Effects = append(result.Effects, fmt.Sprintf("maps %s %s:%s to %s", a.Address, pa.ChainCode, pa.TokenCode, pa.PublicAddress))
+	}
+	if len(a.PublicAddresses) > simMaxPubAddresses {
+		return fmt.Errorf("a fio address may only have %d public addresses", simMaxPubAddresses)
+	}
+	return sim.charge(result, simBundledActions["addaddress"], aa.MaxFee, a)
+}
+
+func (sim *Simulator) remAddress(act *Action, result *SimResult) error {
+	ra := RemoveAddrReq{}
+	if err := decodeActionData(act, &ra); err != nil {
+		return err
+	}
+	if err := sim.checkActor(result, ra.Actor); err != nil {
+		return err
+	}
+	a, err := sim.ownedAddress(ra.FioAddress, ra.Actor)
+	if err != nil {
+		return err
+	}
+	if len(ra.PublicAddresses) == 0 || len(ra.PublicAddresses) > simMaxPubAddressesPerTx {
+		return fmt.Errorf("between 1 and %d public addresses may be removed at a time", simMaxPubAddressesPerTx)
+	}
+	for _, pa := range ra.PublicAddresses {
+		found := false
+		for i := range a.PublicAddresses {
+			existing := a.PublicAddresses[i]
+			if strings.EqualFold(existing.ChainCode, pa.ChainCode) && strings.EqualFold(existing.TokenCode, pa.TokenCode) &&
+				existing.PublicAddress == pa.PublicAddress {
+				a.PublicAddresses = append(a.PublicAddresses[:i], a.PublicAddresses[i+1:]...)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("public address %s:%s %s is not mapped to %s", pa.ChainCode, pa.TokenCode, pa.PublicAddress, a.Address)
+		}
+		result.Effects = append(result.Effects, fmt.Sprintf("removes %s:%s from %s", pa.ChainCode, pa.TokenCode, a.Address))
+	}
+	return sim.charge(result, simBundledActions["remaddress"], ra.MaxFee, a)
+}
+
+func (sim *Simulator) remAllAddr(act *Action, result *SimResult) error {
+	ra := RemoveAllAddrReq{}
+	if err := decodeActionData(act, &ra); err != nil {
+		return err
+	}
+	if err := sim.checkActor(result, ra.Actor); err != nil {
+		return err
+	}
+	a, err := sim.ownedAddress(ra.FioAddress, ra.Actor)
+	if err != nil {
+		return err
+	}
+	// the FIO public key mapping is retained
+	kept := make([]TokenPubAddr, 0)
+	for _, pa := range a.PublicAddresses {
+		if pa.ChainCode == "FIO" && pa.TokenCode == "FIO" {
+			kept = append(kept, pa)
+		}
+	}
+	result.Effects = append(result.Effects, fmt.Sprintf("removes %d public addresses from %s", len(a.PublicAddresses)-len(kept), a.Address))
+	a.PublicAddresses = kept
+	return sim.charge(result, simBundledActions["remalladdr"], ra.MaxFee, a)
+}
+
+func (sim *Simulator) transfer(act *Action, result *SimResult) error {
+	tt := TransferTokensPubKey{}
+	if err := decodeActionData(act, &tt); err != nil {
+		return err
+	}
+	if err := sim.checkActor(result, tt.Actor); err != nil {
+		return err
+	}
+	if tt.Amount == 0 {
+		return errors.New("invalid amount")
+	}
+	if err := sim.charge(result, FeeTransferTokensPubKey, tt.MaxFee, nil); err != nil {
+		return err
+	}
+	from := sim.accounts[tt.Actor]
+	if from.Balance < tt.Amount {
+		return fmt.Errorf("insufficient balance to transfer %s", simFio(tt.Amount))
+	}
+	to, err := sim.account(tt.PayeePublicKey)
+	if err != nil {
+		return err
+	}
+	from.Balance -= tt.Amount
+	to.Balance += tt.Amount
+	result.Effects = append(result.Effects, fmt.Sprintf("transfers %s from %s to %s", simFio(tt.Amount), tt.Actor, to.Actor))
+	return nil
+}
@@ -0,0 +1,81 @@
+package fio
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSimulator(t *testing.T) {
+	alice, err := NewRandomAccount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	bob, err := NewRandomAccount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sim := NewSimulator()
+	sim.Fees[FeeRegisterFioDomain] = Tokens(800)
+	sim.Fees[FeeRegisterFioAddress] = Tokens(40)
+	sim.Fees[FeeAddPubAddress] = Tokens(0.4)
+	sim.Fees[FeeTransferTokensPubKey] = Tokens(2)
+	sim.Fees[FeeRenewFioDomain] = Tokens(1)
+	if _, err = sim.AddAccount(alice.PubKey, Tokens(1000)); err != nil {
+		t.Fatal(err)
+	}
+
+	addBtc, _ := NewAddAddress(alice.Actor, "alice@sim", "BTC", "BTC", "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4")
+	regAddr, _ := NewRegAddress(alice.Actor, "alice@sim", alice.PubKey)
+	results, err := sim.Apply(
+		NewRegDomain(alice.Actor, "sim", alice.PubKey),
+		regAddr,
+		addBtc,
+		NewTransferTokensPubKey(alice.Actor, bob.PubKey, Tokens(100)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 4 || !results[2].Bundled || results[2].Fee != 0 || results[1].Fee != Tokens(40) {
+		t.Error("unexpected results", results)
+	}
+	if sim.Balance(alice.Actor) != Tokens(1000-800-40-2-100) || sim.Balance(bob.Actor) != Tokens(100) {
+		t.Error("wrong balances", sim.Balance(alice.Actor), sim.Balance(bob.Actor))
+	}
+	a, ok := sim.Address("alice@sim")
+	if !ok || a.Bundle != SimBundleCount-1 || len(a.PublicAddresses) != 2 || a.Owner != alice.Actor {
+		t.Error("address not updated", a)
+	}
+
+	// domain is private, bob cannot register and alice's state is rolled back
+	before := sim.Balance(alice.Actor)
+	bobAddr, _ := NewRegAddress(bob.Actor, "bob@sim", bob.PubKey)
+	_, err = sim.Apply(NewRenewDomain(alice.Actor, "sim"), bobAddr)
+	if err == nil || !strings.Contains(err.Error(), "not public") {
+		t.Error("expected private domain error", err)
+	}
+	if sim.Balance(alice.Actor) != before {
+		t.Error("failed batch modified state")
+	}
+
+	// dry run does not modify the simulator
+	_, after, err := sim.DryRun(NewTransferTokensPubKey(bob.Actor, alice.PubKey, Tokens(10)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.Balance(bob.Actor) != Tokens(88) || sim.Balance(bob.Actor) != Tokens(100) {
+		t.Error("dry run should only modify the copy")
+	}
+
+	// expired addresses cannot be updated
+	sim.Time = time.Now().Add(2 * SimRegistrationPeriod)
+	if _, err = sim.Apply(addBtc); err == nil {
+		t.Error("expected expired address error")
+	}
+	if _, err = sim.Apply(NewTransferTokensPubKey(bob.Actor, alice.PubKey, Tokens(1000))); err == nil {
+		t.Error("expected insufficient funds")
+	}
+	if _, err = sim.Apply(NewBurnExpired(bob.Actor)); err == nil {
+		t.Error("expected unsupported action")
+	}
+}
@@ -0,0 +1,252 @@
+package fio
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/fioprotocol/fio-go/eos"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TableSnapshot is a copy of every row in a contract table, which can be saved to disk and compared with a later
+// snapshot using DiffTableSnapshots.
+type TableSnapshot struct {
+	Code      string                     `json:"code"`
+	Table     string                     `json:"table"`
+	Scopes    []string                   `json:"scopes"`
+	KeyField  string                     `json:"key_field,omitempty"` // the field used to identify a row, empty if a hash of the row is used
+	Time      time.Time                  `json:"time"`
+	HeadBlock uint32                     `json:"head_block"`
+	Rows      map[string]json.RawMessage `json:"rows"` // keyed by "scope/key"
+}
+
+// Change types used in a RowChange
+const (
+	RowInserted = "insert"
+	RowUpdated  = "update"
+	RowDeleted  = "delete"
+)
+
+// RowChange is a difference between two snapshots of the same table
+type RowChange struct {
+	Change string          `json:"change"` // one of RowInserted, RowUpdated or RowDeleted
+	Key    string          `json:"key"`
+	Fields []string        `json:"fields,omitempty"` // top-level fields that changed, for updates
+	Old    json.RawMessage `json:"old,omitempty"`
+	New    json.RawMessage `json:"new,omitempty"`
+}
+
+func (rc RowChange) String() string {
+	switch rc.Change {
+	case RowInserted:
+		return fmt.Sprintf("+ %s %s", rc.Key, string(rc.New))
+	case RowDeleted:
+		return fmt.Sprintf("- %s %s", rc.Key, string(rc.Old))
+	}
+	return fmt.Sprintf("~ %s changed %s: %s -> %s", rc.Key, strings.Join(rc.Fields, ", "), string(rc.Old), string(rc.New))
+}
+
+// SnapshotTable reads every row of a table. If no scopes are provided the scope from the table schema is used,
+// or if the table isn't known, every scope is found using GetTableByScopeMore. Rows are identified by the primary
+// key field from the table schema, or by a hash of the row's contents for unknown tables.
+func (api *API) SnapshotTable(code string, table string, scopes ...string) (*TableSnapshot, error) {
+	info, err := api.GetInfo()
+	if err != nil {
+		return nil, err
+	}
+	snap := &TableSnapshot{
+		Code:      code,
+		Table:     table,
+		Time:      time.Now().UTC(),
+		HeadBlock: info.HeadBlockNum,
+		Rows:      make(map[string]json.RawMessage),
+	}
+	schema, _ := GetTableSchema(code, table)
+	if schema != nil {
+		for field, idx := range schema.Indexes {
+			if idx.Position == 1 {
+				snap.KeyField = field
+			}
+		}
+	}
+	if len(scopes) == 0 && schema != nil && schema.Scope != "" {
+		scopes = []string{schema.Scope}
+	}
+	if len(scopes) == 0 {
+		if scopes, err = api.tableScopes(code, table); err != nil {
+			return nil, err
+		}
+	}
+	snap.Scopes = scopes
+
+	for _, scope := range scopes {
+		rows := make([]json.RawMessage, 0)
+		if err = NewTableQuery(api, code, table).Scope(scope).Limit(1000).All(&rows); err != nil {
+			return nil, fmt.Errorf("reading %s::%s scope %s: %s", code, table, scope, err)
+		}
+		for _, row := range rows {
+			key, normalized, err := snapshotRow(snap.KeyField, row)
+			if err != nil {
+				return nil, err
+			}
+			snap.Rows[scope+"/"+key] = normalized
+		}
+	}
+	return snap, nil
+}
+
+// tableScopes lists every scope that has rows in a table
+func (api *API) tableScopes(code string, table string) ([]string, error) {
+	type scopeRow struct {
+		Scope string `json:"scope"`
+	}
+	seen := make(map[string]bool)
+	scopes := make([]string, 0)
+	lower := ""
+	for {
+		resp, err := api.GetTableByScopeMore(eos.GetTableByScopeRequest{
+			Code:       code,
+			Table:      table,
+			LowerBound: lower,
+			Limit:      1000,
+		})
+		if err != nil {
+			return nil, err
+		}
+		rows := make([]scopeRow, 0)
+		if err = json.Unmarshal(resp.Rows, &rows); err != nil {
+			return nil, err
+		}
+		added := 0
+		for _, r := range rows {
+			if !seen[r.Scope] {
+				seen[r.Scope] = true
+				scopes = append(scopes, r.Scope)
+				added += 1
+			}
+		}
+		// the lower bound is inclusive, so the last scope is repeated in the next request
+		if !resp.More || added == 0 {
+			break
+		}
+		lower = rows[len(rows)-1].Scope
+	}
+	return scopes, nil
+}
+
+// snapshotRow normalizes a row so that formatting differences are not reported as changes, and finds its key.
+func snapshotRow(keyField string, row json.RawMessage) (key string, normalized json.RawMessage, err error) {
+	var v interface{}
+	dec := json.NewDecoder(bytes.NewReader(row))
+	dec.UseNumber()
+	if err = dec.Decode(&v); err != nil {
+		return "", nil, err
+	}
+	// json.Marshal sorts map keys
+	if normalized, err = json.Marshal(v); err != nil {
+		return "", nil, err
+	}
+	if m, ok := v.(map[string]interface{}); ok && keyField != "" {
+		if k, ok := m[keyField]; ok {
+			return fmt.Sprint(k), normalized, nil
+		}
+	}
+	sum := sha256.Sum256(normalized)
+	return hex.EncodeToString(sum[:]), normalized, nil
+}
+
+// WriteTo saves the snapshot as JSON
+func (snap *TableSnapshot) WriteTo(w io.Writer) (int64, error) {
+	j, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(j)
+	return int64(n), err
+}
+
+// Save writes the snapshot to a file
+func (snap *TableSnapshot) Save(file string) error {
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = snap.WriteTo(f)
+	return err
+}
+
+// LoadTableSnapshot reads a snapshot from a file created with Save
+func LoadTableSnapshot(file string) (*TableSnapshot, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	snap := &TableSnapshot{}
+	if err = json.NewDecoder(f).Decode(snap); err != nil {
+		return nil, fmt.Errorf("reading snapshot %s: %s", file, err)
+	}
+	return snap, nil
+}
+
+// DiffTableSnapshots compares two snapshots of the same table, and returns the changes sorted by key.
+func DiffTableSnapshots(old *TableSnapshot, new *TableSnapshot) ([]RowChange, error) {
+	if old == nil || new == nil {
+		return nil, errors.New("snapshot is nil")
+	}
+	if old.Code != new.Code || old.Table != new.Table {
+		return nil, fmt.Errorf("cannot compare %s::%s with %s::%s", old.Code, old.Table, new.Code, new.Table)
+	}
+	if old.KeyField != new.KeyField {
+		return nil, fmt.Errorf("snapshots use different key fields: %q and %q", old.KeyField, new.KeyField)
+	}
+	changes := make([]RowChange, 0)
+	for key, oldRow := range old.Rows {
+		newRow, ok := new.Rows[key]
+		switch {
+		case !ok:
+			changes = append(changes, RowChange{Change: RowDeleted, Key: key, Old: oldRow})
+		case !bytes.Equal(oldRow, newRow):
+			changes = append(changes, RowChange{Change: RowUpdated, Key: key, Old: oldRow, New: newRow, Fields: changedFields(oldRow, newRow)})
+		}
+	}
+	for key, newRow := range new.Rows {
+		if _, ok := old.Rows[key]; !ok {
+			changes = append(changes, RowChange{Change: RowInserted, Key: key, New: newRow})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Key < changes[j].Key
+	})
+	return changes, nil
+}
+
+// changedFields lists the top-level fields that differ between two rows
+func changedFields(a json.RawMessage, b json.RawMessage) []string {
+	am := make(map[string]json.RawMessage)
+	bm := make(map[string]json.RawMessage)
+	if json.Unmarshal(a, &am) != nil || json.Unmarshal(b, &bm) != nil {
+		return nil
+	}
+	fields := make([]string, 0)
+	for k, v := range am {
+		if !bytes.Equal(v, bm[k]) {
+			fields = append(fields, k)
+		}
+	}
+	for k := range bm {
+		if _, ok := am[k]; !ok {
+			fields = append(fields, k)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
@@ -0,0 +1,98 @@
+package fio
+
+import (
+	"encoding/json"
+	"github.com/fioprotocol/fio-go/eos"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDiffTableSnapshots(t *testing.T) {
+	fees := `[{"fee_id":0,"end_point":"register_fio_domain","suf_amount":800000000000},{"fee_id":1,"end_point":"register_fio_address","suf_amount":40000000000}]`
+	scoped := map[string]string{
+		"alice": `[{"proposal_name":"one","packed_transaction":"00"}]`,
+		"bob":   `[{"proposal_name":"two","packed_transaction":"00"}]`,
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/chain/get_info":
+			_ = json.NewEncoder(w).Encode(&eos.InfoResp{HeadBlockNum: 100})
+		case "/v1/chain/get_table_by_scope":
+			_, _ = w.Write([]byte(`{"rows":[{"scope":"alice"},{"scope":"bob"}],"more":""}`))
+		case "/v1/chain/get_table_rows":
+			req := GetTableRowsOrderRequest{}
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			rows := fees
+			if req.Table == "proposal" {
+				rows = scoped[req.Scope]
+			}
+			_, _ = w.Write([]byte(`{"rows":` + rows + `,"more":false}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	api := &API{API: eos.New(srv.URL)}
+
+	before, err := api.SnapshotTable("fio.fee", "fiofees")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if before.KeyField != "fee_id" || before.HeadBlock != 100 || len(before.Rows) != 2 {
+		t.Fatal("unexpected snapshot", before)
+	}
+
+	dir, err := ioutil.TempDir("", "snapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "fiofees.json")
+	if err = before.Save(file); err != nil {
+		t.Fatal(err)
+	}
+	if before, err = LoadTableSnapshot(file); err != nil {
+		t.Fatal(err)
+	}
+
+	// fee 0 updated, 1 deleted, 2 inserted. Formatting changes should be ignored.
+	fees = `[{"suf_amount":900000000000, "end_point":"register_fio_domain","fee_id":0},{"fee_id":2,"end_point":"add_pub_address","suf_amount":400000000}]`
+	after, err := api.SnapshotTable("fio.fee", "fiofees")
+	if err != nil {
+		t.Fatal(err)
+	}
+	changes, err := DiffTableSnapshots(before, after)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 3 {
+		t.Fatal("expected 3 changes", changes)
+	}
+	if changes[0].Change != RowUpdated || changes[0].Key != "fio.fee/0" || len(changes[0].Fields) != 1 || changes[0].Fields[0] != "suf_amount" {
+		t.Error("expected update of suf_amount", changes[0])
+	}
+	if changes[1].Change != RowDeleted || changes[1].Key != "fio.fee/1" {
+		t.Error("expected delete", changes[1])
+	}
+	if changes[2].Change != RowInserted || changes[2].Key != "fio.fee/2" {
+		t.Error("expected insert", changes[2])
+	}
+	if unchanged, _ := DiffTableSnapshots(after, after); len(unchanged) != 0 {
+		t.Error("expected no changes", unchanged)
+	}
+
+	proposals, err := api.SnapshotTable("eosio.msig", "proposal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(proposals.Scopes) != 2 || proposals.Rows["alice/one"] == nil || proposals.Rows["bob/two"] == nil {
+		t.Error("expected rows from every scope", proposals.Scopes, proposals.Rows)
+	}
+	if _, err = DiffTableSnapshots(before, proposals); err == nil {
+		t.Error("should not compare different tables")
+	}
+}
@@ -0,0 +1,185 @@
+// Package supply serves FIO token supply figures for exchanges and market data sites.
+//
+// Calculating the locked supply requires scanning several large tables, so a Service computes the figures on a
+// schedule and serves the cached result. The plain-number endpoints return FIO with nine decimal places, which is the
+// format expected by aggregators such as CoinGecko:
+//
+//	/total        minted supply
+//	/circulating  minted supply less genesis and FIP-6 locked tokens
+//	/locked       genesis and FIP-6 locked tokens
+//	/supply.json  a breakdown of every value, in SUF
+//
+// Example:
+//
+//	api, _, err := fio.NewConnection(nil, "https://fio.example.com")
+//	s := supply.NewService(api, 10*time.Minute)
+//	go s.Run(ctx)
+//	log.Fatal(http.ListenAndServe(":8080", s))
+package supply
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/fioprotocol/fio-go"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Stats is a snapshot of the token supply, all amounts are exact
+type Stats struct {
+	Time            time.Time  `json:"time"`
+	Total           fio.Amount `json:"total"`
+	Circulating     fio.Amount `json:"circulating"`
+	Locked          fio.Amount `json:"locked"`
+	GenesisLocked   fio.Amount `json:"genesis_locked"`
+	FounderLocked   fio.Amount `json:"founder_locked"`
+	MemberLocked    fio.Amount `json:"member_locked"`
+	PresaleLocked   fio.Amount `json:"presale_locked"`
+	GiveawayLocked  fio.Amount `json:"giveaway_locked"`
+	LockTokens      fio.Amount `json:"lock_tokens"`       // FIP-6 locked tokens
+	LockedBpRewards fio.Amount `json:"locked_bp_rewards"` // informational, already included in circulating
+}
+
+// Compute queries the chain for the current supply, this is slow and makes many requests
+func Compute(api *fio.API) (*Stats, error) {
+	stats := &Stats{Time: time.Now().UTC()}
+	gcr, err := api.GetCurrencyStats("fio.token", "FIO")
+	if err != nil {
+		return nil, fmt.Errorf("getting currency stats: %s", err)
+	}
+	if gcr == nil {
+		return nil, errors.New("no currency stats for FIO")
+	}
+	if stats.Total, err = fio.ParseAmount(gcr.Supply.String()); err != nil {
+		return nil, err
+	}
+	genesis, founder, member, presale, giveaway, err := api.GetTotalGenesisLockTokens()
+	if err != nil {
+		return nil, fmt.Errorf("getting genesis locked tokens: %s", err)
+	}
+	stats.GenesisLocked, stats.FounderLocked, stats.MemberLocked = fio.Amount(genesis), fio.Amount(founder), fio.Amount(member)
+	stats.PresaleLocked, stats.GiveawayLocked = fio.Amount(presale), fio.Amount(giveaway)
+	lockTokens, err := api.GetTotalLockTokens()
+	if err != nil {
+		return nil, fmt.Errorf("getting locked tokens: %s", err)
+	}
+	stats.LockTokens = fio.Amount(lockTokens)
+	rewards, err := api.GetLockedBpRewards()
+	if err != nil {
+		return nil, fmt.Errorf("getting locked producer rewards: %s", err)
+	}
+	stats.LockedBpRewards = fio.Amount(rewards)
+
+	if stats.Locked, err = stats.GenesisLocked.Add(stats.LockTokens); err != nil {
+		return nil, err
+	}
+	if stats.Circulating, err = stats.Total.Sub(stats.Locked); err != nil {
+		return nil, fmt.Errorf("locked tokens exceed the supply: %s", err)
+	}
+	return stats, nil
+}
+
+// Service periodically updates the supply Stats and serves them over HTTP
+type Service struct {
+	Interval time.Duration
+	MaxAge   time.Duration // stats older than this are served with a 503, zero allows any age
+
+	api     *fio.API
+	compute func(api *fio.API) (*Stats, error)
+	mux     sync.RWMutex
+	stats   *Stats
+	err     error
+}
+
+// NewService creates a service that updates every interval, and serves stale stats for up to three intervals if
+// updates fail.
+func NewService(api *fio.API, interval time.Duration) *Service {
+	return &Service{
+		Interval: interval,
+		MaxAge:   3 * interval,
+		api:      api,
+		compute:  Compute,
+	}
+}
+
+// Update computes the stats, on error the previous stats are kept
+func (s *Service) Update() error {
+	stats, err := s.compute(s.api)
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.err = err
+	if err != nil {
+		return err
+	}
+	s.stats = stats
+	return nil
+}
+
+// Stats returns the last successful result, and the error from the last update if it failed
+func (s *Service) Stats() (*Stats, error) {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+	return s.stats, s.err
+}
+
+// Run updates the stats every Interval until the context is cancelled
+func (s *Service) Run(ctx context.Context) error {
+	if s.Interval <= 0 {
+		return errors.New("interval must be greater than zero")
+	}
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+	for {
+		_ = s.Update()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// ServeHTTP handles the supply endpoints
+func (s *Service) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	stats, err := s.Stats()
+	if stats == nil || (s.MaxAge > 0 && time.Since(stats.Time) > s.MaxAge) {
+		msg := "supply has not been calculated"
+		if err != nil {
+			msg = err.Error()
+		}
+		http.Error(w, msg, http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	if s.Interval > 0 {
+		w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(s.Interval.Seconds())))
+	}
+	w.Header().Set("Last-Modified", stats.Time.Format(http.TimeFormat))
+
+	var amount fio.Amount
+	switch strings.TrimSuffix(r.URL.Path, "/") {
+	case "/total":
+		amount = stats.Total
+	case "/circulating":
+		amount = stats.Circulating
+	case "/locked":
+		amount = stats.Locked
+	case "/supply.json", "":
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(stats)
+		return
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain")
+	_, _ = w.Write([]byte(amount.Decimal()))
+}
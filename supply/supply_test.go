@@ -0,0 +1,101 @@
+package supply
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/fioprotocol/fio-go"
+	"github.com/fioprotocol/fio-go/eos"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func fakeNode(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "get_currency_stats") {
+			_, _ = w.Write([]byte(`{"FIO":{"supply":"1000.000000001 FIO","max_supply":"1000000000.000000000 FIO","issuer":"eosio"}}`))
+			return
+		}
+		req := fio.GetTableRowsOrderRequest{}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		switch req.Table {
+		case "lockedtokens":
+			// only answer for the name range that includes the owner
+			owner, _ := eos.StringToName("alohaaccount")
+			lower, _ := eos.StringToName(req.LowerBound)
+			upper, _ := eos.StringToName(req.UpperBound)
+			if owner < lower || owner > upper {
+				_, _ = w.Write([]byte(`{"rows":[]}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"rows":[{"owner":"alohaaccount","total_grant_amount":100000000000,"grant_type":4,"remaining_locked_amount":100000000000}]}`))
+		case "locktokens":
+			_, _ = w.Write([]byte(`{"rows":[{"id":1,"owner_account":"bob","lock_amount":50000000000,"periods":[{"duration":86400,"percent":100}],"time_stamp":4102444800}]}`))
+		case "bpbucketpool":
+			_, _ = w.Write([]byte(`{"rows":[{"rewards":7}]}`))
+		default:
+			t.Error("unexpected table", req.Table)
+		}
+	}))
+}
+
+func TestService(t *testing.T) {
+	node := fakeNode(t)
+	defer node.Close()
+	s := NewService(&fio.API{API: eos.New(node.URL)}, time.Minute)
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	get := func(path string) (int, string) {
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := ioutil.ReadAll(resp.Body)
+		return resp.StatusCode, strings.TrimSpace(string(body))
+	}
+	if code, _ := get("/total"); code != http.StatusServiceUnavailable {
+		t.Error("expected 503 before the first update, got", code)
+	}
+
+	if err := s.Update(); err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range map[string]string{
+		"/total":       "1000.000000001",
+		"/circulating": "850.000000001",
+		"/locked":      "150.000000000",
+	} {
+		if code, body := get(path); code != http.StatusOK || body != want {
+			t.Errorf("%s returned %d %q, want %q", path, code, body, want)
+		}
+	}
+	code, body := get("/supply.json")
+	stats := &Stats{}
+	if err := json.Unmarshal([]byte(body), stats); err != nil || code != http.StatusOK {
+		t.Fatal(code, err)
+	}
+	if stats.GiveawayLocked != fio.Fio(100) || stats.LockTokens != fio.Fio(50) || stats.LockedBpRewards != 7 {
+		t.Errorf("unexpected breakdown %+v", stats)
+	}
+	if code, _ = get("/unknown"); code != http.StatusNotFound {
+		t.Error("expected 404, got", code)
+	}
+
+	// failed updates keep serving the last result until it is too old
+	s.compute = func(api *fio.API) (*Stats, error) { return nil, errors.New("node is down") }
+	if err := s.Update(); err == nil {
+		t.Error("expected update error")
+	}
+	if code, body = get("/total"); code != http.StatusOK || body != "1000.000000001" {
+		t.Error("stale stats should be served", code, body)
+	}
+	s.MaxAge = time.Nanosecond
+	if code, body = get("/total"); code != http.StatusServiceUnavailable || body != "node is down" {
+		t.Error("old stats should not be served", code, body)
+	}
+}
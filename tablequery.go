@@ -0,0 +1,535 @@
+package fio
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/fioprotocol/fio-go/eos"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Key types supported by TableQuery
+const (
+	KeyTypeName   = "name"
+	KeyTypeI64    = "i64"
+	KeyTypeI128   = "i128"
+	KeyTypeSha256 = "sha256"
+)
+
+// TableIndex describes an index on a contract table
+type TableIndex struct {
+	Position int    // index_position, 1 is the primary key
+	KeyType  string // one of the KeyType constants
+	Hashed   bool   // the key is the I128Hash of a string, such as a FIO address or domain
+}
+
+// TableSchema lists the named indexes for a table, see RegisterTable
+type TableSchema struct {
+	Code    string
+	Scope   string
+	Table   string
+	Indexes map[string]TableIndex
+}
+
+// tables holds the indexes for FIO contract tables used by TableQuery, keyed by "code::table". Additional tables can
+// be added with RegisterTable.
+var tables = map[string]*TableSchema{
+	"fio.address::fionames": {Code: "fio.address", Scope: "fio.address", Table: "fionames", Indexes: map[string]TableIndex{
+		"id":   {Position: 1, KeyType: KeyTypeI64},
+		"name": {Position: 5, KeyType: KeyTypeI128, Hashed: true},
+	}},
+	"fio.address::domains": {Code: "fio.address", Scope: "fio.address", Table: "domains", Indexes: map[string]TableIndex{
+		"id":         {Position: 1, KeyType: KeyTypeI64},
+		"expiration": {Position: 3, KeyType: KeyTypeI64},
+		"name":       {Position: 4, KeyType: KeyTypeI128, Hashed: true},
+	}},
+	"fio.address::accountmap": {Code: "fio.address", Scope: "fio.address", Table: "accountmap", Indexes: map[string]TableIndex{
+		"account": {Position: 1, KeyType: KeyTypeI64},
+	}},
+	"fio.reqobt::fioreqctxts": {Code: "fio.reqobt", Scope: "fio.reqobt", Table: "fioreqctxts", Indexes: map[string]TableIndex{
+		"fio_request_id": {Position: 1, KeyType: KeyTypeI64},
+	}},
+	"fio.reqobt::fioreqstss": {Code: "fio.reqobt", Scope: "fio.reqobt", Table: "fioreqstss", Indexes: map[string]TableIndex{
+		"id":             {Position: 1, KeyType: KeyTypeI64},
+		"fio_request_id": {Position: 2, KeyType: KeyTypeI64},
+	}},
+	"fio.fee::fiofees": {Code: "fio.fee", Scope: "fio.fee", Table: "fiofees", Indexes: map[string]TableIndex{
+		"fee_id": {Position: 1, KeyType: KeyTypeI64},
+	}},
+	"fio.fee::feevoters": {Code: "fio.fee", Scope: "fio.fee", Table: "feevoters", Indexes: map[string]TableIndex{
+		"block_producer_name": {Position: 1, KeyType: KeyTypeName},
+	}},
+	"fio.fee::feevotes2": {Code: "fio.fee", Scope: "fio.fee", Table: "feevotes2", Indexes: map[string]TableIndex{
+		"id":                  {Position: 1, KeyType: KeyTypeI64},
+		"block_producer_name": {Position: 2, KeyType: KeyTypeName},
+	}},
+	"eosio::producers": {Code: "eosio", Scope: "eosio", Table: "producers", Indexes: map[string]TableIndex{
+		"id":    {Position: 1, KeyType: KeyTypeI64},
+		"owner": {Position: 4, KeyType: KeyTypeName},
+	}},
+	"eosio::voters": {Code: "eosio", Scope: "eosio", Table: "voters", Indexes: map[string]TableIndex{
+		"id":    {Position: 1, KeyType: KeyTypeI64},
+		"owner": {Position: 3, KeyType: KeyTypeName},
+	}},
+	"eosio::lockedtokens": {Code: "eosio", Scope: "eosio", Table: "lockedtokens", Indexes: map[string]TableIndex{
+		"owner": {Position: 1, KeyType: KeyTypeName},
+	}},
+	"eosio::locktokens": {Code: "eosio", Scope: "eosio", Table: "locktokens", Indexes: map[string]TableIndex{
+		"id":    {Position: 1, KeyType: KeyTypeI64},
+		"owner": {Position: 2, KeyType: KeyTypeName},
+	}},
+	"eosio::locktokensv2": {Code: "eosio", Scope: "eosio", Table: "locktokensv2", Indexes: map[string]TableIndex{
+		"id":    {Position: 1, KeyType: KeyTypeI64},
+		"owner": {Position: 2, KeyType: KeyTypeName},
+	}},
+	"eosio.msig::proposal": {Code: "eosio.msig", Table: "proposal", Indexes: map[string]TableIndex{
+		"proposal_name": {Position: 1, KeyType: KeyTypeName},
+	}},
+	"eosio.msig::approvals2": {Code: "eosio.msig", Table: "approvals2", Indexes: map[string]TableIndex{
+		"proposal_name": {Position: 1, KeyType: KeyTypeName},
+	}},
+}
+
+var tablesMux = sync.RWMutex{}
+
+// RegisterTable adds or replaces the index information for a table
+func RegisterTable(schema *TableSchema) {
+	tablesMux.Lock()
+	tables[schema.Code+"::"+schema.Table] = schema
+	tablesMux.Unlock()
+}
+
+// GetTableSchema returns the index information for a table, if known
+func GetTableSchema(code string, table string) (schema *TableSchema, ok bool) {
+	tablesMux.RLock()
+	schema, ok = tables[code+"::"+table]
+	tablesMux.RUnlock()
+	return
+}
+
+// TableQuery builds and runs get_table_rows requests, handling the index positions, key encoding and paging:
+//
+//	rows := make([]myRow, 0)
+//	err := fio.NewTableQuery(api, "fio.address", "fionames").Index("name").Equal("alice@fiotestnet").All(&rows)
+//
+// Errors from the builder methods are deferred until the query is run.
+type TableQuery struct {
+	api    *API
+	req    GetTableRowsOrderRequest
+	schema *TableSchema
+	index  TableIndex
+	lower  interface{}
+	upper  interface{}
+	max    int
+	err    error
+}
+
+// NewTableQuery starts a query against a table, the scope defaults to the known scope for the table, or the code.
+func NewTableQuery(api *API, code string, table string) *TableQuery {
+	q := &TableQuery{
+		api:   api,
+		req:   GetTableRowsOrderRequest{Code: code, Scope: code, Table: table, JSON: true},
+		index: TableIndex{Position: 1, KeyType: KeyTypeI64},
+	}
+	if schema, ok := GetTableSchema(code, table); ok && schema != nil {
+		q.schema = schema
+		if schema.Scope != "" {
+			q.req.Scope = schema.Scope
+		}
+	}
+	return q
+}
+
+// Scope overrides the table scope
+func (q *TableQuery) Scope(scope string) *TableQuery {
+	q.req.Scope = scope
+	return q
+}
+
+// Index selects a named index from the table's schema
+func (q *TableQuery) Index(name string) *TableQuery {
+	if q.schema == nil {
+		q.err = fmt.Errorf("no index information for %s::%s, use IndexPosition or RegisterTable", q.req.Code, q.req.Table)
+		return q
+	}
+	idx, ok := q.schema.Indexes[name]
+	if !ok {
+		q.err = fmt.Errorf("index %s is not known for %s::%s", name, q.req.Code, q.req.Table)
+		return q
+	}
+	q.index = idx
+	return q
+}
+
+// IndexPosition selects an index that isn't in the table's schema
+func (q *TableQuery) IndexPosition(position int, keyType string, hashed bool) *TableQuery {
+	q.index = TableIndex{Position: position, KeyType: keyType, Hashed: hashed}
+	return q
+}
+
+// Lower sets the lower bound (inclusive), which is encoded for the index's key type when the query is run
+func (q *TableQuery) Lower(key interface{}) *TableQuery {
+	q.lower = key
+	return q
+}
+
+// Upper sets the upper bound (inclusive)
+func (q *TableQuery) Upper(key interface{}) *TableQuery {
+	q.upper = key
+	return q
+}
+
+// Equal sets both the lower and upper bound
+func (q *TableQuery) Equal(key interface{}) *TableQuery {
+	q.lower, q.upper = key, key
+	return q
+}
+
+// Limit sets the number of rows requested per page
+func (q *TableQuery) Limit(limit uint32) *TableQuery {
+	q.req.Limit = limit
+	return q
+}
+
+// Max limits the total rows returned by All, zero is unlimited
+func (q *TableQuery) Max(max int) *TableQuery {
+	q.max = max
+	return q
+}
+
+// Reverse returns the rows in descending order
+func (q *TableQuery) Reverse() *TableQuery {
+	q.req.Reverse = true
+	return q
+}
+
+// Request returns the get_table_rows request for the first page
+func (q *TableQuery) Request() (GetTableRowsOrderRequest, error) {
+	req := q.req
+	if q.err != nil {
+		return req, q.err
+	}
+	req.Index = strconv.Itoa(q.index.Position)
+	req.KeyType = q.index.KeyType
+	if req.KeyType == KeyTypeI64 {
+		req.EncodeType = "dec"
+	}
+	var err error
+	if q.lower != nil {
+		if req.LowerBound, err = EncodeTableKey(q.index, q.lower); err != nil {
+			return req, err
+		}
+	}
+	if q.upper != nil {
+		if req.UpperBound, err = EncodeTableKey(q.index, q.upper); err != nil {
+			return req, err
+		}
+	}
+	return req, nil
+}
+
+// All fetches every matching row, following next_key across pages, and decodes them into out, which must be a
+// pointer to a slice. Nodes that don't return next_key are paged using the key of the last row instead, this requires
+// the index to be in the table's schema. When doing so on a secondary index, rows sharing the last row's key may be
+// skipped.
+func (q *TableQuery) All(out interface{}) error {
+	rv := reflect.ValueOf(out)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Slice {
+		return errors.New("TableQuery: out must be a pointer to a slice")
+	}
+	slice := rv.Elem()
+	req, err := q.Request()
+	if err != nil {
+		return err
+	}
+	for {
+		resp, err := q.api.getTableRowsPage(req)
+		if err != nil {
+			return err
+		}
+		page := reflect.New(slice.Type())
+		if err = json.Unmarshal(resp.Rows, page.Interface()); err != nil {
+			return err
+		}
+		slice.Set(reflect.AppendSlice(slice, page.Elem()))
+		if q.max > 0 && slice.Len() >= q.max {
+			slice.Set(slice.Slice(0, q.max))
+			return nil
+		}
+		if !resp.More || page.Elem().Len() == 0 {
+			return nil
+		}
+		next := resp.NextKey
+		if next == "" {
+			var done bool
+			if next, done, err = q.afterLastRow(resp.Rows); err != nil {
+				return err
+			}
+			if done {
+				return nil
+			}
+		}
+		if req.Reverse {
+			req.UpperBound = next
+		} else {
+			req.LowerBound = next
+		}
+	}
+}
+
+// afterLastRow finds the bound for the next page from the last row of a page, for nodes that don't provide next_key.
+// done is true if there can't be any more rows.
+func (q *TableQuery) afterLastRow(rows json.RawMessage) (next string, done bool, err error) {
+	noKey := fmt.Errorf("TableQuery: %s::%s has more rows, but the node did not provide next_key", q.req.Code, q.req.Table)
+	field := ""
+	if q.schema != nil {
+		for name, idx := range q.schema.Indexes {
+			if idx == q.index {
+				field = name
+			}
+		}
+	}
+	if field == "" {
+		return "", false, noKey
+	}
+	page := make([]json.RawMessage, 0)
+	if err = json.Unmarshal(rows, &page); err != nil || len(page) == 0 {
+		return "", false, noKey
+	}
+	last := make(map[string]interface{})
+	dec := json.NewDecoder(bytes.NewReader(page[len(page)-1]))
+	dec.UseNumber()
+	if err = dec.Decode(&last); err != nil {
+		return "", false, err
+	}
+	var key interface{}
+	switch v := last[field].(type) {
+	case json.Number:
+		i, ok := new(big.Int).SetString(v.String(), 10)
+		if !ok {
+			return "", false, noKey
+		}
+		key = i
+	case string:
+		key = v
+		if _, e := strconv.ParseUint(v, 10, 64); e != nil && q.index.KeyType == KeyTypeI64 && !q.index.Hashed {
+			key = eos.AccountName(v)
+		}
+	default:
+		return "", false, noKey
+	}
+	if i, ok := key.(*big.Int); ok {
+		// EncodeTableKey doesn't accept a big.Int, so the same conversions are used here
+		switch q.index.KeyType {
+		case KeyTypeI64:
+			key = i.String()
+		case KeyTypeI128:
+			key = fmt.Sprintf("0x%032x", i)
+		}
+	}
+	encoded, err := EncodeTableKey(q.index, key)
+	if err != nil {
+		return "", false, err
+	}
+
+	// bounds are inclusive, so step past the last row's key
+	var n *big.Int
+	switch q.index.KeyType {
+	case KeyTypeI64:
+		n, _ = new(big.Int).SetString(encoded, 10)
+	case KeyTypeI128:
+		n, _ = new(big.Int).SetString(strings.TrimPrefix(encoded, "0x"), 16)
+	case KeyTypeName:
+		u, e := eos.StringToName(encoded)
+		if e != nil {
+			return "", false, e
+		}
+		n = new(big.Int).SetUint64(u)
+	}
+	if n == nil {
+		return "", false, noKey
+	}
+	if q.req.Reverse {
+		if n.Sign() == 0 {
+			return "", true, nil
+		}
+		n.Sub(n, big.NewInt(1))
+	} else {
+		n.Add(n, big.NewInt(1))
+	}
+	switch q.index.KeyType {
+	case KeyTypeI64:
+		if !n.IsUint64() {
+			return "", true, nil
+		}
+		return n.String(), false, nil
+	case KeyTypeI128:
+		if n.BitLen() > 128 {
+			return "", true, nil
+		}
+		return fmt.Sprintf("0x%032x", n), false, nil
+	default:
+		if !n.IsUint64() {
+			return "", true, nil
+		}
+		return eos.NameToString(n.Uint64()), false, nil
+	}
+}
+
+// First fetches a single row into out, which must be a pointer, and reports if a row was found
+func (q *TableQuery) First(out interface{}) (found bool, err error) {
+	rv := reflect.ValueOf(out)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return false, errors.New("TableQuery: out must be a non-nil pointer")
+	}
+	req, err := q.Request()
+	if err != nil {
+		return false, err
+	}
+	req.Limit = 1
+	resp, err := q.api.getTableRowsPage(req)
+	if err != nil {
+		return false, err
+	}
+	rows := reflect.New(reflect.SliceOf(rv.Elem().Type()))
+	if err = json.Unmarshal(resp.Rows, rows.Interface()); err != nil {
+		return false, err
+	}
+	if rows.Elem().Len() == 0 {
+		return false, nil
+	}
+	rv.Elem().Set(rows.Elem().Index(0))
+	return true, nil
+}
+
+func (api *API) getTableRowsPage(req GetTableRowsOrderRequest) (*eos.GetTableRowsResp, error) {
+	raw, err := api.PushEndpointRaw("/v1/chain/get_table_rows", &req)
+	if err != nil {
+		return nil, err
+	}
+	resp := &eos.GetTableRowsResp{}
+	if err = json.Unmarshal(raw, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// EncodeTableKey converts a value to a get_table_rows bound for an index. Accepted values by key type:
+//
+//	name:   string, eos.Name, eos.AccountName
+//	i64:    integers, decimal strings, names (as their uint64 value) and time.Time (as a unix timestamp)
+//	i128:   integers, or "0x" prefixed hex strings. Hashed indexes accept a string, or Address to hash
+//	sha256: 64 character hex strings, []byte or eos.Checksum256
+func EncodeTableKey(index TableIndex, key interface{}) (string, error) {
+	if index.Hashed {
+		var s string
+		switch v := key.(type) {
+		case string:
+			s = v
+		case Address:
+			s = string(v)
+		default:
+			return "", fmt.Errorf("hashed index requires a string key, got %T", key)
+		}
+		if strings.HasPrefix(s, "0x") && len(s) == 34 {
+			// already hashed
+			return s, nil
+		}
+		return I128Hash(strings.ToLower(s)), nil
+	}
+
+	switch index.KeyType {
+	case KeyTypeName:
+		var s string
+		switch v := key.(type) {
+		case string:
+			s = v
+		case eos.Name:
+			s = string(v)
+		case eos.AccountName:
+			s = string(v)
+		default:
+			return "", fmt.Errorf("name index requires a name key, got %T", key)
+		}
+		if !validName(s) {
+			return "", fmt.Errorf("invalid name key %q", s)
+		}
+		return s, nil
+
+	case KeyTypeI64:
+		switch v := key.(type) {
+		case eos.Name:
+			n, err := eos.StringToName(string(v))
+			return strconv.FormatUint(n, 10), err
+		case eos.AccountName:
+			n, err := eos.StringToName(string(v))
+			return strconv.FormatUint(n, 10), err
+		case time.Time:
+			return strconv.FormatInt(v.UTC().Unix(), 10), nil
+		case string:
+			if _, err := strconv.ParseUint(v, 10, 64); err != nil {
+				return "", fmt.Errorf("invalid i64 key %q: %s", v, err)
+			}
+			return v, nil
+		}
+		if i, ok := tableKeyInt(key); ok {
+			return i.String(), nil
+		}
+		return "", fmt.Errorf("i64 index requires an integer key, got %T", key)
+
+	case KeyTypeI128:
+		if s, ok := key.(string); ok {
+			if !strings.HasPrefix(s, "0x") {
+				return "", fmt.Errorf("i128 key %q must be 0x prefixed hex", s)
+			}
+			if _, err := hex.DecodeString(s[2:]); err != nil {
+				return "", fmt.Errorf("invalid i128 key %q: %s", s, err)
+			}
+			return s, nil
+		}
+		if i, ok := tableKeyInt(key); ok {
+			return fmt.Sprintf("0x%032x", i), nil
+		}
+		return "", fmt.Errorf("i128 index requires an integer or hex key, got %T", key)
+
+	case KeyTypeSha256:
+		var s string
+		switch v := key.(type) {
+		case string:
+			s = strings.TrimPrefix(v, "0x")
+		case []byte:
+			s = hex.EncodeToString(v)
+		case eos.Checksum256:
+			s = hex.EncodeToString(v)
+		default:
+			return "", fmt.Errorf("sha256 index requires a hex string or bytes, got %T", key)
+		}
+		if b, err := hex.DecodeString(s); err != nil || len(b) != 32 {
+			return "", fmt.Errorf("invalid sha256 key %q", s)
+		}
+		return s, nil
+	}
+	return "", fmt.Errorf("unsupported key type %q", index.KeyType)
+}
+
+// tableKeyInt converts any non-negative integer type to a big.Int
+func tableKeyInt(key interface{}) (*big.Int, bool) {
+	rv := reflect.ValueOf(key)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if rv.Int() < 0 {
+			return nil, false
+		}
+		return big.NewInt(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return new(big.Int).SetUint64(rv.Uint()), true
+	}
+	return nil, false
+}
@@ -0,0 +1,149 @@
+package fio
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/fioprotocol/fio-go/eos"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestEncodeTableKey(t *testing.T) {
+	name, _ := eos.StringToName("fio.address")
+	good := []struct {
+		index TableIndex
+		key   interface{}
+		want  string
+	}{
+		{TableIndex{KeyType: KeyTypeName}, eos.AccountName("eosio"), "eosio"},
+		{TableIndex{KeyType: KeyTypeI64}, uint64(42), "42"},
+		{TableIndex{KeyType: KeyTypeI64}, "42", "42"},
+		{TableIndex{KeyType: KeyTypeI64}, eos.AccountName("fio.address"), strconv.FormatUint(name, 10)},
+		{TableIndex{KeyType: KeyTypeI64}, time.Unix(1600000000, 0), "1600000000"},
+		{TableIndex{KeyType: KeyTypeI128}, 255, "0x000000000000000000000000000000ff"},
+		{TableIndex{KeyType: KeyTypeI128, Hashed: true}, "Test@FIOTestnet", I128Hash("test@fiotestnet")},
+		{TableIndex{KeyType: KeyTypeI128, Hashed: true}, Address("test@fiotestnet"), I128Hash("test@fiotestnet")},
+		{TableIndex{KeyType: KeyTypeSha256}, make([]byte, 32), "0000000000000000000000000000000000000000000000000000000000000000"},
+	}
+	for _, g := range good {
+		got, err := EncodeTableKey(g.index, g.key)
+		if err != nil || got != g.want {
+			t.Errorf("%s key %v: got %q, want %q, err: %v", g.index.KeyType, g.key, got, g.want, err)
+		}
+	}
+
+	bad := []struct {
+		index TableIndex
+		key   interface{}
+	}{
+		{TableIndex{KeyType: KeyTypeName}, "not_a_name"},
+		{TableIndex{KeyType: KeyTypeI64}, -1},
+		{TableIndex{KeyType: KeyTypeI64}, "abc"},
+		{TableIndex{KeyType: KeyTypeI128}, "ff"},
+		{TableIndex{KeyType: KeyTypeI128, Hashed: true}, 1},
+		{TableIndex{KeyType: KeyTypeSha256}, "abcd"},
+		{TableIndex{KeyType: "float64"}, 1},
+	}
+	for _, b := range bad {
+		if _, err := EncodeTableKey(b.index, b.key); err == nil {
+			t.Errorf("%s key %v should be invalid", b.index.KeyType, b.key)
+		}
+	}
+}
+
+func TestTableQuery(t *testing.T) {
+	// serves rows with ids 1-25 from the primary index, two rows at a time
+	requests := make([]GetTableRowsOrderRequest, 0)
+	omitNextKey := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := GetTableRowsOrderRequest{}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		requests = append(requests, req)
+		lower, _ := strconv.Atoi(req.LowerBound)
+		if lower == 0 {
+			lower = 1
+		}
+		limit := int(req.Limit)
+		if limit == 0 {
+			limit = 2
+		}
+		rows := make([]map[string]int, 0)
+		for id := lower; id <= 25 && len(rows) < limit; id++ {
+			rows = append(rows, map[string]int{"id": id})
+		}
+		more := lower+len(rows) <= 25
+		next := ""
+		if more && !omitNextKey {
+			next = strconv.Itoa(lower + len(rows))
+		}
+		j, _ := json.Marshal(rows)
+		_ = json.NewEncoder(w).Encode(eos.GetTableRowsResp{More: more, Rows: j, NextKey: next})
+	}))
+	defer srv.Close()
+	api := &API{API: eos.New(srv.URL)}
+
+	type row struct {
+		Id int `json:"id"`
+	}
+	rows := make([]row, 0)
+	if err := NewTableQuery(api, "fio.address", "domains").Index("id").Lower(3).All(&rows); err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 23 || rows[0].Id != 3 || rows[22].Id != 25 {
+		t.Error("did not page through all rows", len(rows))
+	}
+	if len(requests) != 12 || requests[0].Index != "1" || requests[0].KeyType != "i64" || requests[1].LowerBound != "5" {
+		t.Error("unexpected requests", requests[:2])
+	}
+
+	// older nodes don't provide next_key, the key of the last row is used instead
+	omitNextKey = true
+	rows = rows[:0]
+	requests = requests[:0]
+	if err := NewTableQuery(api, "fio.address", "domains").Index("id").Lower(3).All(&rows); err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 23 || rows[0].Id != 3 || rows[22].Id != 25 || requests[1].LowerBound != "5" {
+		t.Error("did not page using the last row", len(rows))
+	}
+	if err := NewTableQuery(api, "fio.address", "unknown").All(&rows); err == nil {
+		t.Error("expected an error paging a table without a schema")
+	}
+	omitNextKey = false
+
+	rows = rows[:0]
+	if err := NewTableQuery(api, "fio.address", "domains").Index("id").Max(5).All(&rows); err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 5 {
+		t.Error("max was not applied", len(rows))
+	}
+
+	first := row{}
+	found, err := NewTableQuery(api, "fio.address", "domains").Index("id").Equal(7).First(&first)
+	if err != nil || !found || first.Id != 7 {
+		t.Error("did not get first row", first, err)
+	}
+
+	if err = NewTableQuery(api, "fio.address", "domains").Index("nope").All(&rows); err == nil {
+		t.Error("expected unknown index error")
+	}
+	if err = NewTableQuery(api, "fio.address", "domains").All(rows); err == nil {
+		t.Error("expected error for non-pointer")
+	}
+
+	req, err := NewTableQuery(api, "fio.address", "fionames").Index("name").Equal("alice@fiotestnet").Request()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := GetTableRowsOrderRequest{
+		Code: "fio.address", Scope: "fio.address", Table: "fionames", Index: "5", KeyType: "i128",
+		LowerBound: AddressHash("alice@fiotestnet"), UpperBound: AddressHash("alice@fiotestnet"), JSON: true,
+	}
+	if fmt.Sprintf("%+v", req) != fmt.Sprintf("%+v", want) {
+		t.Errorf("got request %+v, want %+v", req, want)
+	}
+}
@@ -6,10 +6,12 @@ import (
 
 const FioSymbol = "ᵮ"
 
-// Tokens is a convenience function for converting from a float for human readability.
-// Example 1 FIO Token: Tokens(1.0) == uint64(1000000000)
+// Tokens is a convenience function for converting from a float for human readability, it rounds to the nearest SUF.
+// Example 1 FIO Token: Tokens(1.0) == uint64(1000000000). Use Amount for exact values.
+//
+// Tokens previously truncated, which lost a SUF for many values: Tokens(0.29) is now 290000000 instead of 289999999.
 func Tokens(tokens float64) uint64 {
-	return uint64(tokens * 1000000000.0)
+	return uint64(floatToAmount(tokens))
 }
 
 // TransferTokensPubKey is used to send FIO tokens to a public key
@@ -35,6 +37,11 @@ func NewTransferTokensPubKey(actor eos.AccountName, recipientPubKey string, amou
 	)
 }
 
+// NewTransferTokensPubKeyAmount is the same as NewTransferTokensPubKey, but uses an exact Amount
+func NewTransferTokensPubKeyAmount(actor eos.AccountName, recipientPubKey string, amount Amount) *Action {
+	return NewTransferTokensPubKey(actor, recipientPubKey, amount.Suf())
+}
+
 // Transfer is a privileged call, and not normally used for sending tokens, use TransferTokensPubKey instead
 type Transfer struct {
 	From     eos.AccountName `json:"from"`
@@ -63,6 +70,13 @@ func NewTransfer(actor eos.AccountName, recipient eos.AccountName, amount uint64
 	)
 }
 
+// NewTransferAmount is the same as NewTransfer, but uses an exact Amount
+//
+// deprecated: internal action, user cannot call.
+func NewTransferAmount(actor eos.AccountName, recipient eos.AccountName, amount Amount) *Action {
+	return NewTransfer(actor, recipient, amount.Suf())
+}
+
 // GetBalance gets an account's balance
 func (api *API) GetBalance(account eos.AccountName) (float64, error) {
 	a, err := api.GetCurrencyBalance(account, "FIO", eos.AccountName("fio.token"))
@@ -77,9 +91,22 @@ func (api *API) GetBalance(account eos.AccountName) (float64, error) {
 	return 0.0, nil
 }
 
+// GetBalanceAmount is the same as GetBalance, but returns an exact Amount
+func (api *API) GetBalanceAmount(account eos.AccountName) (Amount, error) {
+	a, err := api.GetCurrencyBalance(account, "FIO", eos.AccountName("fio.token"))
+	if err != nil {
+		return 0, err
+	}
+	if len(a) == 0 {
+		return 0, nil
+	}
+	return AmountFromAsset(a[0])
+}
+
 type GetFioBalanceResp struct {
 	Balance   uint64 `json:"balance"`
 	Available uint64 `json:"available"`
+	Staked    uint64 `json:"staked"` // only provided by nodes with staking
 }
 
 type getFioBalanceReq struct {
@@ -92,3 +119,21 @@ func (api *API) GetFioBalance(pubkey string) (fiobalance *GetFioBalanceResp, err
 	err = api.call("chain", "get_fio_balance", &getFioBalanceReq{FioPublicKey: pubkey}, &fiobalance)
 	return fiobalance, err
 }
+
+// GetFioBalanceAmount is the same as GetFioBalance, but returns the balance and available tokens as an Amount
+func (api *API) GetFioBalanceAmount(pubkey string) (balance Amount, available Amount, err error) {
+	resp, err := api.GetFioBalance(pubkey)
+	if err != nil {
+		return 0, 0, err
+	}
+	return Amount(resp.Balance), Amount(resp.Available), nil
+}
+
+// GetStakedAmount returns the tokens staked by an account, zero if the chain does not have staking
+func (api *API) GetStakedAmount(pubkey string) (Amount, error) {
+	resp, err := api.GetFioBalance(pubkey)
+	if err != nil {
+		return 0, err
+	}
+	return Amount(resp.Staked), nil
+}
@@ -60,6 +60,11 @@ func NewUpdateTpid(actor eos.AccountName, tpid string, amount uint64) *Action {
 	)
 }
 
+// NewUpdateTpidAmount is the same as NewUpdateTpid, but uses an exact Amount
+func NewUpdateTpidAmount(actor eos.AccountName, tpid string, amount Amount) *Action {
+	return NewUpdateTpid(actor, tpid, amount.Suf())
+}
+
 // RewardsPaid is privileged
 type RewardsPaid struct {
 	Tpid string `json:"tpid"`
@@ -86,3 +91,8 @@ func NewUpdateBounty(actor eos.AccountName, amount uint64) *Action {
 		UpdateBounty{Amount: amount},
 	)
 }
+
+// NewUpdateBountyAmount is the same as NewUpdateBounty, but uses an exact Amount
+func NewUpdateBountyAmount(actor eos.AccountName, amount Amount) *Action {
+	return NewUpdateBounty(actor, amount.Suf())
+}
@@ -0,0 +1,233 @@
+package fio
+
+import (
+	"github.com/fioprotocol/fio-go/eos"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Sources of locked tokens in an UnlockSchedule
+const (
+	UnlockGenesis    = "genesis"    // locked at chain genesis, see GenesisLockedTokens
+	UnlockLockTokens = "locktokens" // FIP-6 locked token transfers, and on chains with staking, unstaked tokens
+)
+
+// UnlockEvent is a future date when locked tokens become spendable
+type UnlockEvent struct {
+	Time      time.Time `json:"time"`
+	Amount    Amount    `json:"amount"`    // tokens unlocked at this time
+	Available Amount    `json:"available"` // spendable balance after this unlock, assuming the balance does not change
+	Source    string    `json:"source"`
+}
+
+// UnlockSchedule projects when an account's locked tokens become spendable
+type UnlockSchedule struct {
+	Account   eos.AccountName `json:"account"`
+	Time      time.Time       `json:"time"`      // when the schedule was calculated
+	Balance   Amount          `json:"balance"`   // total balance, including locked and staked tokens
+	Locked    Amount          `json:"locked"`    // tokens that are locked now
+	Staked    Amount          `json:"staked"`    // staked tokens, not spendable until unstaked
+	Permanent Amount          `json:"permanent"` // locked tokens without an unlock date, such as inhibited member grants
+	Unlocks   []UnlockEvent   `json:"unlocks"`   // sorted by time
+}
+
+// Available is the spendable balance now
+func (s *UnlockSchedule) Available() Amount {
+	if s.Locked+s.Staked > s.Balance {
+		return 0
+	}
+	return s.Balance - s.Locked - s.Staked
+}
+
+// AvailableAt is the spendable balance at a future time, assuming the balance does not change
+func (s *UnlockSchedule) AvailableAt(t time.Time) Amount {
+	available := s.Available()
+	for _, u := range s.Unlocks {
+		if u.Time.After(t) {
+			break
+		}
+		available = u.Available
+	}
+	return available
+}
+
+// add merges unlock events into the schedule
+func (s *UnlockSchedule) add(events []UnlockEvent, permanent Amount) {
+	for _, e := range events {
+		s.Locked += e.Amount
+	}
+	s.Locked += permanent
+	s.Permanent += permanent
+	s.Unlocks = append(s.Unlocks, events...)
+}
+
+// finish sorts the events and calculates the available balance after each one
+func (s *UnlockSchedule) finish() {
+	sort.SliceStable(s.Unlocks, func(i, j int) bool {
+		return s.Unlocks[i].Time.Before(s.Unlocks[j].Time)
+	})
+	available := s.Available()
+	unstaked := Amount(0)
+	if s.Balance > s.Staked {
+		unstaked = s.Balance - s.Staked
+	}
+	for i := range s.Unlocks {
+		available += s.Unlocks[i].Amount
+		if available > unstaked {
+			available = unstaked
+		}
+		s.Unlocks[i].Available = available
+	}
+}
+
+// Unlocks projects the genesis lock's remaining unlock periods after a point in time. Tokens that will never unlock,
+// giveaway grants and inhibited member grants, are returned as permanent.
+func (g *GenesisLockedTokens) Unlocks(now time.Time) (events []UnlockEvent, permanent Amount, err error) {
+	locked, err := g.remainingAt(now)
+	if err != nil {
+		return nil, 0, err
+	}
+	events = make([]UnlockEvent, 0)
+	if g.GrantType == LockedGiveaway || (g.GrantType == LockedMember && g.InhibitUnlocking == 1) {
+		return events, Amount(locked), nil
+	}
+	for period := 0; period < LockedPeriods; period++ {
+		t := g.unlockTime(period)
+		if !t.After(now) {
+			continue
+		}
+		after, _ := g.remainingAt(t)
+		if after < locked {
+			events = append(events, UnlockEvent{Time: t, Amount: Amount(locked - after), Source: UnlockGenesis})
+			locked = after
+		}
+	}
+	return events, Amount(locked), nil
+}
+
+// Unlocks projects the remaining FIP-6 unlock periods after a point in time
+func (l *LockTokensResp) Unlocks(now time.Time) []UnlockEvent {
+	events := make([]UnlockEvent, 0)
+	var distributed uint64
+	for i, p := range l.Periods {
+		if p == nil {
+			continue
+		}
+		amount := uint64(math.Round(float64(l.LockAmount) * p.Percent / 100))
+		if i == len(l.Periods)-1 || distributed+amount > l.LockAmount {
+			// the last period gets any rounding difference
+			amount = l.LockAmount - distributed
+		}
+		distributed += amount
+		t := time.Unix(l.TimeStamp+int64(p.Duration), 0).UTC()
+		if t.After(now) && amount > 0 {
+			events = append(events, UnlockEvent{Time: t, Amount: Amount(amount), Source: UnlockLockTokens})
+		}
+	}
+	return events
+}
+
+// LockPeriodV2 is an unlock period in the locktokensv2 table, which uses amounts instead of percentages
+type LockPeriodV2 struct {
+	Duration int64 `json:"duration"`
+	Amount   int64 `json:"amount"`
+}
+
+// LockTokensV2Resp (table query response) is a row in the eosio locktokensv2 table, which replaced locktokens
+// when staking was added. Unstaked tokens are also locked using this table.
+type LockTokensV2Resp struct {
+	Id                  uint64          `json:"id"`
+	OwnerAccount        eos.AccountName `json:"owner_account"`
+	LockAmount          int64           `json:"lock_amount"`
+	PayoutsPerformed    int32           `json:"payouts_performed"`
+	CanVote             int32           `json:"can_vote"`
+	Periods             []LockPeriodV2  `json:"periods"`
+	RemainingLockAmount int64           `json:"remaining_lock_amount"`
+	TimeStamp           int64           `json:"timestamp"`
+}
+
+// Unlocks projects the remaining unlock periods after a point in time
+func (l *LockTokensV2Resp) Unlocks(now time.Time) []UnlockEvent {
+	events := make([]UnlockEvent, 0)
+	for _, p := range l.Periods {
+		t := time.Unix(l.TimeStamp+p.Duration, 0).UTC()
+		if t.After(now) && p.Amount > 0 {
+			events = append(events, UnlockEvent{Time: t, Amount: Amount(p.Amount), Source: UnlockLockTokens})
+		}
+	}
+	return events
+}
+
+// GetUnlockSchedule merges an account's genesis, FIP-6, and unstaking locks into a timeline of when tokens become
+// spendable. Staked tokens have no unlock date, they are excluded from the available amounts until unstaked:
+//
+//	schedule, err := api.GetUnlockSchedule("alohaaccount")
+//	for _, u := range schedule.Unlocks {
+//	    fmt.Printf("%s available on %s\n", u.Available, u.Time.Format("2006-01-02"))
+//	}
+func (api *API) GetUnlockSchedule(account eos.AccountName) (*UnlockSchedule, error) {
+	balance, err := api.GetBalanceAmount(account)
+	if err != nil {
+		return nil, err
+	}
+	staked, err := api.getStaked(account)
+	if err != nil {
+		return nil, err
+	}
+	schedule := &UnlockSchedule{
+		Account: account,
+		Time:    time.Now().UTC(),
+		Balance: balance,
+		Staked:  staked,
+		Unlocks: make([]UnlockEvent, 0),
+	}
+
+	found, genesis, err := api.GetGenesisLockedTokens(string(account))
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		events, permanent, err := genesis.Unlocks(schedule.Time)
+		if err != nil {
+			return nil, err
+		}
+		schedule.add(events, permanent)
+	}
+
+	v1 := make([]LockTokensResp, 0)
+	if err = NewTableQuery(api, "eosio", "locktokens").Index("owner").Equal(account).All(&v1); err != nil && !missingTable(err) {
+		return nil, err
+	}
+	for i := range v1 {
+		schedule.add(v1[i].Unlocks(schedule.Time), 0)
+	}
+	v2 := make([]LockTokensV2Resp, 0)
+	if err = NewTableQuery(api, "eosio", "locktokensv2").Index("owner").Equal(account).All(&v2); err != nil && !missingTable(err) {
+		return nil, err
+	}
+	for i := range v2 {
+		schedule.add(v2[i].Unlocks(schedule.Time), 0)
+	}
+
+	schedule.finish()
+	return schedule, nil
+}
+
+// getStaked looks up an account's public key in the accountmap table to find its staked tokens, it is zero if the
+// account does not have a key mapped
+func (api *API) getStaked(account eos.AccountName) (Amount, error) {
+	row := accountMap{}
+	found, err := NewTableQuery(api, "fio.address", "accountmap").Index("account").Equal(account).First(&row)
+	if err != nil || !found || row.Clientkey == "" {
+		return 0, err
+	}
+	return api.GetStakedAmount(row.Clientkey)
+}
+
+// missingTable checks for the error returned when a table is not in the contract's ABI, such as locktokensv2 before
+// staking was deployed
+func missingTable(err error) bool {
+	return strings.Contains(err.Error(), "is not specified in the ABI")
+}
@@ -0,0 +1,127 @@
+package fio
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/fioprotocol/fio-go/eos"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGenesisLockedTokens_Unlocks(t *testing.T) {
+	start := time.Date(2020, 3, 25, 0, 0, 0, 0, time.UTC)
+	lt := GenesisLockedTokens{
+		TotalGrantAmount:      1000,
+		GrantType:             LockedPresale,
+		RemainingLockedAmount: 1000,
+		Timestamp:             uint32(start.Unix()),
+	}
+	// after the second unlock, 24.8% is unlocked and four periods remain
+	now := start.Add(300 * 24 * time.Hour)
+	events, permanent, err := lt.Unlocks(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if permanent != 0 || len(events) != 4 {
+		t.Fatalf("unexpected unlocks %+v %d", events, permanent)
+	}
+	var total Amount
+	for i, e := range events {
+		total += e.Amount
+		if want := start.Add(time.Duration(90+(i+2)*180) * 24 * time.Hour); !e.Time.Equal(want) {
+			t.Error("unexpected unlock time", e.Time, want)
+		}
+	}
+	if events[0].Amount != 188 || total != 752 {
+		t.Errorf("unexpected unlock amounts %+v", events)
+	}
+	if rem, _ := lt.remainingAt(start.Add(2000 * 24 * time.Hour)); rem != 0 {
+		t.Error("all tokens should be unlocked after the final period", rem)
+	}
+
+	// tokens spent while locked reduce the later unlocks
+	lt.RemainingLockedAmount = 500
+	events, _, _ = lt.Unlocks(now)
+	total = 0
+	for _, e := range events {
+		total += e.Amount
+	}
+	if total != 500 || len(events) != 3 || !events[0].Time.Equal(start.Add(630*24*time.Hour)) {
+		t.Errorf("unexpected unlocks after spending %+v", events)
+	}
+
+	lt.GrantType, lt.InhibitUnlocking = LockedMember, 1
+	if events, permanent, _ = lt.Unlocks(now); len(events) != 0 || permanent != 500 {
+		t.Error("inhibited tokens should not unlock", events, permanent)
+	}
+}
+
+func TestGetUnlockSchedule(t *testing.T) {
+	now := time.Now().UTC()
+	var staked uint64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "get_currency_balance") {
+			_, _ = w.Write([]byte(`["1000.000000000 FIO"]`))
+			return
+		}
+		if strings.HasSuffix(r.URL.Path, "get_fio_balance") {
+			_, _ = fmt.Fprintf(w, `{"balance":1000000000000,"available":%d,"staked":%d}`, 1000000000000-staked, staked)
+			return
+		}
+		req := GetTableRowsOrderRequest{}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		switch req.Table {
+		case "accountmap":
+			_, _ = w.Write([]byte(`{"rows":[{"id":1,"account":"alohaaccount","clientkey":"FIO6MRyAjQq8ud7hVNYcfnVPJqcVpscN5So8BhtHuGYqET5GDW5CV"}]}`))
+		case "lockedtokens":
+			_, _ = fmt.Fprintf(w, `{"rows":[{"owner":"alohaaccount","total_grant_amount":100000000000,"grant_type":1,
+				"remaining_locked_amount":100000000000,"timestamp":%d}]}`, now.Add(-100*24*time.Hour).Unix())
+		case "locktokens":
+			_, _ = fmt.Fprintf(w, `{"rows":[{"id":1,"owner_account":"alohaaccount","lock_amount":300000000000,
+				"periods":[{"duration":86400,"percent":33.3},{"duration":172800,"percent":66.7}],"time_stamp":%d}]}`, now.Add(-time.Hour).Unix())
+		case "locktokensv2":
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(`{"code":500,"message":"Internal Service Error","error":{"code":3060003,"name":"contract_table_query_exception",
+				"what":"Contract Table Query Exception","details":[{"message":"Table locktokensv2 is not specified in the ABI"}]}}`))
+		}
+	}))
+	defer srv.Close()
+	api := &API{API: eos.New(srv.URL)}
+
+	s, err := api.GetUnlockSchedule("alohaaccount")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// genesis: 94 FIO still locked, FIP-6: 300 FIO
+	if s.Balance != Fio(1000) || s.Locked != Fio(394) || s.Available() != Fio(606) {
+		t.Errorf("unexpected schedule %+v", s)
+	}
+	if len(s.Unlocks) != 7 || s.Unlocks[0].Amount != MustParseAmount("99.9") || s.Unlocks[1].Amount != MustParseAmount("200.1") {
+		t.Fatalf("unexpected unlocks %+v", s.Unlocks)
+	}
+	last := s.Unlocks[len(s.Unlocks)-1]
+	if last.Available != Fio(1000) || last.Source != UnlockGenesis {
+		t.Errorf("everything should be available after the last unlock %+v", last)
+	}
+	if s.AvailableAt(now.Add(36*time.Hour)) != MustParseAmount("705.9") || s.AvailableAt(now) != Fio(606) {
+		t.Error("unexpected available amount", s.AvailableAt(now.Add(36*time.Hour)))
+	}
+
+	// staked tokens are part of the balance, but are never available
+	staked = uint64(Fio(200))
+	if s, err = api.GetUnlockSchedule("alohaaccount"); err != nil {
+		t.Fatal(err)
+	}
+	if s.Balance != Fio(1000) || s.Staked != Fio(200) || s.Locked != Fio(394) || s.Available() != Fio(406) {
+		t.Errorf("unexpected schedule with staked tokens %+v", s)
+	}
+	if last = s.Unlocks[len(s.Unlocks)-1]; last.Available != Fio(800) {
+		t.Errorf("staked tokens should not be available after the last unlock %+v", last)
+	}
+	if s.AvailableAt(now.Add(36*time.Hour)) != MustParseAmount("505.9") {
+		t.Error("unexpected available amount with staked tokens", s.AvailableAt(now.Add(36*time.Hour)))
+	}
+}
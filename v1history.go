@@ -11,16 +11,20 @@ import (
 	"strings"
 )
 
-// BlockTxidsResp contains a list of transactions in a block.
-type BlockTxidsResp struct {
-	Ids                   []eos.Checksum256 `json:"ids"`
-	LastIrreversibleBlock uint32             `json:"last_irreversible_block"`
+// V1History is a HistoryBackend that queries the /v1/history/* endpoints provided by the nodeos history plugin.
+type V1History struct {
+	api *API
 }
 
-// HistGetBlockTxids retrieves the txid for all transactions that occurred in a block from the v1 history plugin.
-func (api *API) HistGetBlockTxids(blockNum uint32) (*BlockTxidsResp, error) {
-	resp, err := api.HttpClient.Post(
-		api.BaseURL+"/v1/history/get_block_txids",
+// NewV1History returns a HistoryBackend using the v1 history plugin on the node the API is connected to.
+func NewV1History(api *API) *V1History {
+	return &V1History{api: api}
+}
+
+// GetBlockTxids retrieves the txid for all transactions that occurred in a block.
+func (v1 *V1History) GetBlockTxids(blockNum uint32) (*BlockTxidsResp, error) {
+	resp, err := v1.api.HttpClient.Post(
+		v1.api.BaseURL+"/v1/history/get_block_txids",
 		"application/json",
 		bytes.NewReader([]byte(fmt.Sprintf(`{"block_num": %d}`, blockNum))),
 	)
@@ -40,10 +44,10 @@ func (api *API) HistGetBlockTxids(blockNum uint32) (*BlockTxidsResp, error) {
 	return blocks, nil
 }
 
-// GetTransaction duplicates eos-go's GetTransaction. TODO: is this redundant? Can it be removed?
-func (api *API) GetTransaction(id eos.Checksum256) (*eos.TransactionResp, error) {
-	resp, err := api.HttpClient.Post(
-		api.BaseURL+"/v1/history/get_transaction",
+// GetTransaction fetches a transaction and its action traces.
+func (v1 *V1History) GetTransaction(id eos.Checksum256) (*eos.TransactionResp, error) {
+	resp, err := v1.api.HttpClient.Post(
+		v1.api.BaseURL+"/v1/history/get_transaction",
 		"application/json",
 		bytes.NewReader([]byte(fmt.Sprintf(`{"id": "%s"}`, id.String()))),
 	)
@@ -64,48 +68,62 @@ func (api *API) GetTransaction(id eos.Checksum256) (*eos.TransactionResp, error)
 	return at, nil
 }
 
-// accountActionSequence is a truncated action trace used only for finding the highest sequence number
-type accountActionSequence struct {
-	AccountActionSequence uint32 `json:"account_action_seq"`
-}
-
-type accountActions struct {
-	Actions []accountActionSequence `json:"actions"`
-}
-
-// GetMaxActions returns the highest account_action_sequence from the get_actions endpoint.
-// This is needed because paging only works with positive offsets.
-func (api *API) GetMaxActions(account eos.AccountName) (highest uint32, err error) {
-	resp, err := api.HttpClient.Post(
-		api.BaseURL+"/v1/history/get_actions",
+// GetActions fetches action traces for an account using get_actions.
+func (v1 *V1History) GetActions(account eos.AccountName, pos int64, offset int64) (*eos.ActionsResp, error) {
+	resp, err := v1.api.HttpClient.Post(
+		v1.api.BaseURL+"/v1/history/get_actions",
 		"application/json",
-		bytes.NewReader([]byte(fmt.Sprintf(`{"account_name":"%s","pos":-1}`, account))),
+		bytes.NewReader([]byte(fmt.Sprintf(`{"account_name":"%s","pos":%d,"offset":%d}`, account, pos, offset))),
 	)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 	defer resp.Body.Close()
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 	if len(body) == 0 {
-		return 0, errors.New("received empty response")
+		return nil, errors.New("received empty response")
 	}
+	actions := &eos.ActionsResp{}
+	err = json.Unmarshal(body, actions)
+	if err != nil {
+		return nil, err
+	}
+	return actions, nil
+}
+
+// HistGetBlockTxids retrieves the txid for all transactions that occurred in a block from the configured
+// HistoryBackend, by default this is the v1 history plugin.
+func (api *API) HistGetBlockTxids(blockNum uint32) (*BlockTxidsResp, error) {
+	return api.History().GetBlockTxids(blockNum)
+}
 
-	aa := &accountActions{}
-	err = json.Unmarshal(body, &aa)
+// GetTransaction fetches a transaction from the configured HistoryBackend, by default this is the v1 history plugin.
+func (api *API) GetTransaction(id eos.Checksum256) (*eos.TransactionResp, error) {
+	return api.History().GetTransaction(id)
+}
+
+// GetMaxActions returns the highest account_action_sequence from the configured HistoryBackend.
+// This is needed because paging only works with positive offsets.
+func (api *API) GetMaxActions(account eos.AccountName) (highest uint32, err error) {
+	aa, err := api.History().GetActions(account, -1, 0)
 	if err != nil {
 		return 0, err
 	}
 	if aa.Actions == nil || len(aa.Actions) == 0 {
 		return 0, nil
 	}
-	return aa.Actions[len(aa.Actions)-1].AccountActionSequence, nil
+	return uint32(aa.Actions[len(aa.Actions)-1].AccountSeq), nil
 }
 
-// HasHistory looks at available APIs and returns true if /v1/history/* exists.
+// HasHistory returns true if a HistoryBackend has been set with SetHistory, otherwise it looks at available APIs
+// and returns true if /v1/history/* exists.
 func (api *API) HasHistory() bool {
+	if api.history != nil {
+		return true
+	}
 	_, apis, err := api.GetSupportedApis()
 	if err != nil {
 		return false
@@ -125,7 +143,7 @@ func (api *API) HasHistory() bool {
 // Deprecated: a new endpoint that handles de-duplication will make this function irrelevant.
 func (api *API) GetActionsUniq(actor eos.AccountName, offset int64, pos int64) ([]*eos.ActionTrace, error) {
 	traceUniq := make(map[string]*eos.ActionTrace)
-	resp, err := api.GetActions(eos.GetActionsRequest{AccountName: actor, Offset: offset, Pos: pos})
+	resp, err := api.History().GetActions(actor, pos, offset)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,243 @@
+package fio
+
+import (
+	"fmt"
+	"github.com/fioprotocol/fio-go/eos"
+	"strings"
+)
+
+// Reason codes used in a ValidationReason
+const (
+	ReasonEmpty          = "empty"
+	ReasonTooShort       = "too_short"
+	ReasonTooLong        = "too_long"
+	ReasonInvalidChars   = "invalid_characters"
+	ReasonMissingAt      = "missing_at"
+	ReasonMultipleAt     = "multiple_at"
+	ReasonEmptyName      = "empty_name"
+	ReasonEmptyDomain    = "empty_domain"
+	ReasonLeadingHyphen  = "leading_hyphen"
+	ReasonTrailingHyphen = "trailing_hyphen"
+	ReasonDoubleHyphen   = "double_hyphen"
+	ReasonInvalidKey     = "invalid_public_key"
+)
+
+// Limits enforced by the fio.address contract
+const (
+	MinAddressLength       = 3
+	MaxAddressLength       = 64
+	MinDomainLength        = 1
+	MaxDomainLength        = 62
+	MaxChainCodeLength     = 10
+	MaxTokenCodeLength     = 10
+	MaxPublicAddressLength = 128
+)
+
+// ValidationReason is a single rule that a value did not satisfy
+type ValidationReason struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ValidationError lists all of the reasons a value is invalid
+type ValidationError struct {
+	Field   string             `json:"field"`
+	Value   string             `json:"value"`
+	Reasons []ValidationReason `json:"reasons"`
+}
+
+func (ve *ValidationError) Error() string {
+	msgs := make([]string, len(ve.Reasons))
+	for i := range ve.Reasons {
+		msgs[i] = ve.Reasons[i].Message
+	}
+	return fmt.Sprintf("invalid %s %q: %s", ve.Field, ve.Value, strings.Join(msgs, ", "))
+}
+
+// Has checks if the error includes a reason code
+func (ve *ValidationError) Has(code string) bool {
+	for _, r := range ve.Reasons {
+		if r.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+func (ve *ValidationError) add(code string, format string, a ...interface{}) {
+	ve.Reasons = append(ve.Reasons, ValidationReason{Code: code, Message: fmt.Sprintf(format, a...)})
+}
+
+// err returns nil if there were no reasons, so it can be returned directly as an error.
+func (ve *ValidationError) err() error {
+	if len(ve.Reasons) == 0 {
+		return nil
+	}
+	return ve
+}
+
+// checkNamePart applies the character and hyphen rules shared by the name and domain portions of a FIO address
+func (ve *ValidationError) checkNamePart(part string, label string) {
+	for _, r := range part {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-') {
+			ve.add(ReasonInvalidChars, "%s may only contain a-z, 0-9 and '-', found %q", label, r)
+			break
+		}
+	}
+	if strings.HasPrefix(part, "-") {
+		ve.add(ReasonLeadingHyphen, "%s cannot begin with a hyphen", label)
+	}
+	if strings.HasSuffix(part, "-") {
+		ve.add(ReasonTrailingHyphen, "%s cannot end with a hyphen", label)
+	}
+	if strings.Contains(part, "--") {
+		ve.add(ReasonDoubleHyphen, "%s cannot contain consecutive hyphens", label)
+	}
+}
+
+// ValidateAddress checks a FIO address (handle) against the contract's rules, and returns the address folded to
+// lower-case. Rules:
+//    Length: 3 to 64 characters
+//    Format: name@domain, with exactly one @ and a non-empty name and domain
+//    Characters allowed: ASCII a-z0-9 and - (dash), case-insensitive
+//    A dash cannot begin or end the name or domain, and cannot be repeated
+func ValidateAddress(address string) (Address, error) {
+	ve := &ValidationError{Field: "fio_address", Value: address}
+	folded := strings.ToLower(address)
+	switch {
+	case len(folded) == 0:
+		ve.add(ReasonEmpty, "address is empty")
+		return "", ve
+	case len(folded) < MinAddressLength:
+		ve.add(ReasonTooShort, "must be at least %d characters", MinAddressLength)
+	case len(folded) > MaxAddressLength:
+		ve.add(ReasonTooLong, "must be no more than %d characters", MaxAddressLength)
+	}
+	parts := strings.Split(folded, "@")
+	switch {
+	case len(parts) == 1:
+		ve.add(ReasonMissingAt, "must be in the format name@domain")
+		ve.checkNamePart(folded, "name")
+	case len(parts) > 2:
+		ve.add(ReasonMultipleAt, "only one @ is allowed")
+	default:
+		if parts[0] == "" {
+			ve.add(ReasonEmptyName, "name before the @ is empty")
+		} else {
+			ve.checkNamePart(parts[0], "name")
+		}
+		if parts[1] == "" {
+			ve.add(ReasonEmptyDomain, "domain after the @ is empty")
+		} else {
+			ve.checkNamePart(parts[1], "domain")
+		}
+	}
+	return Address(folded), ve.err()
+}
+
+// ValidateDomain checks a FIO domain against the contract's rules, and returns the domain folded to lower-case. Rules:
+//    Length: 1 to 62 characters
+//    Characters allowed: ASCII a-z0-9 and - (dash), case-insensitive
+//    A dash cannot begin or end the domain, and cannot be repeated
+func ValidateDomain(domain string) (string, error) {
+	ve := &ValidationError{Field: "fio_domain", Value: domain}
+	folded := strings.ToLower(domain)
+	switch {
+	case len(folded) < MinDomainLength:
+		ve.add(ReasonEmpty, "domain is empty")
+		return "", ve
+	case len(folded) > MaxDomainLength:
+		ve.add(ReasonTooLong, "must be no more than %d characters", MaxDomainLength)
+	}
+	ve.checkNamePart(folded, "domain")
+	return folded, ve.err()
+}
+
+// validateCode handles chain and token codes, which are 1-10 alphanumeric characters
+func validateCode(field string, code string, max int, wildcard bool) error {
+	ve := &ValidationError{Field: field, Value: code}
+	switch {
+	case len(code) == 0:
+		ve.add(ReasonEmpty, "code is empty")
+		return ve
+	case wildcard && code == "*":
+		return nil
+	case len(code) > max:
+		ve.add(ReasonTooLong, "must be no more than %d characters", max)
+	}
+	for _, r := range code {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			ve.add(ReasonInvalidChars, "may only contain A-Z and 0-9, found %q", r)
+			break
+		}
+	}
+	return ve.err()
+}
+
+// ValidateChainCode checks a chain code (1-10 characters, A-Z0-9, case-insensitive)
+func ValidateChainCode(chain string) error {
+	return validateCode("chain_code", chain, MaxChainCodeLength, false)
+}
+
+// ValidateTokenCode checks a token code (1-10 characters, A-Z0-9, case-insensitive), a "*" wildcard matching any
+// token on the chain is also allowed
+func ValidateTokenCode(token string) error {
+	return validateCode("token_code", token, MaxTokenCodeLength, true)
+}
+
+// ValidatePublicAddress checks that a public address is 1-128 characters and does not contain whitespace.
+func ValidatePublicAddress(publicAddress string) error {
+	ve := &ValidationError{Field: "public_address", Value: publicAddress}
+	switch {
+	case len(publicAddress) == 0:
+		ve.add(ReasonEmpty, "public address is empty")
+	case len(publicAddress) > MaxPublicAddressLength:
+		ve.add(ReasonTooLong, "must be no more than %d characters", MaxPublicAddressLength)
+	}
+	if strings.ContainsAny(publicAddress, " \t\r\n") {
+		ve.add(ReasonInvalidChars, "cannot contain whitespace")
+	}
+	return ve.err()
+}
+
+// ValidateTokenPubAddr validates and normalizes a public address mapping. If only one of the chain or token code is
+// provided it is used for both.
+func ValidateTokenPubAddr(tpa TokenPubAddr) (TokenPubAddr, error) {
+	if tpa.ChainCode == "" {
+		tpa.ChainCode = tpa.TokenCode
+	} else if tpa.TokenCode == "" {
+		tpa.TokenCode = tpa.ChainCode
+	}
+	if err := ValidateChainCode(tpa.ChainCode); err != nil {
+		return tpa, err
+	}
+	if err := ValidateTokenCode(tpa.TokenCode); err != nil {
+		return tpa, err
+	}
+	return tpa, ValidatePublicAddress(tpa.PublicAddress)
+}
+
+// validateAddressField is ValidateAddress for a field that isn't named fio_address, such as payer_fio_address
+func validateAddressField(field string, address string) error {
+	_, err := ValidateAddress(address)
+	if ve, ok := err.(*ValidationError); ok {
+		ve.Field = field
+	}
+	return err
+}
+
+// validatePubKey ensures a FIO public key is well formed
+func validatePubKey(field string, pubKey string) error {
+	if _, err := ActorFromPub(pubKey); err != nil {
+		ve := &ValidationError{Field: field, Value: pubKey}
+		ve.add(ReasonInvalidKey, "%s", err.Error())
+		return ve
+	}
+	return nil
+}
+
+// validName checks an account, permission or action name, invalid characters do not survive a round trip
+func validName(name string) bool {
+	n, err := eos.StringToName(name)
+	return err == nil && name != "" && eos.NameToString(n) == name
+}
@@ -0,0 +1,179 @@
+package fio
+
+import (
+	"testing"
+)
+
+func TestValidateAddress(t *testing.T) {
+	addr, err := ValidateAddress("Alice@FIOTestnet")
+	if err != nil || addr != "alice@fiotestnet" {
+		t.Error("expected address to be folded to lower case", addr, err)
+	}
+
+	reasons := map[string]string{
+		"":                       ReasonEmpty,
+		"a@":                     ReasonEmptyDomain,
+		"@ab":                    ReasonEmptyName,
+		"alice":                  ReasonMissingAt,
+		"a@b@c":                  ReasonMultipleAt,
+		"al--ice@test":           ReasonDoubleHyphen,
+		"-alice@test":            ReasonLeadingHyphen,
+		"alice@test-":            ReasonTrailingHyphen,
+		"al_ice@test":            ReasonInvalidChars,
+		"ab":                     ReasonTooShort,
+		string(make([]byte, 65)): ReasonTooLong,
+	}
+	for value, reason := range reasons {
+		_, err := ValidateAddress(value)
+		ve, ok := err.(*ValidationError)
+		if !ok {
+			t.Errorf("%q: expected a *ValidationError, got %v", value, err)
+			continue
+		}
+		if !ve.Has(reason) {
+			t.Errorf("%q: expected reason %s, got %v", value, reason, ve.Reasons)
+		}
+	}
+
+	// all of the problems should be reported, not only the first
+	_, err = ValidateAddress("-al--ice@")
+	if ve, ok := err.(*ValidationError); !ok || len(ve.Reasons) != 3 {
+		t.Error("expected three reasons", err)
+	}
+}
+
+func TestValidateDomain(t *testing.T) {
+	if d, err := ValidateDomain("FIOTestnet"); err != nil || d != "fiotestnet" {
+		t.Error("expected domain to be folded to lower case", d, err)
+	}
+	for _, bad := range []string{"", "has@at", "-lead", "trail-", "dou--ble", "under_score", string(make([]byte, 63))} {
+		if _, err := ValidateDomain(bad); err == nil {
+			t.Errorf("%q should be an invalid domain", bad)
+		}
+	}
+}
+
+func TestValidateTokenPubAddr(t *testing.T) {
+	tpa, err := ValidateTokenPubAddr(TokenPubAddr{TokenCode: "BTC", PublicAddress: "bc1qtest"})
+	if err != nil || tpa.ChainCode != "BTC" {
+		t.Error("expected chain code to be copied from token code", tpa, err)
+	}
+	if _, err = ValidateTokenPubAddr(TokenPubAddr{ChainCode: "ETH", TokenCode: "*", PublicAddress: "0xtest"}); err != nil {
+		t.Error("wildcard token code should be allowed", err)
+	}
+	bad := []TokenPubAddr{
+		{ChainCode: "*", TokenCode: "*", PublicAddress: "0xtest"},
+		{ChainCode: "ELEVENCHARS", TokenCode: "ETH", PublicAddress: "0xtest"},
+		{ChainCode: "E-TH", TokenCode: "ETH", PublicAddress: "0xtest"},
+		{ChainCode: "ETH", TokenCode: "ETH", PublicAddress: "0x test"},
+		{ChainCode: "ETH", TokenCode: "ETH", PublicAddress: ""},
+		{ChainCode: "ETH", TokenCode: "ETH", PublicAddress: string(make([]byte, 129))},
+	}
+	for _, b := range bad {
+		if _, err = ValidateTokenPubAddr(b); err == nil {
+			t.Error("expected invalid public address mapping", b)
+		}
+	}
+}
+
+func TestNewValid(t *testing.T) {
+	acc, err := NewRandomAccount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = NewValidRegAddress(acc.Actor, "test@test", acc.PubKey); err != nil {
+		t.Error(err)
+	}
+	_, err = NewValidRegAddress(acc.Actor, "test@test", "EOS1234")
+	if ve, ok := err.(*ValidationError); !ok || !ve.Has(ReasonInvalidKey) {
+		t.Error("expected invalid key", err)
+	}
+	if _, err = NewValidRegDomain(acc.Actor, "bad--domain", acc.PubKey); err == nil {
+		t.Error("expected invalid domain")
+	}
+	if _, err = NewValidAddAddresses(acc.Actor, "test@test", make([]TokenPubAddr, 6)); err == nil {
+		t.Error("should not allow more than five addresses")
+	}
+	act, err := NewValidAddAddress(acc.Actor, "test@test", "USDT", "ETH", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	aa := act.Data.(AddAddress)
+	if aa.PublicAddresses[0].ChainCode != "ETH" || aa.PublicAddresses[0].TokenCode != "USDT" {
+		t.Error("chain and token codes are swapped", aa.PublicAddresses[0])
+	}
+	if _, ok := NewAddAddress(acc.Actor, "test@test", "ETH", "ETH", "0x test"); ok {
+		t.Error("expected NewAddAddress to reject whitespace")
+	}
+	toRemove := []TokenPubAddr{{TokenCode: "eth", PublicAddress: "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"}}
+	act, err = NewRemoveAddrReq("Test@Test", toRemove, acc.Actor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ra := act.Data.(RemoveAddrReq)
+	if ra.FioAddress != "test@test" || ra.PublicAddresses[0].ChainCode != "eth" {
+		t.Error("remove request was not normalized", ra)
+	}
+	if toRemove[0].ChainCode != "" {
+		t.Error("caller's slice was modified")
+	}
+}
+
+func TestNewValidBuilders(t *testing.T) {
+	acc, err := NewRandomAccount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hasField := func(err error, field string) bool {
+		ve, ok := err.(*ValidationError)
+		return ok && ve.Field == field
+	}
+	if _, err = NewValidFundsReq(acc.Actor, "payer@test", "bad--payee@test", "content"); !hasField(err, "payee_fio_address") {
+		t.Error("expected invalid payee", err)
+	}
+	if _, err = NewValidFundsReq(acc.Actor, "payer@test", "payee@test", "content"); err != nil {
+		t.Error(err)
+	}
+	if _, err = NewValidRecordSend(acc.Actor, "x", "payer@test", "payee@test", "content"); err == nil {
+		t.Error("expected invalid request id")
+	}
+	if _, err = NewValidRecordSend(acc.Actor, "", "payer", "payee@test", "content"); !hasField(err, "payer_fio_address") {
+		t.Error("expected invalid payer", err)
+	}
+	if _, err = NewValidRecordSend(acc.Actor, "12", "payer@test", "payee@test", "content"); err != nil {
+		t.Error(err)
+	}
+	if _, err = NewValidVoteProducer([]string{"bp1@test", "bp2"}, acc.Actor, ""); !hasField(err, "producers") {
+		t.Error("expected invalid producer", err)
+	}
+	if _, err = NewValidVoteProducer([]string{"bp1@test"}, acc.Actor, ""); err != nil {
+		t.Error(err)
+	}
+	if _, err = NewValidRegProducer("bp@test", "FIO1234", "https://example.com", LocationEurope, acc.Actor); err == nil {
+		t.Error("expected invalid key")
+	}
+	if _, err = NewValidRegProducer("bp@test", acc.PubKey, "https://example.com", LocationEurope, acc.Actor); err != nil {
+		t.Error(err)
+	}
+	if _, err = NewValidUnRegProducer("bp@", acc.Actor); err == nil {
+		t.Error("expected invalid address")
+	}
+	if _, err = NewValidRegProxy("@test", acc.Actor); err == nil {
+		t.Error("expected invalid address")
+	}
+	if _, err = NewValidVoteProxy("proxy", "", acc.Actor); !hasField(err, "proxy") {
+		t.Error("expected invalid proxy", err)
+	}
+	if _, err = NewValidBpClaim("bp@test", acc.Actor); err != nil {
+		t.Error(err)
+	}
+	if _, err = NewValidAddNft("nft", []NftToAdd{{ChainCode: "ETH", ContractAddress: "0x1"}}, acc.Actor); err == nil {
+		t.Error("expected invalid address")
+	}
+	if _, err = NewValidRemNft("nft@test", nil, acc.Actor); err == nil {
+		t.Error("expected empty nft list to be rejected")
+	}
+	if _, err = NewValidRemAllNft("nft@test", acc.Actor); err != nil {
+		t.Error(err)
+	}
+}